@@ -29,10 +29,12 @@ type SentPacketHandler interface {
 	OnAlarm()
 
 	DuplicatePacket(packet *Packet)
+	ReinjectPacketsInFlight()
 
 	ComputeRTOTimeout() time.Duration
 
 	GetStatistics() (uint64, uint64, uint64)
+	GetReinjections() uint64
 
 	GetBytesInFlight() protocol.ByteCount
 	GetPacketsInFlight() []*Packet
@@ -51,3 +53,10 @@ type ReceivedPacketHandler interface {
 	GetStatistics() (uint64, uint64)
 	SentRecoveredFrame(f *wire.RecoveredFrame)
 }
+
+// ReceivedSymbolHistory tracks the encoding symbol IDs received or recovered by a FEC receiver
+type ReceivedSymbolHistory interface {
+	ReceiveSymbol(protocol.SymbolNumber) error
+	DeleteUpTo(protocol.SymbolNumber)
+	GetSymbolRanges() []wire.SymbolRange
+}
@@ -17,6 +17,19 @@ type Packet struct {
 
 	SendTime time.Time
 	Duplicated 			bool
+	// Copies links the packet to its copies sent on the other paths, it is nil if the packet has no copies
+	Copies *PacketCopies
+}
+
+// PacketCopies links the copies of a packet sent on several paths. Once one of them is acknowledged or recovered,
+// the others are not retransmitted when they are lost.
+type PacketCopies struct {
+	settled bool
+}
+
+// Settled returns true if one of the copies has been acknowledged or recovered
+func (c *PacketCopies) Settled() bool {
+	return c != nil && c.settled
 }
 
 // GetFramesForRetransmission gets all the frames for retransmission
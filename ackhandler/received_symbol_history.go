@@ -15,6 +15,11 @@ type receivedSymbolHistory struct {
 
 var errTooManyOutstandingReceivedSymbolRanges = qerr.Error(qerr.TooManyOutstandingReceivedSymbols, "Too many outstanding received Symbol ACK ranges")
 
+// NewReceivedSymbolHistory creates a new received symbol history
+func NewReceivedSymbolHistory() ReceivedSymbolHistory {
+	return newreceivedSymbolHistory()
+}
+
 func newreceivedSymbolHistory() *receivedSymbolHistory {
 	return &receivedSymbolHistory{
 		ranges: utils.NewSymbolIntervalList(),
@@ -0,0 +1,58 @@
+package ackhandler
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("receivedSymbolHistory", func() {
+	var (
+		hist *receivedSymbolHistory
+	)
+
+	BeforeEach(func() {
+		hist = newreceivedSymbolHistory()
+	})
+
+	It("has no ranges before receiving symbols", func() {
+		Expect(hist.GetSymbolRanges()).To(BeEmpty())
+	})
+
+	It("merges consecutive symbols", func() {
+		for _, p := range []protocol.SymbolNumber{4, 6, 5, 3} {
+			Expect(hist.ReceiveSymbol(p)).To(Succeed())
+		}
+		Expect(hist.GetSymbolRanges()).To(Equal([]wire.SymbolRange{{First: 3, Last: 6}}))
+	})
+
+	It("returns the ranges, the largest first", func() {
+		for _, p := range []protocol.SymbolNumber{1, 2, 5, 9, 10} {
+			Expect(hist.ReceiveSymbol(p)).To(Succeed())
+		}
+		Expect(hist.GetSymbolRanges()).To(Equal([]wire.SymbolRange{
+			{First: 9, Last: 10},
+			{First: 5, Last: 5},
+			{First: 1, Last: 2},
+		}))
+	})
+
+	It("deletes the old symbols", func() {
+		for _, p := range []protocol.SymbolNumber{1, 2, 3, 5, 6, 9} {
+			Expect(hist.ReceiveSymbol(p)).To(Succeed())
+		}
+		hist.DeleteUpTo(5)
+		Expect(hist.GetSymbolRanges()).To(Equal([]wire.SymbolRange{
+			{First: 9, Last: 9},
+			{First: 6, Last: 6},
+		}))
+	})
+
+	It("errors when tracking too many ranges", func() {
+		for i := 0; i < protocol.MaxTrackedReceivedSymbolAckRanges; i++ {
+			Expect(hist.ReceiveSymbol(protocol.SymbolNumber(2 * i))).To(Succeed())
+		}
+		Expect(hist.ReceiveSymbol(protocol.SymbolNumber(2*protocol.MaxTrackedReceivedSymbolAckRanges + 10))).To(MatchError(errTooManyOutstandingReceivedSymbolRanges))
+	})
+})
@@ -48,6 +48,9 @@ func IsFrameRetransmittable(f wire.Frame) bool {
 		// added by michelfra: FEC Frames and Unreliable Frames handling
 	case *wire.FECFrame:
 		return false
+	// the next SymbolAckFrame acknowledges the latest symbols again
+	case *wire.SymbolAckFrame:
+		return false
 	case *wire.StreamFrame:
 		return !f2.Unreliable || !f2.DeadlineExpired()
 	default:
@@ -126,6 +126,8 @@ type sentPacketHandler struct {
 	retransmissions uint64
 	// 丢失个数
 	losses uint64
+	// Packets moved away from this path before their loss was detected
+	reinjections uint64
 
 	// 是否启用FR，tlp必须启用FR
 	useFastRetransmit bool
@@ -692,6 +694,9 @@ func (h *sentPacketHandler) onPacketAcked(packetElement *PacketElement) {
 	h.rtoCount = 0
 	h.handshakeCount = 0
 	h.tlpCount = 0
+	if copies := packetElement.Value.Copies; copies != nil {
+		copies.settled = true
+	}
 	// 从history删除一个数据包
 	h.packetHistory.Remove(packetElement)
 }
@@ -830,7 +835,10 @@ func (h *sentPacketHandler) queueHandshakePacketsForRetransmission() {
 func (h *sentPacketHandler) queuePacketForRetransmission(packetElement *PacketElement) {
 	packet := &packetElement.Value
 	h.bytesInFlight -= packet.Length
-	h.retransmissionQueue = append(h.retransmissionQueue, packet)
+	// the frames of a packet are not retransmitted if one of its copies arrived on another path
+	if !packet.Copies.Settled() {
+		h.retransmissionQueue = append(h.retransmissionQueue, packet)
+	}
 	h.packetHistory.Remove(packetElement)
 	h.stopWaitingManager.QueuedRetransmissionForPacketNumber(packet.PacketNumber)
 }
@@ -840,6 +848,22 @@ func (h *sentPacketHandler) DuplicatePacket(packet *Packet) {
 	h.retransmissionQueue = append(h.retransmissionQueue, packet)
 }
 
+// ReinjectPacketsInFlight queues all packets in flight for retransmission, without waiting for
+// the loss detection alarm. It is used to move the packets of a degraded path to the other paths.
+// The packets are not reported as lost to the congestion controller.
+func (h *sentPacketHandler) ReinjectPacketsInFlight() {
+	for h.packetHistory.Len() > 0 {
+		h.queuePacketForRetransmission(h.packetHistory.Front())
+		h.reinjections++
+	}
+	h.updateLossDetectionAlarm()
+}
+
+// GetReinjections returns the number of packets reinjected on other paths
+func (h *sentPacketHandler) GetReinjections() uint64 {
+	return h.reinjections
+}
+
 // 每增加一次握手次数，duration会乘2
 func (h *sentPacketHandler) computeHandshakeTimeout() time.Duration {
 	duration := 2 * h.rttStats.SmoothedRTT()
@@ -634,6 +634,33 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.DequeuePacketForRetransmission()).To(BeNil())
 		})
 
+		It("reinjects all packets in flight", func() {
+			handler.ReinjectPacketsInFlight()
+			Expect(handler.packetHistory.Len()).To(BeZero())
+			Expect(handler.bytesInFlight).To(BeZero())
+			Expect(handler.retransmissionQueue).To(HaveLen(6))
+			Expect(handler.GetReinjections()).To(Equal(uint64(6)))
+			Expect(handler.GetAlarmTimeout()).To(BeZero())
+			_, _, lost := handler.GetStatistics()
+			Expect(lost).To(BeZero())
+		})
+
+		It("does not retransmit a lost packet whose copy was acknowledged", func() {
+			copies := &PacketCopies{}
+			copyHandler := NewSentPacketHandler(&congestion.RTTStats{}, nil, nil, func(_ protocol.PacketNumber) {}, func(_ protocol.PacketNumber) {}, false).(*sentPacketHandler)
+			copyHandler.SetHandshakeComplete()
+			Expect(handler.SentPacket(&Packet{PacketNumber: 8, Frames: []wire.Frame{&streamFrame}, Length: 1, Copies: copies})).To(Succeed())
+			Expect(copyHandler.SentPacket(&Packet{PacketNumber: 1, Frames: []wire.Frame{&streamFrame}, Length: 1, Copies: copies})).To(Succeed())
+			Expect(copyHandler.ReceivedAck(&wire.AckFrame{LargestAcked: 1, LowestAcked: 1}, 1, protocol.EncryptionForwardSecure, time.Now())).To(Succeed())
+			Expect(copies.Settled()).To(BeTrue())
+			handler.ReinjectPacketsInFlight()
+			Expect(handler.bytesInFlight).To(BeZero())
+			Expect(handler.retransmissionQueue).To(HaveLen(6))
+			for _, p := range handler.retransmissionQueue {
+				Expect(p.PacketNumber).ToNot(Equal(protocol.PacketNumber(8)))
+			}
+		})
+
 		Context("StopWaitings", func() {
 			It("gets a StopWaitingFrame", func() {
 				ack := wire.AckFrame{LargestAcked: 5, LowestAcked: 5}
@@ -189,6 +189,27 @@ func populateClientConfig(config *Config) *Config {
 		maxReceiveConnectionFlowControlWindow = protocol.DefaultMaxReceiveConnectionFlowControlWindowClient
 	}
 
+	numberOfFECPackets := config.NumberOfFECPackets
+	if numberOfFECPackets == 0 {
+		numberOfFECPackets = protocol.DefaultNumberOfFECPackets
+	}
+	numberOfRepairSymbols := config.NumberOfRepairSymbols
+	if numberOfRepairSymbols == 0 {
+		numberOfRepairSymbols = protocol.DefaultNumberOfRepairSymbols
+	}
+	numberOfInterleavedFECGroups := config.NumberOfInterleavedFECGroups
+	if numberOfInterleavedFECGroups == 0 {
+		numberOfInterleavedFECGroups = protocol.DefaultNumberOfInterleavedFECGroups
+	}
+	maxFECReceiveBufferSize := config.MaxFECReceiveBufferSize
+	if maxFECReceiveBufferSize == 0 {
+		maxFECReceiveBufferSize = uint64(protocol.DefaultMaxFECReceiveBufferSize)
+	}
+	convolutionalStepSize := config.ConvolutionalStepSize
+	if convolutionalStepSize == 0 {
+		convolutionalStepSize = protocol.DefaultConvolutionalStepSize
+	}
+
 	return &Config{
 		Versions:                              versions,
 		HandshakeTimeout:                      handshakeTimeout,
@@ -200,9 +221,19 @@ func populateClientConfig(config *Config) *Config {
 		CacheHandshake:                        config.CacheHandshake,
 		MaxPathID:                             config.MaxPathID,
 		MultipathService:                      config.MultipathService,
+		Scheduler:                             config.Scheduler,
 		NotifyID:                              config.NotifyID,
 		FECScheme:                             config.FECScheme,
 		RedundancyController:									 config.RedundancyController,
+		NewRedundancyController:               config.NewRedundancyController,
+		NumberOfFECPackets:                    numberOfFECPackets,
+		NumberOfRepairSymbols:                 numberOfRepairSymbols,
+		NumberOfInterleavedFECGroups:          numberOfInterleavedFECGroups,
+		ConvolutionalStepSize:                 convolutionalStepSize,
+		ConvolutionalWindowSize:               config.ConvolutionalWindowSize,
+		FECProtectionLevels:                   config.FECProtectionLevels,
+		FECPathPolicy:                         config.FECPathPolicy,
+		MaxFECReceiveBufferSize:               maxFECReceiveBufferSize,
 		DisableFECRecoveredFrames:						 config.DisableFECRecoveredFrames,
 		ProtectReliableStreamFrames:					 config.ProtectReliableStreamFrames,
 		UseFastRetransmit:										 config.UseFastRetransmit,
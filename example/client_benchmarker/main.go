@@ -18,7 +18,6 @@ import (
 
 	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/h2quic"
-	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
@@ -26,6 +25,7 @@ var fs quic.FECSchemeID
 var NUMBER_OF_SOURCE_SYMBOLS uint = 15
 var NUMBER_OF_REPAIR_SYMBOLS uint = 2
 var NUMBER_OF_INTERLEAVED_BLOCKS uint = 1
+var CONVOLUTIONAL_STEP_SIZE uint = 6
 var DISABLE_RECOVERED_FRAMES bool = false
 
 // var USE_FEC bool = false
@@ -121,7 +121,7 @@ func main() {
 		NUMBER_OF_SOURCE_SYMBOLS,             //20 source code
 		NUMBER_OF_REPAIR_SYMBOLS,             //10 repair code
 		NUMBER_OF_INTERLEAVED_BLOCKS,         //1 interleaved blocks
-		uint(protocol.ConvolutionalStepSize)) //6
+		CONVOLUTIONAL_STEP_SIZE)              //6
 	rrRQUIC := fec.NewrQuicRedundancyController(
 		uint8(NUMBER_OF_SOURCE_SYMBOLS),
 		uint8(NUMBER_OF_REPAIR_SYMBOLS))
@@ -14,7 +14,6 @@ import (
 
 	quic "github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/fec"
-	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
 const addr = "localhost:4242"
@@ -26,6 +25,7 @@ var fs quic.FECSchemeID
 var NUMBER_OF_SOURCE_SYMBOLS uint = 20
 var NUMBER_OF_REPAIR_SYMBOLS uint = 10
 var NUMBER_OF_INTERLEAVED_BLOCKS uint = 1
+var CONVOLUTIONAL_STEP_SIZE uint = 6
 var DISABLE_RECOVERED_FRAMES bool = true
 var USE_FEC bool = true
 var RS_WHEN_APPLICATION_LIMITED = false
@@ -73,7 +73,7 @@ func main() {
 		NUMBER_OF_SOURCE_SYMBOLS,             //20 source code
 		NUMBER_OF_REPAIR_SYMBOLS,             //10 repair code
 		NUMBER_OF_INTERLEAVED_BLOCKS,         //1 interleaved blocks
-		uint(protocol.ConvolutionalStepSize)) //6
+		CONVOLUTIONAL_STEP_SIZE)              //6
 
 	quicConfig := &quic.Config{
 		CacheHandshake:                    *cache,
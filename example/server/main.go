@@ -13,7 +13,6 @@ import (
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/h2quic"
-	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
 var certPath string
@@ -24,6 +23,7 @@ var NUMBER_OF_REPAIR_SYMBOLS uint = 1  //number of repair symbol
 
 // changed from (20,10) to (10,3)
 var NUMBER_OF_INTERLEAVED_BLOCKS uint = 1 //interlearved blocks, used in RLC
+var CONVOLUTIONAL_STEP_SIZE uint = 6
 var DISABLE_RECOVERED_FRAMES bool = false //disble recover frames
 
 var RS_WHEN_APPLICATION_LIMITED = false
@@ -136,7 +136,7 @@ func main() {
 		NUMBER_OF_SOURCE_SYMBOLS,             //20 source code
 		NUMBER_OF_REPAIR_SYMBOLS,             //10 repair code
 		NUMBER_OF_INTERLEAVED_BLOCKS,         //1 interleaved blocks
-		uint(protocol.ConvolutionalStepSize)) //6
+		CONVOLUTIONAL_STEP_SIZE)              //6
 	rrRQUIC := fec.NewrQuicRedundancyController(
 		uint8(NUMBER_OF_SOURCE_SYMBOLS),
 		uint8(NUMBER_OF_REPAIR_SYMBOLS))
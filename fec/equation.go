@@ -18,6 +18,22 @@ func NewEquation(symbol *RepairSymbol) *Equation {
 	}
 }
 
+// A LargeWindowFECScheme is a convolutional FEC scheme whose repair symbols tell the number of source symbols of
+// their encoding window, for windows larger than the 255 packets that the NumberOfPackets of the FEC frames can describe
+type LargeWindowFECScheme interface {
+	ConvolutionalFECScheme
+	GetNumberOfSourceSymbols(*RepairSymbol) int
+}
+
+// NewEquationForScheme returns the equation of a repair symbol generated by the FEC scheme f
+func NewEquationForScheme(f ConvolutionalFECScheme, symbol *RepairSymbol) *Equation {
+	eq := NewEquation(symbol)
+	if lw, ok := f.(LargeWindowFECScheme); ok {
+		eq.numberOfVars = lw.GetNumberOfSourceSymbols(symbol)
+	}
+	return eq
+}
+
 // the variables variables of the equation are a repair symbol on one side, and packets with consecutive fec payload IDs on the other side
 // R = Relation(P1, P2, P3, ..., Pn)
 type Equation struct {
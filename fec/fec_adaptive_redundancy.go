@@ -2,93 +2,306 @@ package fec
 
 // Added by zhaolee
 
-var NumberofRecoveredPacket int
+import (
+	"math"
+	"sync"
+	"time"
 
-var TempCount int = 0
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
 
-// var LossRate float32
+const (
+	// the weight of a new sample in the smoothed loss rate and burst length
+	adaptiveEstimationGain = 0.25
+	// the number of smoothed RTTs between two estimations
+	adaptiveEstimationRTTs = 3
+	// the loss rate must stay below this fraction of the threshold of a lower level to switch down to it
+	adaptiveHysteresis = 0.7
+	// the minimum number of estimations between a switch and a switch down
+	adaptiveMinimumEstimations = 3
+)
 
+// AdaptiveFEC is a redundancy controller that estimates the conditions of its path and uses the redundancy
+// controller fitting them
 type AdaptiveFEC interface {
-	GetLossRateAverage() (float32, error)
-	GetLossRate() error
+	RedundancyController
+	// returns the smoothed ratio of lost packets
+	GetLossRate() float64
+	// returns the smoothed number of consecutive lost packets
+	GetBurstLength() float64
+	// returns the smoothed RTT of the path
+	GetSmoothedRTT() time.Duration
+	// returns the redundancy controller currently used
 	GetAdaptiveRC() RedundancyController
+	// returns the number of times the redundancy controller has been switched
+	GetNumberOfSwitches() uint64
 }
 
+// An AdaptiveLevel is a redundancy controller used while the loss rate and the burst length stay below its thresholds
+type AdaptiveLevel struct {
+	MaxLossRate    float64
+	MaxBurstLength float64
+	Controller     RedundancyController
+}
+
+// An AdaptiveSwitch reports a change of level of an AdaptiveController, with the estimations that caused it
+type AdaptiveSwitch struct {
+	From, To    int
+	LossRate    float64
+	BurstLength float64
+	SmoothedRTT time.Duration
+	Time        time.Time
+}
+
+// AdaptiveController switches between redundancy levels, ordered from the least to the most protective, according
+// to the TransParams pushed by its path, to the bursts of lost packets and to the FEC blocks the peer failed to decode.
+// It switches up as soon as the estimations exceed the thresholds of the current level, but only switches down after
+// adaptiveMinimumEstimations estimations, and when the loss rate is well below the threshold of the lower level.
 type AdaptiveController struct {
-	NPacketTrans         *[]int
-	NPacketloss          *[]int
-	RealtimeRTT          *[]float32
-	LossRateAverage      float32
-	LossRate             *[]float32
-	RedundancyController RedundancyController
+	mutex sync.Mutex
+
+	levels  []AdaptiveLevel
+	current int
+
+	// onSwitch is called after every switch, e.g. to swap the controller of the session with Session.SetRedundancyController
+	onSwitch func(AdaptiveSwitch)
+
+	lastParams     TransParams
+	lastEstimation time.Time
+	hasSample      bool
+	lossRate       float64
+	burstLength    float64
+	smoothedRTT    time.Duration
+
+	lastLost     protocol.PacketNumber
+	currentBurst uint
+
+	estimationsSinceSwitch int
+	numberOfSwitches       uint64
 }
 
-// for pathID, pth := range f.sess.paths {
-// 	sntPkts, sntRetrans, sntLost := pth.sentPacketHandler.GetStatistics()
-// 	rcvPkts, recoveredPkts := pth.receivedPacketHandler.GetStatistics()
-// 	utils.Infof("Path %x: sent %d retrans %d lost %d; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, rcvPkts, recoveredPkts)
-// 	// modify -add
-// 	utils.Infof("Number of recovered packets in all: %d", fec.NumberofRecoveredPacket)
-// 	// utils.Infof("Redundancycontroller: D:%d,R:%d", s.redundancyController.GetNumberOfDataSymbols(), s.redundancyController.GetNumberOfRepairSymbols())
-// 	// utils.Infof("Redundancycontroller: D:%d,R:%d", s.fecFrameworkSender.redundancyController.GetNumberOfDataSymbols(), s.fecFrameworkSender.redundancyController.GetNumberOfRepairSymbols())
-// }
+var _ AdaptiveFEC = &AdaptiveController{}
+var _ DecodingFeedbackRedundancyController = &AdaptiveController{}
 
-type DetectedParameters struct {
-	sntPkts       int
-	sntRetrans    int
-	sntLost       int
-	rcvPkts       int
-	recoveredPkts int
+// NewAdaptiveController returns an AdaptiveController using the given levels, starting with the first one.
+// onSwitch may be nil.
+func NewAdaptiveController(levels []AdaptiveLevel, onSwitch func(AdaptiveSwitch)) *AdaptiveController {
+	if len(levels) == 0 {
+		panic("an AdaptiveController needs at least one level")
+	}
+	return &AdaptiveController{
+		levels:         levels,
+		onSwitch:       onSwitch,
+		lastEstimation: time.Now(),
+	}
 }
 
-func (d *DetectedParameters) LossRate() float32 {
-	return float32(d.sntLost) / float32(d.sntPkts)
+// NewDefaultAdaptiveController returns an AdaptiveController going from 1 repair symbol for 20 packets on clean paths
+// to 2 interleaved blocks of 5 source and 5 repair symbols on paths with long bursts of losses
+func NewDefaultAdaptiveController(onSwitch func(AdaptiveSwitch)) *AdaptiveController {
+	return NewAdaptiveController([]AdaptiveLevel{
+		{MaxLossRate: 0.01, MaxBurstLength: 1, Controller: NewConstantRedundancyController(20, 1, 1, 6)},
+		{MaxLossRate: 0.05, MaxBurstLength: 2, Controller: NewConstantRedundancyController(10, 2, 1, 4)},
+		{MaxLossRate: 0.1, MaxBurstLength: 4, Controller: NewConstantRedundancyController(10, 4, 1, 2)},
+		{MaxLossRate: math.Inf(1), MaxBurstLength: math.Inf(1), Controller: NewConstantRedundancyController(5, 5, 2, 2)},
+	}, onSwitch)
 }
 
-func (d *DetectedParameters) RetransRatio() float32 {
-	return float32(d.sntRetrans) / float32(d.sntPkts)
+func (t *AdaptiveController) OnPacketLost(pn protocol.PacketNumber) {
+	t.mutex.Lock()
+	if t.currentBurst > 0 && pn == t.lastLost+1 {
+		t.currentBurst++
+	} else {
+		t.endBurst()
+		t.currentBurst = 1
+	}
+	t.lastLost = pn
+	t.mutex.Unlock()
+	for _, level := range t.levels {
+		level.Controller.OnPacketLost(pn)
+	}
 }
 
-// func (d *DetectedParameters) Residual() float32{
-// 	return float32(d)
-// }
+func (t *AdaptiveController) OnPacketReceived(pn protocol.PacketNumber) {
+	for _, level := range t.levels {
+		level.Controller.OnPacketReceived(pn)
+	}
+}
 
-var _ AdaptiveFEC = &AdaptiveController{}
+func (t *AdaptiveController) GetNumberOfDataSymbols() uint {
+	return t.GetAdaptiveRC().GetNumberOfDataSymbols()
+}
+
+func (t *AdaptiveController) GetNumberOfRepairSymbols() uint {
+	return t.GetAdaptiveRC().GetNumberOfRepairSymbols()
+}
+
+func (t *AdaptiveController) GetNumberOfInterleavedBlocks() uint {
+	return t.GetAdaptiveRC().GetNumberOfInterleavedBlocks()
+}
 
-// change the redundancycontroller if neccesary, return it and set the 2nd parameter (ShouldChange flag) TRUE
-func MaybeChangeAndRenewReduandancyController(c RedundancyController) (RedundancyController, bool) {
-	// ...
-	// TODO
+func (t *AdaptiveController) GetWindowStepSize() uint {
+	return t.GetAdaptiveRC().GetWindowStepSize()
+}
 
-	return nil, false
+// PushParamerters updates the estimations every adaptiveEstimationRTTs smoothed RTTs, and switches the level if needed
+func (t *AdaptiveController) PushParamerters(paras TransParams) {
+	for _, level := range t.levels {
+		level.Controller.PushParamerters(paras)
+	}
+	t.mutex.Lock()
+	now := time.Now()
+	t.smoothedRTT = paras.SmoothedRTT
+	if now.Sub(t.lastEstimation) < adaptiveEstimationRTTs*paras.SmoothedRTT {
+		t.mutex.Unlock()
+		return
+	}
+	t.lastEstimation = now
+	sntPkts := paras.SntPkts - t.lastParams.SntPkts
+	sntLost := paras.SntLost - t.lastParams.SntLost
+	t.lastParams = paras
+	if sntPkts == 0 {
+		t.mutex.Unlock()
+		return
+	}
+	t.addLossRateSample(float64(sntLost) / float64(sntPkts))
+	if t.currentBurst > 0 {
+		t.endBurst()
+		t.currentBurst = 0
+	} else if sntLost == 0 {
+		// the bursts seen before fade out as the path stays clean
+		t.burstLength *= 1 - adaptiveEstimationGain
+	}
+	t.estimationsSinceSwitch++
+	sw, switched := t.maybeSwitch(now)
+	t.mutex.Unlock()
+	if switched {
+		t.notifySwitch(sw)
+	}
 }
 
-func TransLossRate() {}
+// OnFECBlockDecoded switches up to the next level as soon as the peer fails to decode a block, as the current level
+// does not protect the packets enough, whatever the estimations
+func (t *AdaptiveController) OnFECBlockDecoded(status wire.FECBlockStatus) {
+	for _, level := range t.levels {
+		if c, ok := level.Controller.(DecodingFeedbackRedundancyController); ok {
+			c.OnFECBlockDecoded(status)
+		}
+	}
+	if status.Status != wire.FECBlockFailed {
+		return
+	}
+	t.mutex.Lock()
+	if t.current == len(t.levels)-1 {
+		t.mutex.Unlock()
+		return
+	}
+	sw := t.switchTo(t.current+1, time.Now())
+	t.mutex.Unlock()
+	t.notifySwitch(sw)
+}
 
-func (t *AdaptiveController) GetLossRateAverage() (float32, error) {
-	// ...
-	return 0, nil
+func (t *AdaptiveController) GetLossRate() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.lossRate
 }
 
-func (t *AdaptiveController) GetLossRate() error {
-	// ...
+func (t *AdaptiveController) GetBurstLength() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.currentBurstLength()
+}
 
-	return nil
+func (t *AdaptiveController) GetSmoothedRTT() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.smoothedRTT
 }
 
 func (t *AdaptiveController) GetAdaptiveRC() RedundancyController {
-	// ...
-	return NewConstantRedundancyController(20, 10, 1, 6)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.levels[t.current].Controller
+}
+
+func (t *AdaptiveController) GetNumberOfSwitches() uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.numberOfSwitches
+}
+
+func (t *AdaptiveController) addLossRateSample(sample float64) {
+	if !t.hasSample {
+		t.lossRate = sample
+		t.hasSample = true
+		return
+	}
+	t.lossRate = (1-adaptiveEstimationGain)*t.lossRate + adaptiveEstimationGain*sample
 }
 
-// func (t *AdaptiveController) SavetoTxt(writeString string) {
+// endBurst takes the burst of losses that just ended into account in the smoothed burst length
+func (t *AdaptiveController) endBurst() {
+	if t.currentBurst == 0 {
+		return
+	}
+	if t.burstLength == 0 {
+		t.burstLength = float64(t.currentBurst)
+	} else {
+		t.burstLength = (1-adaptiveEstimationGain)*t.burstLength + adaptiveEstimationGain*float64(t.currentBurst)
+	}
+}
+
+// currentBurstLength returns the smoothed burst length, or the length of the ongoing burst if it is longer
+func (t *AdaptiveController) currentBurstLength() float64 {
+	return math.Max(t.burstLength, float64(t.currentBurst))
+}
+
+// maybeSwitch chooses the level fitting the estimations
+func (t *AdaptiveController) maybeSwitch(now time.Time) (AdaptiveSwitch, bool) {
+	burstLength := t.currentBurstLength()
+	target := t.current
+	if current := t.levels[t.current]; t.lossRate > current.MaxLossRate || burstLength > current.MaxBurstLength {
+		// switch up to the first level that fits
+		for target < len(t.levels)-1 && (t.lossRate > t.levels[target].MaxLossRate || burstLength > t.levels[target].MaxBurstLength) {
+			target++
+		}
+	} else if t.estimationsSinceSwitch >= adaptiveMinimumEstimations {
+		// switch down to the least protective level whose loss rate threshold is far enough
+		for i := 0; i < t.current; i++ {
+			if t.lossRate <= adaptiveHysteresis*t.levels[i].MaxLossRate && burstLength <= t.levels[i].MaxBurstLength {
+				target = i
+				break
+			}
+		}
+	}
+	if target == t.current {
+		return AdaptiveSwitch{}, false
+	}
+	return t.switchTo(target, now), true
+}
+
+// switchTo makes the level target the current one
+func (t *AdaptiveController) switchTo(target int, now time.Time) AdaptiveSwitch {
+	sw := AdaptiveSwitch{
+		From:        t.current,
+		To:          target,
+		LossRate:    t.lossRate,
+		BurstLength: t.currentBurstLength(),
+		SmoothedRTT: t.smoothedRTT,
+		Time:        now,
+	}
+	t.current = target
+	t.estimationsSinceSwitch = 0
+	t.numberOfSwitches++
+	return sw
+}
 
-// 	file, err := os.OpenFile("/home/zhaolee/go/src/github.com/lucas-clemente/quic-go/example/data/"+"test.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-// 	if err != nil {
-// 		panic(err)
-// 	}
-// 	defer file.Close()
-// 	write := bufio.NewWriter(file)
-// 	write.WriteString(writeString)
-// 	write.Flush()
-// }
+func (t *AdaptiveController) notifySwitch(sw AdaptiveSwitch) {
+	utils.Infof("Adaptive FEC: switching from level %d to %d (loss rate %f, burst length %f, RTT %s)", sw.From, sw.To, sw.LossRate, sw.BurstLength, sw.SmoothedRTT)
+	if t.onSwitch != nil {
+		t.onSwitch(sw)
+	}
+}
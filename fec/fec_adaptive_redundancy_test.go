@@ -0,0 +1,128 @@
+package fec
+
+import (
+	"math"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adaptive redundancy controller", func() {
+	var (
+		controller *AdaptiveController
+		switches   []AdaptiveSwitch
+		params     TransParams
+		low, high  RedundancyController
+	)
+
+	// send pushes the statistics of a period during which sent packets were sent and lost were lost.
+	// The smoothed RTT is zero, such that every push gives an estimation.
+	send := func(sent, lost uint64) {
+		params.SntPkts += sent
+		params.SntLost += lost
+		controller.PushParamerters(params)
+	}
+
+	BeforeEach(func() {
+		switches = nil
+		params = TransParams{}
+		low = NewConstantRedundancyController(20, 1, 1, 6)
+		high = NewConstantRedundancyController(5, 5, 2, 2)
+		controller = NewAdaptiveController([]AdaptiveLevel{
+			{MaxLossRate: 0.05, MaxBurstLength: 2, Controller: low},
+			{MaxLossRate: math.Inf(1), MaxBurstLength: math.Inf(1), Controller: high},
+		}, func(sw AdaptiveSwitch) {
+			switches = append(switches, sw)
+		})
+	})
+
+	It("starts with the first level", func() {
+		Expect(controller.GetAdaptiveRC()).To(Equal(low))
+		Expect(controller.GetNumberOfDataSymbols()).To(Equal(uint(20)))
+		Expect(controller.GetNumberOfRepairSymbols()).To(Equal(uint(1)))
+	})
+
+	It("estimates the loss rate", func() {
+		send(100, 2)
+		Expect(controller.GetLossRate()).To(Equal(0.02))
+		send(100, 6)
+		Expect(controller.GetLossRate()).To(BeNumerically("~", 0.75*0.02+0.25*0.06))
+	})
+
+	It("estimates the length of the bursts of losses", func() {
+		for _, pn := range []protocol.PacketNumber{3, 4, 5, 10} {
+			controller.OnPacketLost(pn)
+		}
+		Expect(controller.GetBurstLength()).To(Equal(3.0))
+	})
+
+	It("switches up as soon as the loss rate exceeds the threshold", func() {
+		send(100, 1)
+		Expect(switches).To(BeEmpty())
+		send(100, 40)
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+		Expect(controller.GetNumberOfInterleavedBlocks()).To(Equal(uint(2)))
+		Expect(switches).To(HaveLen(1))
+		Expect(switches[0].From).To(Equal(0))
+		Expect(switches[0].To).To(Equal(1))
+		Expect(switches[0].LossRate).To(BeNumerically(">", 0.05))
+		Expect(controller.GetNumberOfSwitches()).To(Equal(uint64(1)))
+	})
+
+	It("switches up when the bursts are too long", func() {
+		for pn := protocol.PacketNumber(1); pn <= 3; pn++ {
+			controller.OnPacketLost(pn)
+		}
+		send(1000, 3)
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+	})
+
+	It("switches down only when the loss rate stays well below the threshold", func() {
+		send(100, 40)
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+		// the loss rate is below the threshold of the first level, but not far enough
+		controller.lossRate = 0.045
+		for i := 0; i < 10; i++ {
+			send(100, 4)
+		}
+		Expect(controller.GetLossRate()).To(BeNumerically(">", adaptiveHysteresis*0.05))
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+		send(100, 0)
+		send(100, 0)
+		Expect(controller.GetAdaptiveRC()).To(Equal(low))
+		Expect(switches).To(HaveLen(2))
+		Expect(switches[1].From).To(Equal(1))
+		Expect(switches[1].To).To(Equal(0))
+	})
+
+	It("waits for a few estimations before switching down", func() {
+		send(100, 40)
+		controller.lossRate = 0
+		send(100, 0)
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+		for i := 1; i < adaptiveMinimumEstimations; i++ {
+			send(100, 0)
+		}
+		Expect(controller.GetAdaptiveRC()).To(Equal(low))
+	})
+
+	It("switches up as soon as the peer fails to decode a block", func() {
+		controller.OnFECBlockDecoded(wire.FECBlockStatus{FECBlockNumber: 1, Status: wire.FECBlockRecovered, NumberOfRecoveredPackets: 1})
+		Expect(controller.GetAdaptiveRC()).To(Equal(low))
+		controller.OnFECBlockDecoded(wire.FECBlockStatus{FECBlockNumber: 2, Status: wire.FECBlockFailed})
+		Expect(controller.GetAdaptiveRC()).To(Equal(high))
+		Expect(switches).To(HaveLen(1))
+		// there is no more protective level
+		controller.OnFECBlockDecoded(wire.FECBlockStatus{FECBlockNumber: 3, Status: wire.FECBlockFailed})
+		Expect(controller.GetNumberOfSwitches()).To(Equal(uint64(1)))
+	})
+
+	It("does not estimate before enough RTTs have passed", func() {
+		params.SmoothedRTT = 1 << 40
+		send(100, 40)
+		send(100, 40)
+		Expect(controller.GetNumberOfSwitches()).To(BeZero())
+	})
+})
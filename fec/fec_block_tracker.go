@@ -9,22 +9,27 @@ const DEFAULT_MAX_TRACE_BLOCKS = 5000
 const SYMBOL_GAP = 5
 
 type BlockTracker struct {
-	ReceivedFrames     map[protocol.FECBlockNumber][]map[protocol.FecFrameOffset]*wire.FECFrame
-	ReceivedSymbol     map[protocol.FECBlockNumber][]*RepairSymbol
-	lastReceivedSymbol protocol.NumberOfAckedSymbol
-	isExpiredBlocks    map[protocol.FECBlockNumber]bool
+	ReceivedFrames map[protocol.FECBlockNumber][]map[protocol.FecFrameOffset]*wire.FECFrame
+	// the number of repair symbols received for every tracked block
+	ReceivedSymbol          map[protocol.FECBlockNumber]int
+	numberOfReceivedSymbols protocol.NumberOfAckedSymbol
+	lastReceivedSymbol      protocol.NumberOfAckedSymbol
+	isExpiredBlocks         map[protocol.FECBlockNumber]bool
+	// the tracked blocks, from the oldest to the newest. No more than maxTrace blocks are tracked.
+	trackedBlocks []protocol.FECBlockNumber
+	maxTrace      int
 }
 
 func NewBlockTracker(maxTrace uint64) *BlockTracker {
-	// 暂时不用
 	if maxTrace == 0 {
 		maxTrace = DEFAULT_MAX_TRACE_BLOCKS
 	}
 
 	return &BlockTracker{
 		ReceivedFrames:  make(map[protocol.FECBlockNumber][]map[protocol.FecFrameOffset]*wire.FECFrame),
-		ReceivedSymbol:  make(map[protocol.FECBlockNumber][]*RepairSymbol),
+		ReceivedSymbol:  make(map[protocol.FECBlockNumber]int),
 		isExpiredBlocks: make(map[protocol.FECBlockNumber]bool),
+		maxTrace:        int(maxTrace),
 	}
 }
 
@@ -34,6 +39,7 @@ func (b *BlockTracker) ReceivedNewFECFrame(frame *wire.FECFrame) {
 	if _, ok := b.isExpiredBlocks[frame.FECBlockNumber]; ok {
 		return
 	}
+	b.track(frame.FECBlockNumber)
 	// add frame if not already present
 	FramesInBlock := b.ReceivedFrames[frame.FECBlockNumber]
 
@@ -51,15 +57,14 @@ func (b *BlockTracker) ReceivedNewFECFrame(frame *wire.FECFrame) {
 		// 定位Group--定位某个Symbol的--定位到具体的Frame
 		FramesInSymbol[frame.Offset] = frame
 	}
-	symbol, _, _ := b.UpdateNewlyConfirmedSymbols(frame)
+	symbol, _, numberOfRepairSymbols := b.UpdateNewlyConfirmedSymbols(frame)
 	if symbol != nil {
-		b.ReceivedSymbol[frame.FECBlockNumber] = append(b.ReceivedSymbol[frame.FECBlockNumber], symbol)
-	}
-	// 适当删除某个Block,注意是整个block一起删除
-	symbolsForTheBlock := b.ReceivedSymbol[frame.FECBlockNumber]
-	if len(symbolsForTheBlock) >= int(symbol.NumberOfRepairSymbols) {
-		delete(b.ReceivedFrames, frame.FECBlockNumber)
-		b.isExpiredBlocks[frame.FECBlockNumber] = true
+		b.ReceivedSymbol[frame.FECBlockNumber]++
+		b.numberOfReceivedSymbols++
+		// 适当删除某个Block,注意是整个block一起删除
+		if b.ReceivedSymbol[frame.FECBlockNumber] >= numberOfRepairSymbols {
+			b.Forget(frame.FECBlockNumber)
+		}
 	}
 
 	b.UpdateTackerByBlock()
@@ -174,11 +179,25 @@ func (b *BlockTracker) UpdateNewlyConfirmedSymbols(receivedFrame *wire.FECFrame)
 
 // 计算所有block统计的所有冗余包并返回
 func (b *BlockTracker) CountReceivedSymbol() protocol.NumberOfAckedSymbol {
-	var numSymbols protocol.NumberOfAckedSymbol
-	for _, Symbols := range b.ReceivedSymbol {
-		numSymbols += protocol.NumberOfAckedSymbol(len(Symbols))
+	return b.numberOfReceivedSymbols
+}
+
+// track adds the block fecBlockNumber to the tracked blocks if it is not tracked yet
+func (b *BlockTracker) track(fecBlockNumber protocol.FECBlockNumber) {
+	_, hasFrames := b.ReceivedFrames[fecBlockNumber]
+	_, hasSymbols := b.ReceivedSymbol[fecBlockNumber]
+	_, isExpired := b.isExpiredBlocks[fecBlockNumber]
+	if !hasFrames && !hasSymbols && !isExpired {
+		b.trackedBlocks = append(b.trackedBlocks, fecBlockNumber)
 	}
-	return numSymbols
+}
+
+// Forget drops the frames of the block fecBlockNumber and ignores its next frames, e.g. when the FEC receiver gave it up
+func (b *BlockTracker) Forget(fecBlockNumber protocol.FECBlockNumber) {
+	b.track(fecBlockNumber)
+	delete(b.ReceivedFrames, fecBlockNumber)
+	b.isExpiredBlocks[fecBlockNumber] = true
+	b.UpdateTackerByBlock()
 }
 
 // 返回一个SmybolACKFrame
@@ -197,11 +216,18 @@ func (b *BlockTracker) GetSymbolACKFrame() *wire.SymbolAckFrame {
 	return frame
 }
 
-// 如果track了超过5000个
+// 如果track了超过maxTrace个，forget the oldest blocks
 func (b *BlockTracker) UpdateTackerByBlock() {
-	if len(b.ReceivedFrames) < DEFAULT_MAX_TRACE_BLOCKS {
-		return
+	for len(b.trackedBlocks) > b.maxTrace {
+		oldest := b.trackedBlocks[0]
+		b.trackedBlocks = b.trackedBlocks[1:]
+		delete(b.ReceivedFrames, oldest)
+		delete(b.ReceivedSymbol, oldest)
+		delete(b.isExpiredBlocks, oldest)
 	}
-	// TODO:complete
-	// b.ReceivedFrames = b.ReceivedFrames[]
+}
+
+// NumberOfTrackedBlocks returns the number of blocks whose frames or symbols are tracked
+func (b *BlockTracker) NumberOfTrackedBlocks() int {
+	return len(b.trackedBlocks)
 }
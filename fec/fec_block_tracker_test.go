@@ -0,0 +1,66 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BlockTracker", func() {
+	var tracker *BlockTracker
+
+	// symbolFrame returns a FEC frame containing the full repair symbol symbolNumber of the block fecBlockNumber
+	symbolFrame := func(fecBlockNumber protocol.FECBlockNumber, symbolNumber uint8, numberOfRepairSymbols uint8) *wire.FECFrame {
+		return &wire.FECFrame{
+			FECBlockNumber:        fecBlockNumber,
+			RepairSymbolNumber:    symbolNumber,
+			NumberOfPackets:       4,
+			NumberOfRepairSymbols: numberOfRepairSymbols,
+			FinBit:                true,
+			Data:                  []byte{1, 2, 3},
+		}
+	}
+
+	BeforeEach(func() {
+		tracker = NewBlockTracker(3)
+	})
+
+	It("counts the received repair symbols", func() {
+		tracker.ReceivedNewFECFrame(symbolFrame(1, 0, 2))
+		tracker.ReceivedNewFECFrame(symbolFrame(1, 1, 2))
+		tracker.ReceivedNewFECFrame(symbolFrame(2, 0, 2))
+		Expect(tracker.CountReceivedSymbol()).To(Equal(protocol.NumberOfAckedSymbol(3)))
+		Expect(tracker.GetSymbolACKFrame().SymbolReceived).To(Equal(protocol.NumberOfAckedSymbol(3)))
+		Expect(tracker.GetSymbolACKFrame()).To(BeNil())
+	})
+
+	It("waits for all the frames of a repair symbol", func() {
+		first := symbolFrame(1, 0, 1)
+		first.FinBit = false
+		tracker.ReceivedNewFECFrame(first)
+		Expect(tracker.CountReceivedSymbol()).To(BeZero())
+		last := symbolFrame(1, 0, 1)
+		last.Offset = 1
+		tracker.ReceivedNewFECFrame(last)
+		Expect(tracker.CountReceivedSymbol()).To(Equal(protocol.NumberOfAckedSymbol(1)))
+	})
+
+	It("ignores the frames of the forgotten blocks", func() {
+		tracker.Forget(1)
+		tracker.ReceivedNewFECFrame(symbolFrame(1, 0, 2))
+		Expect(tracker.CountReceivedSymbol()).To(BeZero())
+	})
+
+	It("tracks a bounded number of blocks", func() {
+		for i := protocol.FECBlockNumber(0); i < 10; i++ {
+			// the first frames of the repair symbols stay until their last frames are received
+			frame := symbolFrame(i, 0, 2)
+			frame.FinBit = false
+			tracker.ReceivedNewFECFrame(frame)
+		}
+		Expect(tracker.NumberOfTrackedBlocks()).To(Equal(3))
+		Expect(tracker.ReceivedFrames).To(HaveLen(3))
+		Expect(tracker.ReceivedFrames).To(HaveKey(protocol.FECBlockNumber(9)))
+	})
+})
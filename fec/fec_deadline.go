@@ -0,0 +1,35 @@
+package fec
+
+import "time"
+
+// the number of RTTs between the sending of a packet and the reception of its retransmission: the packet is detected
+// as lost about one RTT after it was sent, and its retransmission arrives half an RTT later
+const retransmissionDelayRTTs = 1.5
+
+// CanRetransmitBeforeDeadline returns true if a lost packet can be retransmitted and received before its deadline
+func CanRetransmitBeforeDeadline(deadline time.Duration, rtt time.Duration) bool {
+	return float64(deadline) >= retransmissionDelayRTTs*float64(rtt)
+}
+
+// DeadlineFlushTime returns the latest time at which the repair symbols protecting a packet sent at sentTime must be
+// sent for the packet to be recovered before its deadline
+func DeadlineFlushTime(sentTime time.Time, deadline time.Duration, rtt time.Duration) time.Time {
+	return sentTime.Add(deadline - rtt/2)
+}
+
+// DeadlineRepairSymbols returns the number of repair symbols needed to protect numberOfSourceSymbols source symbols
+// that cannot be retransmitted before their deadline, such that the ratio of source symbols lost despite the repair
+// symbols stays below targetResidualLoss when the packets are lost independently at lossRate.
+// It returns maxRepairSymbols if the target cannot be met.
+func DeadlineRepairSymbols(numberOfSourceSymbols uint, lossRate float64, targetResidualLoss float64, maxRepairSymbols uint) uint {
+	for repair := uint(0); repair < maxRepairSymbols; repair++ {
+		n := int(numberOfSourceSymbols + repair)
+		// the state of a Gilbert-Elliott model going to the bad state with probability lossRate from both states
+		// does not depend on the previous one: the losses are independent
+		lossDistributions := gilbertElliottLossDistributions(lossRate, 1-lossRate, 1, n)
+		if residualLoss(lossDistributions[n], int(repair)) <= targetResidualLoss {
+			return repair
+		}
+	}
+	return maxRepairSymbols
+}
@@ -0,0 +1,45 @@
+package fec
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deadline-driven FEC", func() {
+	It("tells if a retransmission arrives before the deadline", func() {
+		Expect(CanRetransmitBeforeDeadline(200*time.Millisecond, 100*time.Millisecond)).To(BeTrue())
+		Expect(CanRetransmitBeforeDeadline(120*time.Millisecond, 100*time.Millisecond)).To(BeFalse())
+	})
+
+	It("flushes the repair symbols half an RTT before the deadline", func() {
+		now := time.Now()
+		Expect(DeadlineFlushTime(now, 100*time.Millisecond, 40*time.Millisecond)).To(Equal(now.Add(80 * time.Millisecond)))
+	})
+
+	Context("number of repair symbols", func() {
+		It("needs no repair symbol without losses", func() {
+			Expect(DeadlineRepairSymbols(10, 0, 0.01, 8)).To(BeZero())
+		})
+
+		It("protects more when the loss rate is higher", func() {
+			low := DeadlineRepairSymbols(10, 0.01, 0.001, 8)
+			high := DeadlineRepairSymbols(10, 0.1, 0.001, 8)
+			Expect(low).To(BeNumerically(">=", 1))
+			Expect(high).To(BeNumerically(">", low))
+		})
+
+		It("meets the target residual loss", func() {
+			repair := DeadlineRepairSymbols(10, 0.05, 0.001, 8)
+			n := 10 + int(repair)
+			Expect(residualLoss(gilbertElliottLossDistributions(0.05, 0.95, 1, n)[n], int(repair))).To(BeNumerically("<=", 0.001))
+			n--
+			Expect(residualLoss(gilbertElliottLossDistributions(0.05, 0.95, 1, n)[n], int(repair)-1)).To(BeNumerically(">", 0.001))
+		})
+
+		It("is bounded", func() {
+			Expect(DeadlineRepairSymbols(10, 0.5, 0.0001, 4)).To(Equal(uint(4)))
+		})
+	})
+})
@@ -24,6 +24,10 @@ type FECBlock struct {
 	version                    protocol.VersionNumber
 	TotalNumberOfPackets       int
 	TotalNumberOfRepairSymbols int
+	// the encoding symbol ID of the next repair symbol generated for this block by a rateless FEC scheme
+	nextRepairESI uint32
+	// the intermediate symbols of the RaptorQ FEC scheme, computed once for all the repair symbols of the block
+	intermediateSymbols [][]byte
 }
 
 var _ FECContainer = &FECBlock{}
@@ -72,7 +76,7 @@ func (f *FECBlock) PrepareToSend() error {
 	f.TotalNumberOfRepairSymbols = len(f.RepairSymbols)
 
 	for _, r := range f.RepairSymbols {
-		r.NumberOfPackets = uint8(f.TotalNumberOfPackets)
+		r.NumberOfPackets = uint16(f.TotalNumberOfPackets)
 		r.NumberOfRepairSymbols = uint8(f.TotalNumberOfRepairSymbols)
 
 	}
@@ -188,7 +192,7 @@ func (f *FECBlock) SetRepairSymbols(symbols []*RepairSymbol) {
 	for _, s := range symbols {
 		s.FECBlockNumber = f.FECBlockNumber
 		s.NumberOfRepairSymbols = uint8(len(symbols))
-		s.NumberOfPackets = uint8(f.CurrentNumberOfPackets())
+		s.NumberOfPackets = uint16(f.CurrentNumberOfPackets())
 	}
 	f.RepairSymbols = symbols
 }
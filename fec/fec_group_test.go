@@ -42,7 +42,6 @@ var _ = Describe("FECBlockNumber", func() {
 			})
 
 			It("adds packets successfully", func() {
-				protocol.NumberOfFecPackets = 10
 				Expect(fecGroup.TotalNumberOfPackets).To(Equal(0))
 				Expect(fecGroup.TotalNumberOfRepairSymbols).To(Equal(0))
 				fecGroup.AddPacket(packet1, hdr1)
@@ -101,7 +100,6 @@ var _ = Describe("FECBlockNumber", func() {
 		Context("Building FEC Frames from Repair Symbols", func() {
 
 			BeforeEach(func() {
-				protocol.NumberOfFecPackets = 5
 				fecGroup = NewFECGroup(42, versionIETFQUIC)
 				fecGroup.RepairSymbols = append(fecGroup.RepairSymbols, &RepairSymbol{
 					FECBlockNumber: 42,
@@ -117,7 +115,6 @@ var _ = Describe("FECBlockNumber", func() {
 		})
 		It("indicates that it should be sent when the payload is full", func() {
 			fecGroup = NewFECGroup(42, versionIETFQUIC)
-			protocol.NumberOfFecPackets = 5
 			Expect(fecGroup.HasPacket(1, 0)).To(BeFalse())
 			Expect(fecGroup.HasPacket(2, 0)).To(BeFalse())
 			Expect(fecGroup.HasPacket(3, 0)).To(BeFalse())
@@ -200,7 +197,6 @@ var _ = Describe("FECBlockNumber", func() {
 			})
 
 			It("adds packets successfully", func() {
-				protocol.NumberOfFecPackets = 10
 				Expect(fecGroup.TotalNumberOfPackets).To(Equal(0))
 				Expect(fecGroup.TotalNumberOfRepairSymbols).To(Equal(0))
 				fecGroup.AddPacket(packet1, hdr1)
@@ -259,7 +255,6 @@ var _ = Describe("FECBlockNumber", func() {
 		Context("Building FEC Frames from Repair Symbols", func() {
 
 			BeforeEach(func() {
-				protocol.NumberOfFecPackets = 5
 				fecGroup = NewFECGroup(42, versionGQUIC)
 				fecGroup.RepairSymbols = append(fecGroup.RepairSymbols, &RepairSymbol{
 					FECBlockNumber: 42,
@@ -275,7 +270,6 @@ var _ = Describe("FECBlockNumber", func() {
 		})
 		It("indicates that it should be sent when the payload is full", func() {
 			fecGroup = NewFECGroup(42, versionGQUIC)
-			protocol.NumberOfFecPackets = 5
 			Expect(fecGroup.HasPacket(1, 0)).To(BeFalse())
 			Expect(fecGroup.HasPacket(2, 0)).To(BeFalse())
 			Expect(fecGroup.HasPacket(3, 0)).To(BeFalse())
@@ -2,6 +2,7 @@ package fec
 
 import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
 )
 
 type RepairSymbol struct {
@@ -11,10 +12,17 @@ type RepairSymbol struct {
 	SymbolNumber      byte
 	Data              []byte // TODO: Maybe put this attr private, and do a GetData() method: some ECC may not be done incrementally but all at once, so random access should be prohibited
 
-	NumberOfPackets       uint8
+	// NumberOfPackets is the number of source symbols protected by the repair symbol. Only its low byte is carried by
+	// the FEC frames: the schemes protecting more than 255 source symbols carry it in their FEC scheme specific field.
+	NumberOfPackets       uint16
 	NumberOfRepairSymbols uint8
 	Convolutional         bool
 
 	// 用于RLC
 	EncodingSymbolID protocol.FECEncodingSymbolID
+	// The Repair FEC Payload ID of the FEC scheme, carried by the FEC frames instead of the FEC-QUIC one if set
+	RepairFECPayloadID wire.RepairFECPayloadID
+
+	// The path carrying the source symbols protected by this repair symbol
+	SourcePathID protocol.PathID
 }
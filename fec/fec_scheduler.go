@@ -12,19 +12,40 @@ type FECScheduler interface {
 	GetNextFECGroup() *FECBlock
 	GetNextFECGroupOffset() byte
 	SetRedundancyController(controller RedundancyController)
+	// returns the FEC groups holding packets, without moving to the next group
+	CurrentFECGroups() []*FECBlock
+}
+
+// A FECBlockNumberGenerator hands out FEC block numbers, it can be shared by several schedulers
+// such that their blocks never get the same number
+type FECBlockNumberGenerator struct {
+	next protocol.FECBlockNumber
+}
+
+const maxFECBlockNumber protocol.FECBlockNumber = (1 << 24) - 1
+
+// Peek returns the next FEC block number without consuming it
+func (g *FECBlockNumberGenerator) Peek() protocol.FECBlockNumber {
+	return g.next
+}
+
+// Pop returns the next FEC block number and consumes it
+func (g *FECBlockNumberGenerator) Pop() protocol.FECBlockNumber {
+	retVal := g.next
+	// blocknumber有界，是循环的
+	g.next = (g.next + 1) % maxFECBlockNumber
+	return retVal
 }
 
 type RoundRobinScheduler struct {
 	fecGroups []*FECBlock
-	// 最大blockNumber
-	maxFECBlockNumber protocol.FECBlockNumber
 	// 最大group数量
 	size uint
 	// ？指的groups的offset即group序号
 	// 当前的group序号
 	offset uint
 	// 下一个group的序号
-	nextFECBlockNumber protocol.FECBlockNumber
+	blockNumbers *FECBlockNumberGenerator
 	// ？建立起Scheduler各个group的索引
 	fecBlockNumberToIndex map[protocol.FECBlockNumber]uint
 	version               protocol.VersionNumber
@@ -34,13 +55,17 @@ type RoundRobinScheduler struct {
 var _ FECScheduler = &RoundRobinScheduler{}
 
 func NewRoundRobinScheduler(redundancyController RedundancyController, version protocol.VersionNumber) *RoundRobinScheduler {
+	return NewRoundRobinSchedulerWithBlockNumbers(redundancyController, version, &FECBlockNumberGenerator{})
+}
+
+// NewRoundRobinSchedulerWithBlockNumbers creates a RoundRobinScheduler numbering its FEC blocks with blockNumbers
+func NewRoundRobinSchedulerWithBlockNumbers(redundancyController RedundancyController, version protocol.VersionNumber, blockNumbers *FECBlockNumberGenerator) *RoundRobinScheduler {
 	return &RoundRobinScheduler{
 		redundancyController:  redundancyController,
 		fecGroups:             make([]*FECBlock, redundancyController.GetNumberOfInterleavedBlocks()),
 		size:                  0,
 		offset:                0,
-		nextFECBlockNumber:    0,
-		maxFECBlockNumber:     (1 << 24) - 1,
+		blockNumbers:          blockNumbers,
 		fecBlockNumberToIndex: make(map[protocol.FECBlockNumber]uint),
 		version:               version,
 	}
@@ -59,7 +84,7 @@ func (s *RoundRobinScheduler) SentFECBlock(group protocol.FECBlockNumber) {
 // post: the FECBlock of the returned FECBlockNumber can be added at least one more packet
 func (s *RoundRobinScheduler) GetNextFECBlockNumber() protocol.FECBlockNumber {
 	if s.size < s.redundancyController.GetNumberOfInterleavedBlocks() {
-		return s.nextFECBlockNumber
+		return s.blockNumbers.Peek()
 	}
 	return s.fecGroups[s.offset].FECBlockNumber
 }
@@ -93,17 +118,19 @@ func (s *RoundRobinScheduler) SetRedundancyController(c RedundancyController) {
 	s.redundancyController = c
 }
 
-// 返回下一个FECBlockNumber并合理增加一个
-func (s *RoundRobinScheduler) getNextFECBlockNumberAndThenIncrementIt() protocol.FECBlockNumber {
-	retVal := s.nextFECBlockNumber
-	// blocknumber有界，是循环的
-	s.nextFECBlockNumber = s.nextFECBlockNumber + 1%s.maxFECBlockNumber
-	return retVal
+func (s *RoundRobinScheduler) CurrentFECGroups() []*FECBlock {
+	var groups []*FECBlock
+	for i := 0; i < int(s.size) && i < len(s.fecGroups); i++ {
+		if group := s.fecGroups[i]; group != nil && group.CurrentNumberOfPackets() > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
 }
 
 // 创建新的group并建立在Scheduler中的映射关系
 func (s *RoundRobinScheduler) putNewFECGroupAtIndex(index uint) {
-	fecGroupNumber := s.getNextFECBlockNumberAndThenIncrementIt()
+	fecGroupNumber := s.blockNumbers.Pop()
 	if len(s.fecGroups) <= int(index) {
 		s.fecGroups = append(s.fecGroups, make([]*FECBlock, int(index)-len(s.fecGroups)+1)...)
 	}
@@ -56,6 +56,48 @@ var _ = Describe("FEC Scheduler", func() {
 			scheduler.GetNextFECGroup().AddPacket([]byte{}, &wire.Header{})
 			Expect(scheduler.GetNextFECGroupOffset()).To(Equal(byte(1)))
 		})
+
+		It("lists the FEC Groups holding packets", func() {
+			Expect(scheduler.CurrentFECGroups()).To(BeEmpty())
+			scheduler.GetNextFECGroup().AddPacket([]byte{1}, &wire.Header{PacketNumber: 1})
+			scheduler.GetNextFECGroup()
+			groups := scheduler.CurrentFECGroups()
+			Expect(groups).To(HaveLen(1))
+			Expect(groups[0].FECBlockNumber).To(Equal(protocol.FECBlockNumber(0)))
+			// listing the groups doesn't move the scheduler
+			Expect(scheduler.GetNextFECBlockNumber()).To(Equal(protocol.FECBlockNumber(0)))
+		})
+
+		It("interleaves the FEC Groups asked by the rQUIC redundancy controller", func() {
+			rc := NewrQuicRedundancyControllerWithInterleaving(4, 1, 2, 0)
+			Expect(rc.GetNumberOfInterleavedBlocks()).To(Equal(uint(2)))
+			Expect(rc.GetWindowStepSize()).To(Equal(uint(protocol.DefaultConvolutionalStepSize)))
+			scheduler = NewRoundRobinScheduler(rc, versionIETFQUIC)
+			Expect(scheduler.GetNextFECGroup()).To(Equal(fecGroup1))
+			Expect(scheduler.GetNextFECGroup()).To(Equal(fecGroup2))
+			Expect(scheduler.GetNextFECGroup()).To(Equal(fecGroup1))
+		})
+
+		It("starts the rQUIC redundancy controller with the given number of source symbols", func() {
+			rc := NewrQuicRedundancyController(4, 3)
+			Expect(rc.GetNumberOfDataSymbols()).To(Equal(uint(4)))
+			Expect(rc.GetNumberOfRepairSymbols()).To(Equal(uint(3)))
+			Expect(rc.GetNumberOfInterleavedBlocks()).To(Equal(uint(protocol.DefaultNumberOfInterleavedFECGroups)))
+			Expect(rc.GetWindowStepSize()).To(Equal(uint(protocol.DefaultConvolutionalStepSize)))
+			Expect(NewrQuicRedundancyController(0, 3).GetNumberOfDataSymbols()).To(Equal(uint(RCInit)))
+		})
+	})
+
+	It("shares the FEC block numbers between schedulers", func() {
+		blockNumbers := &FECBlockNumberGenerator{}
+		scheduler1 := NewRoundRobinSchedulerWithBlockNumbers(NewConstantRedundancyController(2, 1, 1, 1), versionIETFQUIC, blockNumbers)
+		scheduler2 := NewRoundRobinSchedulerWithBlockNumbers(NewConstantRedundancyController(4, 2, 1, 1), versionIETFQUIC, blockNumbers)
+		Expect(scheduler1.GetNextFECGroup().FECBlockNumber).To(Equal(protocol.FECBlockNumber(0)))
+		Expect(scheduler2.GetNextFECBlockNumber()).To(Equal(protocol.FECBlockNumber(1)))
+		Expect(scheduler2.GetNextFECGroup().FECBlockNumber).To(Equal(protocol.FECBlockNumber(1)))
+		scheduler1.SentFECBlock(0)
+		Expect(scheduler1.GetNextFECGroup().FECBlockNumber).To(Equal(protocol.FECBlockNumber(2)))
+		Expect(scheduler2.GetNextFECGroup().FECBlockNumber).To(Equal(protocol.FECBlockNumber(1)))
 	})
 
 	Context("for gQUIC", func() {
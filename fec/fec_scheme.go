@@ -2,6 +2,7 @@ package fec
 
 import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
 	"errors"
 	"unsafe"
 	"runtime"
@@ -27,6 +28,23 @@ type ConvolutionalFECScheme interface {
 	AddKnownVariable(*Variable) bool
 }
 
+// A RepairFECPayloadIDScheme is a ConvolutionalFECScheme that may identify its repair symbols in the FEC frames by its
+// own Repair FEC Payload ID, set in RepairSymbol.RepairFECPayloadID. GetRepairFECPayloadIDParser returns nil if the
+// scheme uses the FEC-QUIC Repair FEC Payload ID.
+type RepairFECPayloadIDScheme interface {
+	ConvolutionalFECScheme
+	GetRepairFECPayloadIDParser() wire.RepairFECPayloadIDParser
+}
+
+// GetRepairFECPayloadIDParser returns the parser of the Repair FEC Payload IDs of the FEC scheme f, or nil if it uses
+// the FEC-QUIC ones
+func GetRepairFECPayloadIDParser(f FECScheme) wire.RepairFECPayloadIDParser {
+	if fs, ok := f.(RepairFECPayloadIDScheme); ok {
+		return fs.GetRepairFECPayloadIDParser()
+	}
+	return nil
+}
+
 func FlushCurrentSymbols(f FECScheme, fc FECContainer, numberOfRepairSymbols uint)([]*RepairSymbol, error){
 	if fs, ok := f.(ConvolutionalFECScheme); ok {
 		window := fc.(*FECWindow)
@@ -0,0 +1,119 @@
+package fec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// A SchemeFactory creates a FEC scheme. It is called for every session using the scheme, as the schemes hold the
+// state of their decoder. The FEC scheme must be a BlockFECScheme or a ConvolutionalFECScheme.
+type SchemeFactory func() (FECScheme, error)
+
+var builtinSchemes = map[protocol.FECSchemeID]SchemeFactory{
+	protocol.XORFECScheme: func() (FECScheme, error) {
+		return &XORFECScheme{}, nil
+	},
+	protocol.ReedSolomonFECScheme: func() (FECScheme, error) {
+		return NewReedSolomonFECScheme()
+	},
+	protocol.RLCFECScheme: func() (FECScheme, error) {
+		return NewRandomLinearFECScheme(), nil
+	},
+	protocol.RLCRFC8681FECScheme: func() (FECScheme, error) {
+		return NewRFC8681RandomLinearFECScheme(), nil
+	},
+	protocol.RaptorQFECScheme: func() (FECScheme, error) {
+		return NewRaptorQFECScheme(), nil
+	},
+	protocol.RLCGF65536FECScheme: func() (FECScheme, error) {
+		return NewRandomLinearFECSchemeGF65536(), nil
+	},
+	protocol.XOR2DFECScheme: func() (FECScheme, error) {
+		return NewXOR2DFECScheme(), nil
+	},
+}
+
+var (
+	customSchemes      = make(map[protocol.FECSchemeID]SchemeFactory)
+	customSchemesMutex sync.RWMutex
+)
+
+// RegisterScheme makes a FEC scheme available under id, such that it can be selected with Config.FECScheme and
+// negotiated during the handshake. The IDs of the built-in FEC schemes cannot be overridden.
+func RegisterScheme(id protocol.FECSchemeID, factory SchemeFactory) error {
+	if _, ok := builtinSchemes[id]; ok {
+		return fmt.Errorf("FEC scheme %d is a built-in FEC scheme", id)
+	}
+	if factory == nil {
+		return fmt.Errorf("no factory given for the FEC scheme %d", id)
+	}
+	customSchemesMutex.Lock()
+	defer customSchemesMutex.Unlock()
+	customSchemes[id] = factory
+	return nil
+}
+
+// NewScheme creates the FEC scheme registered under id
+func NewScheme(id protocol.FECSchemeID) (FECScheme, error) {
+	factory, ok := builtinSchemes[id]
+	if !ok {
+		customSchemesMutex.RLock()
+		factory, ok = customSchemes[id]
+		customSchemesMutex.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("there is no FEC scheme with ID %d", id)
+	}
+	scheme, err := factory()
+	if err != nil {
+		return nil, err
+	}
+	switch scheme.(type) {
+	case BlockFECScheme, ConvolutionalFECScheme:
+		return scheme, nil
+	default:
+		return nil, fmt.Errorf("the FEC scheme %d is neither a block nor a convolutional FEC scheme", id)
+	}
+}
+
+// SupportedSchemes returns the IDs of the built-in and registered FEC schemes, in ascending order
+func SupportedSchemes() []protocol.FECSchemeID {
+	ids := make([]protocol.FECSchemeID, 0, len(builtinSchemes))
+	for id := range builtinSchemes {
+		ids = append(ids, id)
+	}
+	customSchemesMutex.RLock()
+	for id := range customSchemes {
+		ids = append(ids, id)
+	}
+	customSchemesMutex.RUnlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// NegotiateScheme returns the FEC scheme used to protect the packets sent to a decoder, given the preferred scheme and
+// the schemes supported by the decoder, in its order of preference, and the schemes supported by the encoder.
+// Both peers agree on the same scheme as they run it with the same arguments. It returns false if there is no
+// FEC scheme supported by both peers.
+func NegotiateScheme(preferred protocol.FECSchemeID, decoderSchemes []protocol.FECSchemeID, encoderSchemes []protocol.FECSchemeID) (protocol.FECSchemeID, bool) {
+	supportedByEncoder := func(id protocol.FECSchemeID) bool {
+		for _, candidate := range encoderSchemes {
+			if candidate == id {
+				return true
+			}
+		}
+		return false
+	}
+	if supportedByEncoder(preferred) {
+		return preferred, true
+	}
+	for _, id := range decoderSchemes {
+		if supportedByEncoder(id) {
+			return id, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,92 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC scheme registry", func() {
+	const customID protocol.FECSchemeID = 200
+
+	AfterEach(func() {
+		customSchemesMutex.Lock()
+		delete(customSchemes, customID)
+		customSchemesMutex.Unlock()
+	})
+
+	It("creates the built-in FEC schemes", func() {
+		fs, err := NewScheme(protocol.ReedSolomonFECScheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs).To(BeAssignableToTypeOf(&ReedSolomonFECScheme{}))
+		fs, err = NewScheme(protocol.XOR2DFECScheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs).To(BeAssignableToTypeOf(&XOR2DFECScheme{}))
+	})
+
+	It("creates a new instance for every call", func() {
+		fs1, err := NewScheme(protocol.RLCFECScheme)
+		Expect(err).ToNot(HaveOccurred())
+		fs2, err := NewScheme(protocol.RLCFECScheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs1).ToNot(BeIdenticalTo(fs2))
+	})
+
+	It("errors for unknown IDs", func() {
+		_, err := NewScheme(customID)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("registers custom FEC schemes", func() {
+		Expect(RegisterScheme(customID, func() (FECScheme, error) {
+			return NewRaptorQFECScheme(), nil
+		})).To(Succeed())
+		fs, err := NewScheme(customID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs).To(BeAssignableToTypeOf(&RaptorQFECScheme{}))
+		Expect(SupportedSchemes()).To(ContainElement(customID))
+	})
+
+	It("refuses to override the built-in FEC schemes", func() {
+		Expect(RegisterScheme(protocol.XORFECScheme, func() (FECScheme, error) {
+			return NewRaptorQFECScheme(), nil
+		})).ToNot(Succeed())
+		Expect(RegisterScheme(customID, nil)).ToNot(Succeed())
+	})
+
+	It("refuses FEC schemes that are neither block nor convolutional", func() {
+		Expect(RegisterScheme(customID, func() (FECScheme, error) {
+			return struct{}{}, nil
+		})).To(Succeed())
+		_, err := NewScheme(customID)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists the supported FEC schemes in ascending order", func() {
+		schemes := SupportedSchemes()
+		Expect(schemes).To(HaveLen(len(builtinSchemes)))
+		Expect(schemes[0]).To(Equal(protocol.XORFECScheme))
+		for i := 1; i < len(schemes); i++ {
+			Expect(schemes[i]).To(BeNumerically(">", schemes[i-1]))
+		}
+	})
+
+	Context("negotiation", func() {
+		It("uses the preferred FEC scheme of the decoder when the encoder supports it", func() {
+			id, ok := NegotiateScheme(protocol.RLCFECScheme, []protocol.FECSchemeID{0, 1, 2}, []protocol.FECSchemeID{0, 2})
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal(protocol.RLCFECScheme))
+		})
+
+		It("falls back to the first FEC scheme of the decoder that the encoder supports", func() {
+			id, ok := NegotiateScheme(customID, []protocol.FECSchemeID{customID, 1, 0}, []protocol.FECSchemeID{0, 1})
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal(protocol.ReedSolomonFECScheme))
+		})
+
+		It("fails when there is no FEC scheme in common", func() {
+			_, ok := NegotiateScheme(customID, []protocol.FECSchemeID{customID}, []protocol.FECSchemeID{0, 1})
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
@@ -26,7 +26,7 @@ var _ FECContainer = &FECWindow{}
 
 type FECWindow struct {
 	////modify  WindowSize = redundancyController.GetNumberOfDataSymbols()
-	WindowSize                 uint8
+	WindowSize                 uint16
 	RepairSymbols              []*RepairSymbol
 	nSentRepairSymbols         uint8
 	framesOffset               uint8
@@ -41,7 +41,7 @@ type FECWindow struct {
 	TotalNumberOfRepairSymbols int
 }
 
-func NewFECWindow(windowSize uint8, version protocol.VersionNumber) *FECWindow {
+func NewFECWindow(windowSize uint16, version protocol.VersionNumber) *FECWindow {
 	return &FECWindow{
 		WindowSize:    windowSize,
 		packetIndexes: make(map[protocol.PathID]map[protocol.PacketNumber]int),
@@ -105,7 +105,7 @@ func (f *FECWindow) SetRepairSymbols(symbols []*RepairSymbol) {
 	for _, s := range symbols {
 		s.EncodingSymbolID = f.currentIndex
 		s.NumberOfRepairSymbols = uint8(len(symbols))
-		s.NumberOfPackets = uint8(f.CurrentNumberOfPackets())
+		s.NumberOfPackets = uint16(f.CurrentNumberOfPackets())
 	}
 	f.RepairSymbols = symbols
 }
@@ -123,9 +123,29 @@ func (f *FECWindow) HasSomethingToSend() bool {
 }
 
 func (f *FECWindow) SetSize(s int) {
-	newRingBuffer := utils.NewPacketsRingBuffer(uint8(s))
+	f.WindowSize = uint16(utils.Min(s, int(protocol.MaxFECWindowSize)))
+	newRingBuffer := utils.NewPacketsRingBuffer(f.WindowSize)
 	for _, p := range f.packets.GetAll() {
 		newRingBuffer.AddPacket(p)
 	}
 	f.packets = newRingBuffer
 }
+
+// FirstEncodingSymbolID returns the encoding symbol ID of the oldest source symbol of the window
+func (f *FECWindow) FirstEncodingSymbolID() protocol.FECEncodingSymbolID {
+	return f.currentIndex - protocol.FECEncodingSymbolID(f.packets.CurrentSize()) + 1
+}
+
+// ReleaseUpTo removes the source symbols up to the encoding symbol ID id from the window, as the receiver
+// acknowledged them: the next repair symbols don't need to protect them anymore
+func (f *FECWindow) ReleaseUpTo(id protocol.FECEncodingSymbolID) {
+	first := f.FirstEncodingSymbolID()
+	if id < first {
+		return
+	}
+	if id > f.currentIndex {
+		id = f.currentIndex
+	}
+	f.packets.RemoveOldest(int(id - first + 1))
+	f.currentNumberOfPackets = f.packets.CurrentSize()
+}
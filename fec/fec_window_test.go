@@ -0,0 +1,58 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC window", func() {
+	var window *FECWindow
+
+	addPackets := func(n int) {
+		for i := 0; i < n; i++ {
+			id := window.currentIndex + 1
+			window.AddPacket([]byte{byte(id)}, &wire.Header{
+				PacketNumber: protocol.PacketNumber(id),
+				FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(id),
+			})
+		}
+	}
+
+	BeforeEach(func() {
+		window = NewFECWindow(5, protocol.VersionWhatever)
+	})
+
+	It("keeps the latest source symbols", func() {
+		addPackets(7)
+		Expect(window.CurrentNumberOfPackets()).To(Equal(5))
+		Expect(window.FirstEncodingSymbolID()).To(Equal(protocol.FECEncodingSymbolID(3)))
+		Expect(window.GetPackets()).To(Equal([][]byte{{3}, {4}, {5}, {6}, {7}}))
+	})
+
+	It("releases the acknowledged source symbols", func() {
+		addPackets(7)
+		window.ReleaseUpTo(4)
+		Expect(window.CurrentNumberOfPackets()).To(Equal(3))
+		Expect(window.FirstEncodingSymbolID()).To(Equal(protocol.FECEncodingSymbolID(5)))
+		Expect(window.GetPackets()).To(Equal([][]byte{{5}, {6}, {7}}))
+		// the window is filled again by the next source symbols
+		addPackets(3)
+		Expect(window.GetPackets()).To(Equal([][]byte{{6}, {7}, {8}, {9}, {10}}))
+	})
+
+	It("ignores the source symbols already released", func() {
+		addPackets(3)
+		window.ReleaseUpTo(2)
+		window.ReleaseUpTo(1)
+		Expect(window.GetPackets()).To(Equal([][]byte{{3}}))
+	})
+
+	It("releases all the source symbols", func() {
+		addPackets(3)
+		window.ReleaseUpTo(10)
+		Expect(window.CurrentNumberOfPackets()).To(BeZero())
+		Expect(window.FirstEncodingSymbolID()).To(Equal(protocol.FECEncodingSymbolID(4)))
+	})
+})
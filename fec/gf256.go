@@ -25,13 +25,7 @@ func gf256Div(a uint8, b uint8) uint8 {
  * @param[in]     p2     Second symbol
  */
 func symbolAddScaled (symbol1 []uint8, coef uint8, symbol2 []uint8) {
-	length := len(symbol1)
-	if len(symbol2) < length {
-		length = len(symbol2)
-	}
-	for i := 0 ; i < length ; i++ {
-		symbol1[i] ^= gf256Mul(coef, symbol2[i])
-	}
+	gf256MulAdd(symbol1, coef, symbol2)
 }
 
 var symbolSubScaled = symbolAddScaled
@@ -0,0 +1,61 @@
+package fec
+
+import "encoding/binary"
+
+// Multiply-accumulate kernel over whole symbols: dst += coef * src.
+// The platforms with an assembly implementation use split-nibble tables: coef * x = low[x & 0x0F] ^ high[x >> 4],
+// which allows to multiply 16 bytes at once with a byte shuffle instruction. The pure Go implementation multiplies
+// 64 bits words, and gives the same results on every GOARCH.
+
+// gf256NibbleTables holds the low and high split-nibble tables of each coefficient
+var gf256NibbleTables [256][2][16]uint8
+
+func init() {
+	for c := 0; c < 256; c++ {
+		for x := 0; x < 16; x++ {
+			gf256NibbleTables[c][0][x] = gf256_mul_table[c][x]
+			gf256NibbleTables[c][1][x] = gf256_mul_table[c][x<<4]
+		}
+	}
+}
+
+// gf256MulAdd performs dst += coef * src on the min(len(dst), len(src)) first bytes
+func gf256MulAdd(dst []uint8, coef uint8, src []uint8) {
+	n := len(dst)
+	if len(src) < n {
+		n = len(src)
+	}
+	dst, src = dst[:n], src[:n]
+	if coef == 0 || n == 0 {
+		return
+	}
+	if useGF256Assembly {
+		done := n &^ 15
+		if done > 0 {
+			gf256MulAddAsm(&gf256NibbleTables[coef], dst[:done], src[:done])
+		}
+		dst, src = dst[done:], src[done:]
+	}
+	gf256MulAddGeneric(dst, coef, src)
+}
+
+// gf256MulAddGeneric is the pure Go implementation of gf256MulAdd
+// pre: len(dst) == len(src)
+func gf256MulAddGeneric(dst []uint8, coef uint8, src []uint8) {
+	row := &gf256_mul_table[coef]
+	n := len(dst) &^ 7
+	for i := 0; i < n; i += 8 {
+		w := binary.LittleEndian.Uint64(src[i:])
+		var p uint64
+		if coef == 1 {
+			p = w
+		} else {
+			p = uint64(row[uint8(w)]) | uint64(row[uint8(w>>8)])<<8 | uint64(row[uint8(w>>16)])<<16 | uint64(row[uint8(w>>24)])<<24 |
+				uint64(row[uint8(w>>32)])<<32 | uint64(row[uint8(w>>40)])<<40 | uint64(row[uint8(w>>48)])<<48 | uint64(row[uint8(w>>56)])<<56
+		}
+		binary.LittleEndian.PutUint64(dst[i:], binary.LittleEndian.Uint64(dst[i:])^p)
+	}
+	for i := n; i < len(dst); i++ {
+		dst[i] ^= row[src[i]]
+	}
+}
@@ -0,0 +1,15 @@
+//go:build amd64 && !noasm
+// +build amd64,!noasm
+
+package fec
+
+// useGF256Assembly is true if the CPU supports the SSSE3 byte shuffle instruction
+var useGF256Assembly = hasSSSE3()
+
+func hasSSSE3() bool
+
+// gf256MulAddAsm performs dst += coef * src with the split-nibble tables of coef
+// pre: len(dst) == len(src) && len(dst) % 16 == 0
+//
+//go:noescape
+func gf256MulAddAsm(tables *[2][16]uint8, dst []uint8, src []uint8)
@@ -0,0 +1,50 @@
+//go:build amd64 && !noasm
+// +build amd64,!noasm
+
+#include "textflag.h"
+
+// func hasSSSE3() bool
+TEXT ·hasSSSE3(SB), NOSPLIT, $0-1
+	MOVL $1, AX
+	XORL CX, CX
+	CPUID
+	SHRL $9, CX
+	ANDL $1, CX
+	MOVB CX, ret+0(FP)
+	RET
+
+// func gf256MulAddAsm(tables *[2][16]uint8, dst []uint8, src []uint8)
+TEXT ·gf256MulAddAsm(SB), NOSPLIT, $0-56
+	MOVQ   tables+0(FP), AX
+	MOVQ   dst_base+8(FP), DI
+	MOVQ   dst_len+16(FP), CX
+	MOVQ   src_base+32(FP), SI
+	SHRQ   $4, CX
+	JZ     done
+	MOVOU  (AX), X6               // low nibble table
+	MOVOU  16(AX), X7             // high nibble table
+	MOVQ   $0x0f0f0f0f0f0f0f0f, DX
+	MOVQ   DX, X8
+	PUNPCKLQDQ X8, X8             // low nibbles mask
+
+loop:
+	MOVOU  (SI), X0
+	MOVOU  X0, X1
+	PSRLQ  $4, X1
+	PAND   X8, X0                 // low nibbles of src
+	PAND   X8, X1                 // high nibbles of src
+	MOVOU  X6, X2
+	MOVOU  X7, X3
+	PSHUFB X0, X2                 // low[src & 0x0F]
+	PSHUFB X1, X3                 // high[src >> 4]
+	PXOR   X2, X3
+	MOVOU  (DI), X4
+	PXOR   X3, X4
+	MOVOU  X4, (DI)
+	ADDQ   $16, SI
+	ADDQ   $16, DI
+	DECQ   CX
+	JNZ    loop
+
+done:
+	RET
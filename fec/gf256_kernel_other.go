@@ -0,0 +1,10 @@
+//go:build !amd64 || noasm
+// +build !amd64 noasm
+
+package fec
+
+const useGF256Assembly = false
+
+func gf256MulAddAsm(tables *[2][16]uint8, dst []uint8, src []uint8) {
+	panic("no assembly implementation of the GF(256) kernel on this platform")
+}
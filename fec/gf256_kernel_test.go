@@ -0,0 +1,107 @@
+package fec
+
+import (
+	"testing"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// gf256MulAddBytewise is the byte-at-a-time reference implementation of gf256MulAdd
+func gf256MulAddBytewise(dst []uint8, coef uint8, src []uint8) {
+	for i := 0; i < len(dst) && i < len(src); i++ {
+		dst[i] ^= gf256Mul(coef, src[i])
+	}
+}
+
+func getKernelTestSymbol(length int, seed int) []uint8 {
+	s := make([]uint8, length)
+	for i := range s {
+		s[i] = uint8(i*31 + seed*7 + 1)
+	}
+	return s
+}
+
+var _ = Describe("GF(256) multiply-accumulate kernel", func() {
+	It("gives the same results as the byte-at-a-time multiplication", func() {
+		for _, length := range []int{0, 1, 7, 8, 15, 16, 17, 33, 100, 1400} {
+			for coef := 0; coef < 256; coef++ {
+				src := getKernelTestSymbol(length, coef)
+				expected := getKernelTestSymbol(length, coef+1)
+				actual := getKernelTestSymbol(length, coef+1)
+				generic := getKernelTestSymbol(length, coef+1)
+				gf256MulAddBytewise(expected, uint8(coef), src)
+				gf256MulAdd(actual, uint8(coef), src)
+				gf256MulAddGeneric(generic, uint8(coef), src)
+				Expect(actual).To(Equal(expected))
+				Expect(generic).To(Equal(expected))
+			}
+		}
+	})
+
+	It("only modifies the bytes that are present in both symbols", func() {
+		dst := getKernelTestSymbol(40, 1)
+		expected := getKernelTestSymbol(40, 1)
+		src := getKernelTestSymbol(20, 2)
+		gf256MulAdd(dst, 0x42, src)
+		gf256MulAddBytewise(expected, 0x42, src)
+		Expect(dst).To(Equal(expected))
+
+		short := getKernelTestSymbol(5, 3)
+		gf256MulAdd(short, 0x42, getKernelTestSymbol(40, 4))
+		expected = getKernelTestSymbol(5, 3)
+		gf256MulAddBytewise(expected, 0x42, getKernelTestSymbol(40, 4))
+		Expect(short).To(Equal(expected))
+	})
+
+	It("handles unaligned symbols", func() {
+		buf := getKernelTestSymbol(100, 5)
+		expected := getKernelTestSymbol(100, 5)
+		src := getKernelTestSymbol(100, 6)
+		gf256MulAdd(buf[3:90], 0xAB, src[5:])
+		gf256MulAddBytewise(expected[3:90], 0xAB, src[5:])
+		Expect(buf).To(Equal(expected))
+	})
+})
+
+func benchmarkGF256MulAdd(b *testing.B, mulAdd func([]uint8, uint8, []uint8)) {
+	dst := getKernelTestSymbol(1400, 1)
+	src := getKernelTestSymbol(1400, 2)
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mulAdd(dst, uint8(i)|0x80, src)
+	}
+}
+
+func BenchmarkGF256MulAdd(b *testing.B) {
+	benchmarkGF256MulAdd(b, gf256MulAdd)
+}
+
+func BenchmarkGF256MulAddGeneric(b *testing.B) {
+	benchmarkGF256MulAdd(b, gf256MulAddGeneric)
+}
+
+func BenchmarkGF256MulAddBytewise(b *testing.B) {
+	benchmarkGF256MulAdd(b, gf256MulAddBytewise)
+}
+
+func BenchmarkRLCGetRepairSymbols(b *testing.B) {
+	window := NewFECWindow(20, versionIETFQUIC)
+	for i := 0; i < 20; i++ {
+		window.AddPacket(getKernelTestSymbol(1400, i), &wire.Header{
+			PacketNumber: protocol.PacketNumber(i + 1),
+			FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(protocol.FECEncodingSymbolID(i + 1)),
+		})
+	}
+	scheme := NewRandomLinearFECScheme()
+	b.SetBytes(20 * 1400)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := scheme.GetRepairSymbols(window, 1, 20); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package fec
+
+// Arithmetic over GF(2^16), with the primitive polynomial x^16 + x^12 + x^3 + x + 1.
+// The symbols are handled as sequences of big endian 16 bits elements. A symbol of odd length is considered to be
+// padded with a zero byte.
+
+const (
+	gf65536Polynomial = 0x1100B
+	gf65536Order      = 1<<16 - 1
+)
+
+var (
+	gf65536Log [1 << 16]uint16
+	// gf65536Exp is doubled to avoid a modulo when adding two logarithms
+	gf65536Exp [2 * gf65536Order]uint16
+)
+
+func init() {
+	x := 1
+	for i := 0; i < gf65536Order; i++ {
+		gf65536Exp[i] = uint16(x)
+		gf65536Exp[i+gf65536Order] = uint16(x)
+		gf65536Log[x] = uint16(i)
+		x <<= 1
+		if x&(1<<16) != 0 {
+			x ^= gf65536Polynomial
+		}
+	}
+}
+
+func gf65536Mul(a uint16, b uint16) uint16 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf65536Exp[int(gf65536Log[a])+int(gf65536Log[b])]
+}
+
+// pre: b != 0
+func gf65536Div(a uint16, b uint16) uint16 {
+	if a == 0 {
+		return 0
+	}
+	return gf65536Exp[int(gf65536Log[a])+gf65536Order-int(gf65536Log[b])]
+}
+
+// gf65536SymbolLength returns the length of a symbol able to contain n bytes
+func gf65536SymbolLength(n int) int {
+	return n + n%2
+}
+
+// gf65536SymbolAddScaled performs symbol1 += coef * symbol2
+func gf65536SymbolAddScaled(symbol1 []byte, coef uint16, symbol2 []byte) {
+	if coef == 0 {
+		return
+	}
+	for i := 0; i < len(symbol1) && i < len(symbol2); i += 2 {
+		v := uint16(symbol2[i]) << 8
+		if i+1 < len(symbol2) {
+			v |= uint16(symbol2[i+1])
+		}
+		p := gf65536Mul(coef, v)
+		symbol1[i] ^= byte(p >> 8)
+		if i+1 < len(symbol1) {
+			symbol1[i+1] ^= byte(p)
+		}
+	}
+}
+
+var gf65536SymbolSubScaled = gf65536SymbolAddScaled
+
+// gf65536SymbolDiv divides the elements of symbol by coef
+// pre: len(symbol) is even
+func gf65536SymbolDiv(symbol []byte, coef uint16) {
+	for i := 0; i+1 < len(symbol); i += 2 {
+		v := gf65536Div(uint16(symbol[i])<<8|uint16(symbol[i+1]), coef)
+		symbol[i] = byte(v >> 8)
+		symbol[i+1] = byte(v)
+	}
+}
+
+// gf65536VectorAddScaled performs v1 += coef * v2 on vectors of GF(2^16) elements
+func gf65536VectorAddScaled(v1 []uint16, coef uint16, v2 []uint16) {
+	if coef == 0 {
+		return
+	}
+	for i := range v1 {
+		v1[i] ^= gf65536Mul(coef, v2[i])
+	}
+}
+
+func gf65536VectorDiv(v []uint16, coef uint16) {
+	for i := range v {
+		v[i] = gf65536Div(v[i], coef)
+	}
+}
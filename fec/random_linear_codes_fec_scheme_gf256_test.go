@@ -0,0 +1,164 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RLC FEC Scheme over GF(2^8)", func() {
+	const numberOfPackets = 300
+
+	var (
+		packets   [][]byte
+		variables []*Variable
+		receiver  ConvolutionalFECScheme
+	)
+
+	// getRepairSymbols returns repair symbols protecting the packets with IDs first to last
+	getRepairSymbols := func(scheme ConvolutionalFECScheme, numberOfSymbols uint, first, last protocol.FECEncodingSymbolID) []*RepairSymbol {
+		// the window slides over the packets preceding first
+		window := NewFECWindow(uint16(last-first+1), versionIETFQUIC)
+		for id := protocol.FECEncodingSymbolID(1); id <= last; id++ {
+			window.AddPacket(packets[id-1], &wire.Header{
+				PacketNumber: protocol.PacketNumber(id),
+				FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(id),
+			})
+		}
+		symbols, err := scheme.GetRepairSymbols(window, numberOfSymbols, last)
+		Expect(err).ToNot(HaveOccurred())
+		return symbols
+	}
+
+	// receive puts the packets in the variables buffer, excepted the lost ones
+	receive := func(first, last protocol.FECEncodingSymbolID, lost ...protocol.FECEncodingSymbolID) []protocol.FECEncodingSymbolID {
+		isLost := make(map[protocol.FECEncodingSymbolID]bool)
+		for _, id := range lost {
+			isLost[id] = true
+		}
+		var unknowns []protocol.FECEncodingSymbolID
+		for id := first; id <= last; id++ {
+			if isLost[id] {
+				unknowns = append(unknowns, id)
+			} else {
+				variables[id] = &Variable{ID: id, Packet: packets[id-1]}
+			}
+		}
+		return unknowns
+	}
+
+	BeforeEach(func() {
+		packets = nil
+		for i := 0; i < numberOfPackets; i++ {
+			packets = append(packets, []byte{byte(i), byte(i >> 8), 0x42, 0x13})
+		}
+		variables = make([]*Variable, 2*numberOfPackets)
+		receiver = NewRandomLinearFECScheme()
+	})
+
+	It("recovers a variable as soon as an equation has a single unknown", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 1, 1, 4)
+		unknowns := receive(1, 4, 3)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[2]}))
+		Expect(receiver.CanRecoverPackets()).To(BeFalse())
+	})
+
+	It("recovers a variable when a known variable leaves it alone in its pivot equation", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 1, 1, 4)
+		unknowns := receive(1, 4, 2, 3)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		Expect(receiver.CanRecoverPackets()).To(BeFalse())
+		// the packet 3 arrives late
+		Expect(receiver.AddKnownVariable(&Variable{ID: 3, Packet: packets[2]})).To(BeTrue())
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[1]}))
+	})
+
+	It("recovers a pivot variable when the other unknowns of its equation are received", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 1, 1, 4)
+		unknowns := receive(1, 4, 2, 3)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		// the pivot of the equation is the variable 2
+		Expect(receiver.AddKnownVariable(&Variable{ID: 2, Packet: packets[1]})).To(BeTrue())
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[2]}))
+	})
+
+	It("ignores the known variables that are not in the system", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 1, 1, 4)
+		unknowns := receive(1, 4, 2, 3)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		Expect(receiver.AddKnownVariable(&Variable{ID: 5, Packet: packets[4]})).To(BeFalse())
+		Expect(receiver.CanRecoverPackets()).To(BeFalse())
+	})
+
+	It("drops the equations that do not bring new information", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 1, 1, 4)
+		unknowns := receive(1, 4, 2, 3)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		Expect(receiver.CanRecoverPackets()).To(BeFalse())
+		Expect(receiver.(*randomLinearFECSchemeGF256).pivots).To(HaveLen(1))
+	})
+
+	It("uses the recovered variables as constants before they are received", func() {
+		sender := NewRandomLinearFECScheme()
+		first := getRepairSymbols(sender, 1, 1, 3)
+		receiver.AddEquation(NewEquation(first[0]), receive(1, 3, 3), variables)
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[2]}))
+		// the recovered packet 3 is not in the variables buffer yet
+		second := getRepairSymbols(sender, 1, 2, 5)
+		receiver.AddEquation(NewEquation(second[0]), receive(2, 5, 3, 5), variables)
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err = receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[4]}))
+	})
+
+	It("does not return the variables below ignoreBelow", func() {
+		symbols := getRepairSymbols(NewRandomLinearFECScheme(), 2, 1, 4)
+		unknowns := receive(1, 4, 1, 4)
+		receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+		receiver.AddEquation(NewEquation(symbols[1]), unknowns, variables)
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[3]}))
+	})
+
+	It("recovers the losses of a long RFC 8681 window as the equations arrive", func() {
+		sender := NewRFC8681RandomLinearFECScheme()
+		receiver = NewRFC8681RandomLinearFECScheme()
+		lost := []protocol.FECEncodingSymbolID{10, 50, 51, 200, 299}
+		symbols := getRepairSymbols(sender, uint(len(lost)), 1, numberOfPackets)
+		unknowns := receive(1, numberOfPackets, lost...)
+		for i, symbol := range symbols {
+			receiver.AddEquation(NewEquationForScheme(receiver, symbol), unknowns, variables)
+			if i < len(lost)-1 {
+				// every equation has been reduced, but there are still more unknowns than equations
+				Expect(receiver.CanRecoverPackets()).To(BeFalse())
+			}
+		}
+		Expect(receiver.CanRecoverPackets()).To(BeTrue())
+		recovered, err := receiver.RecoverPackets(0)
+		Expect(err).ToNot(HaveOccurred())
+		var expected [][]byte
+		for _, id := range lost {
+			expected = append(expected, packets[id-1])
+		}
+		Expect(recovered).To(ConsistOf(expected))
+		Expect(receiver.(*randomLinearFECSchemeGF256).pivots).To(BeEmpty())
+	})
+})
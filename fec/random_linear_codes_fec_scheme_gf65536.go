@@ -0,0 +1,108 @@
+package fec
+
+import (
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// Random linear codes over GF(2^16). With 65535 non-zero coefficients instead of 255, the systems built from large
+// encoding windows are far less likely to be singular than over GF(2^8).
+// The FEC scheme specific field has the layout of RFC 8681 (repair key, density threshold and number of source
+// symbols), so that the encoding window is not limited by the NumberOfPackets of the FEC frames.
+
+var _ LargeWindowFECScheme = &randomLinearFECSchemeGF65536{}
+
+type randomLinearFECSchemeGF65536 struct {
+	// the decoder is the on-the-fly Gauss-Jordan elimination of rlcDecoder, over GF(2^16)
+	rlcDecoder[uint16, gf65536Field]
+	repairKey        uint16
+	densityThreshold uint8
+}
+
+// NewRandomLinearFECSchemeGF65536 returns a random linear codes FEC scheme over GF(2^16)
+func NewRandomLinearFECSchemeGF65536() ConvolutionalFECScheme {
+	return &randomLinearFECSchemeGF65536{
+		rlcDecoder:       newRLCDecoder[uint16, gf65536Field](),
+		repairKey:        1,
+		densityThreshold: 15,
+	}
+}
+
+func (f *randomLinearFECSchemeGF65536) getAndIncrementRepairKey() uint16 {
+	retVal := f.repairKey
+	f.repairKey++
+	if f.repairKey == 0 {
+		f.repairKey++
+	}
+	return retVal
+}
+
+func (f *randomLinearFECSchemeGF65536) GetNumberOfSourceSymbols(symbol *RepairSymbol) int {
+	return int(rlcSchemeSpecific(symbol.FECSchemeSpecific).GetNumberOfSourceSymbols())
+}
+
+func (f *randomLinearFECSchemeGF65536) GetRepairSymbols(fecContainer FECContainer, numberOfSymbols uint, id protocol.FECEncodingSymbolID) ([]*RepairSymbol, error) {
+	if numberOfSymbols > 0xFF {
+		return nil, fmt.Errorf("impossible to generate %d repair symbols with RLC (max %d allowed)", numberOfSymbols, 0xFF)
+	}
+	numberOfPackets := fecContainer.CurrentNumberOfPackets()
+	if numberOfPackets > rlcMaxNumberOfSourceSymbols {
+		return nil, fmt.Errorf("impossible to protect %d source symbols with RLC (max %d allowed)", numberOfPackets, rlcMaxNumberOfSourceSymbols)
+	}
+	maxLen := 0
+	for _, packet := range fecContainer.GetPackets() {
+		if len(packet) > maxLen {
+			maxLen = len(packet)
+		}
+	}
+	var rs []*RepairSymbol
+	for i := uint(0); i < numberOfSymbols; i++ {
+		fecSchemeSpecific := newRLCSchemeSpecific(f.getAndIncrementRepairKey(), f.densityThreshold, uint16(numberOfPackets))
+		coefficients, err := getEquationCoefficientsGF65536(fecSchemeSpecific, numberOfPackets)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, gf65536SymbolLength(maxLen))
+		for j, p := range fecContainer.GetPackets() {
+			gf65536SymbolAddScaled(data, coefficients[j], p)
+		}
+		rs = append(rs, &RepairSymbol{
+			FECSchemeSpecific:     uint32(fecSchemeSpecific),
+			Data:                  data,
+			NumberOfPackets:       uint16(numberOfPackets),
+			NumberOfRepairSymbols: uint8(numberOfSymbols),
+			Convolutional:         true,
+			EncodingSymbolID:      id,
+			SymbolNumber:          uint8(i),
+		})
+	}
+	return rs, nil
+}
+
+// pre: len(unknownVariables) > 0 && unknownVariables is sorted in ascending order
+func (f *randomLinearFECSchemeGF65536) AddEquation(eq *Equation, unknownVariables []protocol.FECEncodingSymbolID, variables []*Variable) {
+	coefficients, err := getEquationCoefficientsGF65536(rlcSchemeSpecific(eq.fecPayloadID.GetFECSchemeSpecific()), eq.numberOfVars)
+	if err != nil {
+		return
+	}
+	f.addEquation(eq, coefficients, unknownVariables, variables)
+}
+
+// getEquationCoefficientsGF65536 generates the coefficients like RFC 8681 does over GF(2^8), with 16 bits coefficients
+func getEquationCoefficientsGF65536(fss rlcSchemeSpecific, numberOfCoefficients int) ([]uint16, error) {
+	if int(fss.GetNumberOfSourceSymbols()) != numberOfCoefficients {
+		return nil, fmt.Errorf("the RLC encoding window has %d source symbols, not %d", fss.GetNumberOfSourceSymbols(), numberOfCoefficients)
+	}
+	dt := fss.GetDensityThreshold()
+	prng := newTinyMT32(uint32(fss.GetRepairKey()))
+	coefs := make([]uint16, numberOfCoefficients)
+	for i := range coefs {
+		if dt == 15 || prng.rand16() <= dt {
+			for coefs[i] == 0 {
+				coefs[i] = uint16(prng.uint32())
+			}
+		}
+	}
+	return coefs, nil
+}
@@ -0,0 +1,155 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RLC FEC Scheme over GF(2^16)", func() {
+	Context("arithmetic", func() {
+		It("uses a primitive polynomial", func() {
+			seen := make(map[uint16]bool)
+			for i := 0; i < gf65536Order; i++ {
+				Expect(seen[gf65536Exp[i]]).To(BeFalse())
+				seen[gf65536Exp[i]] = true
+			}
+			Expect(seen).ToNot(HaveKey(uint16(0)))
+		})
+
+		It("multiplies and divides", func() {
+			Expect(gf65536Mul(0, 1234)).To(BeZero())
+			Expect(gf65536Mul(1, 1234)).To(Equal(uint16(1234)))
+			Expect(gf65536Mul(2, 0x8000)).To(Equal(uint16(0x100B)))
+			for _, a := range []uint16{1, 2, 0x1337, 0xFFFF} {
+				for _, b := range []uint16{1, 3, 0xBEEF, 0xFFFF} {
+					Expect(gf65536Div(gf65536Mul(a, b), b)).To(Equal(a))
+				}
+			}
+		})
+
+		It("scales symbols of odd length", func() {
+			symbol := make([]byte, 4)
+			gf65536SymbolAddScaled(symbol, 2, []byte{0x80, 0x00, 0x01})
+			Expect(symbol).To(Equal([]byte{0x10, 0x0B, 0x02, 0x00}))
+			gf65536SymbolDiv(symbol, 2)
+			Expect(symbol).To(Equal([]byte{0x80, 0x00, 0x01, 0x00}))
+		})
+	})
+
+	Context("encoding and decoding", func() {
+		const numberOfPackets = 300
+
+		var (
+			packets   [][]byte
+			window    *FECWindow
+			variables []*Variable
+		)
+
+		BeforeEach(func() {
+			window = NewFECWindow(numberOfPackets, versionIETFQUIC)
+			packets = nil
+			for i := 0; i < numberOfPackets; i++ {
+				p := []byte{byte(i), byte(i >> 8), 0x42}
+				if i%2 == 0 {
+					p = append(p, 0x13, 0x37)
+				}
+				packets = append(packets, p)
+				window.AddPacket(p, &wire.Header{
+					PacketNumber: protocol.PacketNumber(i + 1),
+					FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(protocol.FECEncodingSymbolID(i + 1)),
+				})
+			}
+			variables = make([]*Variable, 2*numberOfPackets)
+			for i, p := range packets {
+				variables[i+1] = &Variable{ID: protocol.FECEncodingSymbolID(i + 1), Packet: p}
+			}
+		})
+
+		It("describes windows of more than 255 packets", func() {
+			scheme := NewRandomLinearFECSchemeGF65536()
+			symbols, err := scheme.GetRepairSymbols(window, 1, numberOfPackets)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(symbols[0].Data).To(HaveLen(6))
+			Expect(symbols[0].NumberOfPackets).To(Equal(uint16(numberOfPackets)))
+			eq := NewEquationForScheme(scheme, symbols[0])
+			begin, end := eq.Bounds()
+			Expect(begin).To(Equal(protocol.FECEncodingSymbolID(1)))
+			Expect(end).To(Equal(protocol.FECEncodingSymbolID(numberOfPackets)))
+		})
+
+		It("refuses windows that do not fit in the FEC scheme specific field", func() {
+			_, err := NewRandomLinearFECSchemeGF65536().GetRepairSymbols(&FECWindow{currentNumberOfPackets: rlcMaxNumberOfSourceSymbols + 1}, 1, 1)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("recovers lost packets", func() {
+			symbols, err := NewRandomLinearFECSchemeGF65536().GetRepairSymbols(window, 3, numberOfPackets)
+			Expect(err).ToNot(HaveOccurred())
+			lost := []protocol.FECEncodingSymbolID{10, 200, 290}
+			for _, id := range lost {
+				variables[id] = nil
+			}
+			receiver := NewRandomLinearFECSchemeGF65536()
+			for _, s := range symbols {
+				receiver.AddEquation(NewEquationForScheme(receiver, s), lost, variables)
+			}
+			Expect(receiver.CanRecoverPackets()).To(BeTrue())
+			recovered, err := receiver.RecoverPackets(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(HaveLen(3))
+			for _, id := range lost {
+				// the recovered packets are padded to the length of the repair symbols
+				expected := append(append([]byte{}, packets[id-1]...), make([]byte, 6-len(packets[id-1]))...)
+				Expect(recovered).To(ContainElement(expected))
+			}
+			Expect(receiver.CanRecoverPackets()).To(BeFalse())
+		})
+
+		It("reduces the system as the equations arrive", func() {
+			symbols, err := NewRandomLinearFECSchemeGF65536().GetRepairSymbols(window, 3, numberOfPackets)
+			Expect(err).ToNot(HaveOccurred())
+			lost := []protocol.FECEncodingSymbolID{10, 200, 290}
+			for _, id := range lost {
+				variables[id] = nil
+			}
+			receiver := NewRandomLinearFECSchemeGF65536()
+			receiver.AddEquation(NewEquationForScheme(receiver, symbols[0]), lost, variables)
+			Expect(receiver.(*randomLinearFECSchemeGF65536).pivots).To(HaveLen(1))
+			Expect(receiver.CanRecoverPackets()).To(BeFalse())
+			receiver.AddEquation(NewEquationForScheme(receiver, symbols[1]), lost, variables)
+			Expect(receiver.(*randomLinearFECSchemeGF65536).pivots).To(HaveLen(2))
+			Expect(receiver.CanRecoverPackets()).To(BeFalse())
+			// an equation that brings no new information is dropped
+			receiver.AddEquation(NewEquationForScheme(receiver, symbols[0]), lost, variables)
+			Expect(receiver.(*randomLinearFECSchemeGF65536).pivots).To(HaveLen(2))
+			receiver.AddEquation(NewEquationForScheme(receiver, symbols[2]), lost, variables)
+			Expect(receiver.(*randomLinearFECSchemeGF65536).pivots).To(BeEmpty())
+			recovered, err := receiver.RecoverPackets(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(HaveLen(3))
+		})
+
+		It("takes the packets received after the repair symbols into account", func() {
+			symbols, err := NewRandomLinearFECSchemeGF65536().GetRepairSymbols(window, 1, numberOfPackets)
+			Expect(err).ToNot(HaveOccurred())
+			lost := []protocol.FECEncodingSymbolID{10, 20}
+			for _, id := range lost {
+				variables[id] = nil
+			}
+			receiver := NewRandomLinearFECSchemeGF65536()
+			receiver.AddEquation(NewEquationForScheme(receiver, symbols[0]), lost, variables)
+			recovered, err := receiver.RecoverPackets(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(BeEmpty())
+			// packet 20 finally arrives
+			Expect(receiver.AddKnownVariable(&Variable{ID: 20, Packet: packets[19]})).To(BeTrue())
+			Expect(receiver.AddKnownVariable(&Variable{ID: 21, Packet: packets[20]})).To(BeFalse())
+			Expect(receiver.CanRecoverPackets()).To(BeTrue())
+			recovered, err = receiver.RecoverPackets(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(Equal([][]byte{{9, 0, 0x42, 0, 0, 0}}))
+		})
+	})
+})
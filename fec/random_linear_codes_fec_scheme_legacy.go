@@ -47,6 +47,9 @@ func (f *randomLinearFECSchemeLegacy) GetRepairSymbols(fecContainer FECContainer
 	if numberOfSymbols > 0xFF {
 		return nil, errors.New(fmt.Sprintf("impossible to generate %d repair symbols with RLC (max %d allowed)", numberOfSymbols, 0xFF))
 	}
+	if fecContainer.CurrentNumberOfPackets() > 0xFF {
+		return nil, errors.New(fmt.Sprintf("impossible to protect %d source symbols with RLC (max %d allowed)", fecContainer.CurrentNumberOfPackets(), 0xFF))
+	}
 	var rs []*RepairSymbol
 	for i := uint(0) ; i < numberOfSymbols ; i++ {
 		fecSchemeSpecific := f.getFECSchemeSpecific()
@@ -62,7 +65,7 @@ func (f *randomLinearFECSchemeLegacy) GetRepairSymbols(fecContainer FECContainer
 		rs = append(rs, &RepairSymbol{
 				FECSchemeSpecific: uint32(fecSchemeSpecific),
 				Data: equationConstantTerm.Num().Bytes(),
-				NumberOfPackets: uint8(fecContainer.CurrentNumberOfPackets()),
+				NumberOfPackets: uint16(fecContainer.CurrentNumberOfPackets()),
 				NumberOfRepairSymbols: uint8(numberOfSymbols),
 				Convolutional: true,
 				EncodingSymbolID: id,
@@ -0,0 +1,166 @@
+package fec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// Sliding window RLC FEC scheme over GF(2^8), as specified in RFC 8681.
+// The decoder is the one of randomLinearFECSchemeGF256, only the coding coefficients and the
+// FEC scheme specific part of the repair FEC payload ID change.
+
+// RLCRepairFECPayloadIDLength is the length of the Repair FEC Payload ID of RFC 8681, Section 4.1.3
+const RLCRepairFECPayloadIDLength = 8
+
+// rlcMaxNumberOfSourceSymbols is the highest number of source symbols that fits in the NSS field (12 bits)
+const rlcMaxNumberOfSourceSymbols = 0xFFF
+
+// RLCRepairFECPayloadID is the Repair FEC Payload ID of RFC 8681:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|       Repair_Key              |  DT   |NSS (# src symb in ew.)|
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                            FSS_ESI                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+type RLCRepairFECPayloadID struct {
+	RepairKey             uint16
+	DensityThreshold      uint8
+	NumberOfSourceSymbols uint16
+	// FirstSourceSymbolESI is the encoding symbol ID of the first source symbol of the encoding window
+	FirstSourceSymbolESI protocol.FECEncodingSymbolID
+}
+
+// NewRLCRepairFECPayloadID returns the RFC 8681 Repair FEC Payload ID of a repair symbol generated by the RFC 8681 RLC FEC scheme
+func NewRLCRepairFECPayloadID(rs *RepairSymbol) *RLCRepairFECPayloadID {
+	fss := rlcSchemeSpecific(rs.FECSchemeSpecific)
+	return &RLCRepairFECPayloadID{
+		RepairKey:             fss.GetRepairKey(),
+		DensityThreshold:      fss.GetDensityThreshold(),
+		NumberOfSourceSymbols: fss.GetNumberOfSourceSymbols(),
+		FirstSourceSymbolESI:  rs.EncodingSymbolID - protocol.FECEncodingSymbolID(fss.GetNumberOfSourceSymbols()) + 1,
+	}
+}
+
+// ParseRLCRepairFECPayloadID parses a Repair FEC Payload ID of RFC 8681
+func ParseRLCRepairFECPayloadID(r *bytes.Reader) (*RLCRepairFECPayloadID, error) {
+	repairKey, err := utils.BigEndian.ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+	dtNSS, err := utils.BigEndian.ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+	fssESI, err := utils.BigEndian.ReadUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	return &RLCRepairFECPayloadID{
+		RepairKey:             repairKey,
+		DensityThreshold:      uint8(dtNSS >> 12),
+		NumberOfSourceSymbols: dtNSS & rlcMaxNumberOfSourceSymbols,
+		FirstSourceSymbolESI:  protocol.FECEncodingSymbolID(fssESI),
+	}, nil
+}
+
+// Write writes the Repair FEC Payload ID
+func (p *RLCRepairFECPayloadID) Write(b *bytes.Buffer) error {
+	if p.DensityThreshold > 15 {
+		return fmt.Errorf("invalid RLC density threshold %d", p.DensityThreshold)
+	}
+	if p.NumberOfSourceSymbols > rlcMaxNumberOfSourceSymbols {
+		return fmt.Errorf("too many source symbols in the RLC encoding window: %d", p.NumberOfSourceSymbols)
+	}
+	utils.BigEndian.WriteUint16(b, p.RepairKey)
+	utils.BigEndian.WriteUint16(b, uint16(p.DensityThreshold)<<12|p.NumberOfSourceSymbols)
+	utils.BigEndian.WriteUint32(b, uint32(p.FirstSourceSymbolESI))
+	return nil
+}
+
+// GetFECSchemeSpecific returns the first 32 bits of the Repair FEC Payload ID, kept in the FEC scheme specific field of the repair symbols
+func (p *RLCRepairFECPayloadID) GetFECSchemeSpecific() uint32 {
+	return uint32(newRLCSchemeSpecific(p.RepairKey, p.DensityThreshold, p.NumberOfSourceSymbols))
+}
+
+// GetEncodingSymbolID returns the encoding symbol ID of the repair symbol, i.e. the one of the last source symbol of the encoding window
+func (p *RLCRepairFECPayloadID) GetEncodingSymbolID() protocol.FECEncodingSymbolID {
+	return p.LastSourceSymbolESI()
+}
+
+// LastSourceSymbolESI returns the encoding symbol ID of the last source symbol of the encoding window
+func (p *RLCRepairFECPayloadID) LastSourceSymbolESI() protocol.FECEncodingSymbolID {
+	return p.FirstSourceSymbolESI + protocol.FECEncodingSymbolID(p.NumberOfSourceSymbols) - 1
+}
+
+var _ wire.RepairFECPayloadID = &RLCRepairFECPayloadID{}
+
+// rlcSchemeSpecific is the FEC scheme specific part of the FEC payload IDs of the RFC 8681 scheme.
+// It holds the first 32 bits of the Repair FEC Payload ID, the FSS_ESI being deduced from the encoding symbol ID of the repair symbol
+type rlcSchemeSpecific uint32
+
+func newRLCSchemeSpecific(repairKey uint16, dt uint8, nss uint16) rlcSchemeSpecific {
+	return rlcSchemeSpecific(uint32(repairKey)<<16 | uint32(dt&0x0F)<<12 | uint32(nss&rlcMaxNumberOfSourceSymbols))
+}
+
+func (f rlcSchemeSpecific) GetRepairKey() uint16 {
+	return uint16(f >> 16)
+}
+
+func (f rlcSchemeSpecific) GetDensityThreshold() uint8 {
+	return uint8(f>>12) & 0x0F
+}
+
+func (f rlcSchemeSpecific) GetNumberOfSourceSymbols() uint16 {
+	return uint16(f) & rlcMaxNumberOfSourceSymbols
+}
+
+// NewRFC8681RandomLinearFECScheme returns the sliding window RLC FEC scheme over GF(2^8) of RFC 8681
+func NewRFC8681RandomLinearFECScheme() ConvolutionalFECScheme {
+	return newRandomLinearFECSchemeGF256(true)
+}
+
+// generateRLCCodingCoefficients generates the coding coefficients over GF(2^8) of RFC 8681, Section 3.6
+func generateRLCCodingCoefficients(repairKey uint16, coefs []uint8, dt uint8) error {
+	if dt > 15 {
+		return errors.New("bad dt parameter")
+	}
+	prng := newTinyMT32(uint32(repairKey))
+	if dt == 15 {
+		// coefficient 0 is avoided here in order to include all the source symbols
+		for i := range coefs {
+			coefs[i] = 0
+			for coefs[i] == 0 {
+				coefs[i] = prng.rand256()
+			}
+		}
+		return nil
+	}
+	// here a certain fraction of coefficients should be 0
+	for i := range coefs {
+		coefs[i] = 0
+		if prng.rand16() <= dt {
+			for coefs[i] == 0 {
+				coefs[i] = prng.rand256()
+			}
+		}
+	}
+	return nil
+}
+
+func getRLCEquationCoefficients(fss rlcSchemeSpecific, numberOfCoefficients int) ([]uint8, error) {
+	if int(fss.GetNumberOfSourceSymbols()) != numberOfCoefficients {
+		return nil, fmt.Errorf("the RLC encoding window has %d source symbols, not %d", fss.GetNumberOfSourceSymbols(), numberOfCoefficients)
+	}
+	coefs := make([]uint8, numberOfCoefficients)
+	if err := generateRLCCodingCoefficients(fss.GetRepairKey(), coefs, fss.GetDensityThreshold()); err != nil {
+		return nil, err
+	}
+	return coefs, nil
+}
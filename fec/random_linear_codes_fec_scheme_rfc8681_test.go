@@ -0,0 +1,162 @@
+package fec
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RFC 8681 RLC FEC Scheme", func() {
+	Context("coding coefficients", func() {
+		It("uses the TinyMT32 sequence, without zero, when DT is 15", func() {
+			// the low bytes of the RFC 8682 test vector with seed 1 are 37, 225, 177, 176, 21, 246
+			coefs := make([]uint8, 6)
+			Expect(generateRLCCodingCoefficients(1, coefs, 15)).To(Succeed())
+			Expect(coefs).To(Equal([]uint8{37, 225, 177, 176, 21, 246}))
+		})
+
+		It("sets coefficients to zero according to the density threshold", func() {
+			// the low nibbles of the RFC 8682 test vector with seed 1 are 5, 1, 1, 0, 5
+			// 5 > 4: zero, 1 <= 4: low byte of the next integer (177), 0 <= 4: low byte of the next integer (21)
+			coefs := make([]uint8, 3)
+			Expect(generateRLCCodingCoefficients(1, coefs, 4)).To(Succeed())
+			Expect(coefs).To(Equal([]uint8{0, 177, 21}))
+		})
+
+		It("refuses invalid density thresholds", func() {
+			Expect(generateRLCCodingCoefficients(1, make([]uint8, 3), 16)).ToNot(Succeed())
+		})
+
+		It("refuses an encoding window size that does not match NSS", func() {
+			_, err := getRLCEquationCoefficients(newRLCSchemeSpecific(1, 15, 4), 3)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("repair FEC payload ID", func() {
+		It("writes the RFC 8681 layout", func() {
+			b := &bytes.Buffer{}
+			p := &RLCRepairFECPayloadID{
+				RepairKey:             0x1337,
+				DensityThreshold:      0xA,
+				NumberOfSourceSymbols: 0x123,
+				FirstSourceSymbolESI:  0xDEADBEEF,
+			}
+			Expect(p.Write(b)).To(Succeed())
+			Expect(b.Bytes()).To(Equal([]byte{0x13, 0x37, 0xA1, 0x23, 0xDE, 0xAD, 0xBE, 0xEF}))
+			Expect(b.Len()).To(Equal(RLCRepairFECPayloadIDLength))
+			parsed, err := ParseRLCRepairFECPayloadID(bytes.NewReader(b.Bytes()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed).To(Equal(p))
+			Expect(parsed.LastSourceSymbolESI()).To(Equal(protocol.FECEncodingSymbolID(0xDEADBEEF + 0x123 - 1)))
+		})
+
+		It("refuses to write invalid fields", func() {
+			Expect((&RLCRepairFECPayloadID{DensityThreshold: 16}).Write(&bytes.Buffer{})).ToNot(Succeed())
+			Expect((&RLCRepairFECPayloadID{NumberOfSourceSymbols: 0x1000}).Write(&bytes.Buffer{})).ToNot(Succeed())
+		})
+
+		It("errors on EOF", func() {
+			_, err := ParseRLCRepairFECPayloadID(bytes.NewReader([]byte{0x13, 0x37, 0xA1, 0x23, 0xDE}))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("encoding and decoding", func() {
+		var (
+			packets [][]byte
+			window  *FECWindow
+		)
+
+		BeforeEach(func() {
+			packets = [][]byte{
+				{0xDE, 0xAD, 0xBE, 0xEF},
+				{0xCA, 0xFE, 0xBA, 0xBE},
+				{0x01, 0x23, 0x45, 0x67},
+			}
+			window = NewFECWindow(10, versionIETFQUIC)
+			for i, p := range packets {
+				window.AddPacket(p, &wire.Header{
+					PacketNumber: protocol.PacketNumber(i + 1),
+					FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(protocol.FECEncodingSymbolID(i + 1)),
+				})
+			}
+		})
+
+		It("generates repair symbols checkable with the RFC coefficients", func() {
+			scheme := NewRFC8681RandomLinearFECScheme()
+			symbols, err := scheme.GetRepairSymbols(window, 1, 3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(symbols).To(HaveLen(1))
+			payloadID := NewRLCRepairFECPayloadID(symbols[0])
+			Expect(payloadID).To(Equal(&RLCRepairFECPayloadID{
+				RepairKey:             1,
+				DensityThreshold:      15,
+				NumberOfSourceSymbols: 3,
+				FirstSourceSymbolESI:  1,
+			}))
+			// repair key 1 with DT 15 gives the coefficients 37, 225 and 177
+			expected := make([]byte, 4)
+			symbolAddScaled(expected, 37, packets[0])
+			symbolAddScaled(expected, 225, packets[1])
+			symbolAddScaled(expected, 177, packets[2])
+			Expect(symbols[0].Data).To(Equal(expected))
+		})
+
+		It("writes and parses the RFC 8681 repair FEC payload ID in the FEC frames", func() {
+			scheme := NewRFC8681RandomLinearFECScheme()
+			symbols, err := scheme.GetRepairSymbols(window, 1, 3)
+			Expect(err).ToNot(HaveOccurred())
+			frame := &wire.FECFrame{
+				FECSchemeSpecific:  symbols[0].FECSchemeSpecific,
+				Convolutional:      true,
+				EncodingSymbolID:   symbols[0].EncodingSymbolID,
+				FinBit:             true,
+				DataLength:         protocol.FecFrameLength(len(symbols[0].Data)),
+				Data:               symbols[0].Data,
+				RepairFECPayloadID: symbols[0].RepairFECPayloadID,
+			}
+			b := &bytes.Buffer{}
+			Expect(frame.Write(b, versionIETFQUIC)).To(Succeed())
+			// type byte, FIN bit and data length, then the repair FEC payload ID with FSS_ESI 1
+			Expect(b.Bytes()[3 : 3+RLCRepairFECPayloadIDLength]).To(Equal([]byte{0x00, 0x01, 0xF0, 0x03, 0x00, 0x00, 0x00, 0x01}))
+
+			parser := GetRepairFECPayloadIDParser(NewRFC8681RandomLinearFECScheme())
+			Expect(parser).ToNot(BeNil())
+			parsed, err := wire.ParseFECFrameWithRepairFECPayloadID(bytes.NewReader(b.Bytes()), versionIETFQUIC, parser)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.EncodingSymbolID).To(Equal(protocol.FECEncodingSymbolID(3)))
+			Expect(parsed.FECSchemeSpecific).To(Equal(symbols[0].FECSchemeSpecific))
+			Expect(parsed.RepairFECPayloadID).To(Equal(symbols[0].RepairFECPayloadID))
+			Expect(parsed.Data).To(Equal(symbols[0].Data))
+		})
+
+		It("keeps the FEC-QUIC repair FEC payload ID for the other RLC schemes", func() {
+			symbols, err := NewRandomLinearFECScheme().GetRepairSymbols(window, 1, 3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(symbols[0].RepairFECPayloadID).To(BeNil())
+			Expect(GetRepairFECPayloadIDParser(NewRandomLinearFECScheme())).To(BeNil())
+		})
+
+		It("recovers lost source symbols", func() {
+			symbols, err := NewRFC8681RandomLinearFECScheme().GetRepairSymbols(window, 2, 3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(symbols).To(HaveLen(2))
+
+			receiver := NewRFC8681RandomLinearFECScheme()
+			variables := make([]*Variable, 10)
+			variables[1] = &Variable{ID: 1, Packet: packets[0]}
+			unknowns := []protocol.FECEncodingSymbolID{2, 3}
+			receiver.AddEquation(NewEquation(symbols[0]), unknowns, variables)
+			Expect(receiver.CanRecoverPackets()).To(BeFalse())
+			receiver.AddEquation(NewEquation(symbols[1]), unknowns, variables)
+			Expect(receiver.CanRecoverPackets()).To(BeTrue())
+			recovered, err := receiver.RecoverPackets(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered).To(ConsistOf(packets[1], packets[2]))
+		})
+	})
+})
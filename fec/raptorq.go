@@ -0,0 +1,490 @@
+package fec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RaptorQ code of RFC 6330, Section 5.
+// The source symbols of a block are the first K encoding symbols of the code. The encoder computes the L intermediate
+// symbols C from them, by solving the S LDPC constraints, the H HDPC constraints and the LT relations of the source
+// symbols (Section 5.3.3.4). Any repair symbol is then an LT combination of the intermediate symbols, of W LT symbols
+// and P permanently inactivated (PI) symbols (Section 5.3.5.3).
+// The decoder solves the same system with the symbols it received, with the inactivation decoding of Section 5.4.2:
+// the sparse LT and LDPC rows are peeled, the columns that block the peeling are inactivated, and only the few
+// inactivated columns are solved by a dense Gaussian elimination, instead of the K columns.
+//
+// The tables V0, V1, V2 and V3 of Section 5.5 and the systematic indices of Section 5.6 are not included in this
+// implementation. The Rand tables are generated by a TinyMT32 PRNG, and the parameters are computed for every K
+// instead of rounding K up to K' (see raptorQParametersOf), so the repair symbols are not interoperable with
+// the other RaptorQ implementations.
+
+// raptorQMaxSourceSymbols is the highest K' of RFC 6330
+const raptorQMaxSourceSymbols = 56403
+
+// raptorQMaxESI is the highest encoding symbol ID, it is 24 bits long in the FEC payload ID of RFC 6330
+const raptorQMaxESI = 1<<24 - 1
+
+// raptorQMaxSystematicIndex bounds the search of the systematic index of a K
+const raptorQMaxSystematicIndex = 1024
+
+var errRaptorQSingularSystem = errors.New("RaptorQ: the symbols don't determine the intermediate symbols")
+
+// raptorQDegrees is the degree distribution of Section 5.3.5.2
+var raptorQDegrees = [...]uint32{
+	0, 5243, 529531, 704294, 791675, 844104, 879057, 904023, 922747, 937311, 948962, 958494, 966438, 973160, 978921,
+	983914, 988283, 992138, 995565, 998631, 1001391, 1003887, 1006157, 1008229, 1010129, 1011876, 1013490, 1014983,
+	1016370, 1017662, 1048576,
+}
+
+// raptorQRandTables replace the tables V0, V1, V2 and V3 of Section 5.5
+var raptorQRandTables = func() (v [4][256]uint32) {
+	prng := newTinyMT32(6330)
+	for t := range v {
+		for i := range v[t] {
+			v[t][i] = prng.uint32()
+		}
+	}
+	return v
+}()
+
+// raptorQRand is the function Rand[y, i, m] of Section 5.3.5.1
+func raptorQRand(y uint32, i uint32, m uint32) uint32 {
+	v := &raptorQRandTables
+	return (v[0][(y+i)&0xFF] ^ v[1][((y>>8)+i)&0xFF] ^ v[2][((y>>16)+i)&0xFF] ^ v[3][((y>>24)+i)&0xFF]) % m
+}
+
+// raptorQParameters are the parameters of the code for K source symbols, Section 5.3.3.3
+type raptorQParameters struct {
+	k int
+	// the systematic index J(K)
+	j uint32
+	// the number of LDPC, HDPC and LT symbols
+	s, h, w int
+	// the number of intermediate symbols, of PI symbols, and the smallest prime greater or equal to p
+	l, p, p1 int
+	// the number of LT symbols that are not LDPC symbols
+	b int
+}
+
+// raptorQSystematicIndexes caches the systematic index of every K, as searching it solves the encoding system
+var raptorQSystematicIndexes sync.Map
+
+// raptorQParametersOf returns the parameters of the code for k source symbols. RFC 6330 rounds K up to the K' of
+// its table, whose S, H and W values follow these rules for the small K'. The systematic index J is the smallest one for
+// which the source symbols determine the intermediate symbols, as for the J(K') of the table.
+func raptorQParametersOf(k int) (*raptorQParameters, error) {
+	if k <= 0 || k > raptorQMaxSourceSymbols {
+		return nil, fmt.Errorf("RaptorQ: invalid number of source symbols %d", k)
+	}
+	// S is the smallest prime greater or equal to ceil(0.01*K) + X, with X the smallest integer with X*(X-1) >= 2*K
+	x := 1
+	for x*(x-1) < 2*k {
+		x++
+	}
+	s := nextPrime((k+99)/100 + x)
+	h := 10
+	for 1<<uint(h) < k+s {
+		h++
+	}
+	// W is prime, and there are at least as many PI symbols as HDPC symbols
+	w := k + s
+	for !isPrime(w) {
+		w--
+	}
+	p := k + s + h - w
+	params := &raptorQParameters{k: k, s: s, h: h, w: w, l: k + s + h, p: p, p1: nextPrime(p), b: w - s}
+	if j, ok := raptorQSystematicIndexes.Load(k); ok {
+		params.j = j.(uint32)
+		return params, nil
+	}
+	for params.j = 0; params.j < raptorQMaxSystematicIndex; params.j++ {
+		rows := params.constraintRows(0)
+		for i := 0; i < k; i++ {
+			rows = append(rows, params.ltRow(uint32(i), nil))
+		}
+		if _, err := raptorQSolve(params, rows, 0); err == nil {
+			raptorQSystematicIndexes.Store(k, params.j)
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("RaptorQ: no systematic index for %d source symbols", k)
+}
+
+// degree is the function Deg[v] of Section 5.3.5.2
+func (p *raptorQParameters) degree(v uint32) int {
+	d := 1
+	for v >= raptorQDegrees[d] {
+		d++
+	}
+	if d > p.w-2 {
+		return p.w - 2
+	}
+	return d
+}
+
+// ltColumns returns the intermediate symbols that make the encoding symbol with internal symbol ID x, as the
+// functions Tuple[K', X] of Section 5.3.5.4 and LTEnc of Section 5.3.5.3
+func (p *raptorQParameters) ltColumns(x uint32) []int {
+	a := 53591 + p.j*997
+	if a%2 == 0 {
+		a++
+	}
+	y := 10267*(p.j+1) + x*a
+	d := p.degree(raptorQRand(y, 0, 1<<20))
+	ltA := int(1 + raptorQRand(y, 1, uint32(p.w-1)))
+	ltB := int(raptorQRand(y, 2, uint32(p.w)))
+	d1 := 2
+	if d < 4 {
+		d1 = 2 + int(raptorQRand(x, 3, 2))
+	}
+	piA := int(1 + raptorQRand(x, 4, uint32(p.p1-1)))
+	piB := int(raptorQRand(x, 5, uint32(p.p1)))
+
+	columns := make([]int, 0, d+d1)
+	columns = append(columns, ltB)
+	for i := 1; i < d; i++ {
+		ltB = (ltB + ltA) % p.w
+		columns = append(columns, ltB)
+	}
+	for piB >= p.p {
+		piB = (piB + piA) % p.p1
+	}
+	columns = append(columns, p.w+piB)
+	for i := 1; i < d1; i++ {
+		piB = (piB + piA) % p.p1
+		for piB >= p.p {
+			piB = (piB + piA) % p.p1
+		}
+		columns = append(columns, p.w+piB)
+	}
+	return columns
+}
+
+// ltRow returns the relation between the intermediate symbols and the encoding symbol value with internal symbol ID x
+func (p *raptorQParameters) ltRow(x uint32, value []byte) *raptorQRow {
+	row := newRaptorQRow(value)
+	for _, c := range p.ltColumns(x) {
+		row.coefs[c] ^= 1
+	}
+	return row
+}
+
+// encodingSymbol returns the encoding symbol with internal symbol ID x, computed from the intermediate symbols
+func (p *raptorQParameters) encodingSymbol(x uint32, intermediate [][]byte) []byte {
+	symbol := make([]byte, len(intermediate[0]))
+	for _, c := range p.ltColumns(x) {
+		symbolAddScaled(symbol, 1, intermediate[c])
+	}
+	return symbol
+}
+
+// constraintRows returns the S LDPC and the H HDPC relations between the intermediate symbols, Section 5.3.3.3
+func (p *raptorQParameters) constraintRows(symbolLen int) []*raptorQRow {
+	rows := make([]*raptorQRow, p.s+p.h)
+	for i := range rows {
+		rows[i] = newRaptorQRow(make([]byte, symbolLen))
+	}
+	ldpc := rows[:p.s]
+	for i := 0; i < p.b; i++ {
+		a := 1 + i/p.s
+		b := i % p.s
+		ldpc[b].coefs[i] ^= 1
+		b = (b + a) % p.s
+		ldpc[b].coefs[i] ^= 1
+		b = (b + a) % p.s
+		ldpc[b].coefs[i] ^= 1
+	}
+	for i := 0; i < p.s; i++ {
+		ldpc[i].coefs[p.b+i] ^= 1
+		ldpc[i].coefs[p.w+i%p.p] ^= 1
+		ldpc[i].coefs[p.w+(i+1)%p.p] ^= 1
+	}
+	for _, row := range ldpc {
+		row.removeZeros()
+	}
+
+	// G_HDPC = MT * GAMMA, with GAMMA[i][j] = alpha^(i-j) for i >= j, so G_HDPC[r][j] = MT[r][j] + alpha * G_HDPC[r][j+1]
+	hdpc := rows[p.s:]
+	n := p.k + p.s
+	mt := make([][]uint8, p.h)
+	for r := range mt {
+		mt[r] = make([]uint8, n)
+	}
+	for j := 0; j < n-1; j++ {
+		r1 := raptorQRand(uint32(j+1), 6, uint32(p.h))
+		r2 := (r1 + raptorQRand(uint32(j+1), 7, uint32(p.h-1)) + 1) % uint32(p.h)
+		mt[r1][j] = 1
+		mt[r2][j] = 1
+	}
+	alphaPower := uint8(1)
+	for r := range mt {
+		mt[r][n-1] = alphaPower
+		alphaPower = gf256Mul(alphaPower, 2)
+	}
+	for r, row := range hdpc {
+		row.hdpc = true
+		var coef uint8
+		for j := n - 1; j >= 0; j-- {
+			coef = mt[r][j] ^ gf256Mul(2, coef)
+			if coef != 0 {
+				row.coefs[j] = coef
+			}
+		}
+		row.coefs[n+r] = 1
+	}
+	return rows
+}
+
+// raptorQRow is a linear relation between the intermediate symbols: the sum of coefs[c] * C[c] is value
+type raptorQRow struct {
+	coefs map[int]uint8
+	value []byte
+	hdpc  bool
+	// the number of columns of the row that are neither solved nor inactivated, during the peeling
+	activeDegree int
+}
+
+func newRaptorQRow(value []byte) *raptorQRow {
+	return &raptorQRow{coefs: make(map[int]uint8), value: value}
+}
+
+func (r *raptorQRow) removeZeros() {
+	for c, coef := range r.coefs {
+		if coef == 0 {
+			delete(r.coefs, c)
+		}
+	}
+}
+
+// addScaled adds coef times the row other to the row
+func (r *raptorQRow) addScaled(coef uint8, other *raptorQRow) {
+	for c, otherCoef := range other.coefs {
+		if v := r.coefs[c] ^ gf256Mul(coef, otherCoef); v != 0 {
+			r.coefs[c] = v
+		} else {
+			delete(r.coefs, c)
+		}
+	}
+	symbolAddScaled(r.value, coef, other.value)
+}
+
+// eliminate removes the solved columns from the row, with the rows that solved them. These rows only contain the
+// column they solved and inactivated columns.
+func (r *raptorQRow) eliminate(solved []bool, pivotRows []*raptorQRow, except int) {
+	var columns []int
+	for c := range r.coefs {
+		if c != except && solved[c] {
+			columns = append(columns, c)
+		}
+	}
+	for _, c := range columns {
+		pivot := pivotRows[c]
+		r.addScaled(gf256Div(r.coefs[c], pivot.coefs[c]), pivot)
+	}
+}
+
+// raptorQSolve returns the intermediate symbols determined by the rows, with the inactivation decoding of
+// Section 5.4.2. The values of the rows are changed.
+func raptorQSolve(p *raptorQParameters, rows []*raptorQRow, symbolLen int) ([][]byte, error) {
+	if len(rows) < p.l {
+		return nil, errRaptorQSingularSystem
+	}
+	const (
+		active = iota
+		solved
+		inactive
+	)
+	state := make([]int, p.l)
+	isSolved := make([]bool, p.l)
+	pivotRows := make([]*raptorQRow, p.l)
+	var pivotOrder []int
+	var inactivated []int
+	isPivot := make(map[*raptorQRow]bool)
+
+	// the sparse rows that contain each column, the HDPC rows are only used in the dense elimination
+	columnRows := make([][]*raptorQRow, p.l)
+	for _, row := range rows {
+		if row.hdpc {
+			continue
+		}
+		row.activeDegree = len(row.coefs)
+		for c := range row.coefs {
+			columnRows[c] = append(columnRows[c], row)
+		}
+	}
+	var queue []*raptorQRow
+	for _, row := range rows {
+		if !row.hdpc && row.activeDegree == 1 {
+			queue = append(queue, row)
+		}
+	}
+	leave := func(c int, newState int) {
+		state[c] = newState
+		for _, row := range columnRows[c] {
+			row.activeDegree--
+			if row.activeDegree == 1 && !isPivot[row] {
+				queue = append(queue, row)
+			}
+		}
+	}
+	// the PI symbols are inactivated from the start, Section 5.4.2.2
+	for c := p.w; c < p.l; c++ {
+		inactivated = append(inactivated, c)
+		leave(c, inactive)
+	}
+
+	remaining := p.w
+	for remaining > 0 {
+		for len(queue) > 0 && remaining > 0 {
+			row := queue[0]
+			queue = queue[1:]
+			if isPivot[row] || row.activeDegree != 1 {
+				continue
+			}
+			for c := range row.coefs {
+				if state[c] == active {
+					isPivot[row] = true
+					pivotRows[c] = row
+					isSolved[c] = true
+					pivotOrder = append(pivotOrder, c)
+					remaining--
+					leave(c, solved)
+					break
+				}
+			}
+		}
+		if remaining == 0 {
+			break
+		}
+		// the peeling is stuck: inactivate all the active columns but one of a row of the lowest degree
+		var chosen *raptorQRow
+		for _, row := range rows {
+			if !row.hdpc && !isPivot[row] && row.activeDegree >= 2 && (chosen == nil || row.activeDegree < chosen.activeDegree) {
+				chosen = row
+			}
+		}
+		var columns []int
+		if chosen != nil {
+			for c := range chosen.coefs {
+				if state[c] == active {
+					columns = append(columns, c)
+				}
+			}
+			columns = columns[1:]
+		} else {
+			// no row can solve the remaining columns, let the dense elimination try with the HDPC rows
+			for c := 0; c < p.w; c++ {
+				if state[c] == active {
+					columns = append(columns, c)
+				}
+			}
+		}
+		for _, c := range columns {
+			inactivated = append(inactivated, c)
+			remaining--
+			leave(c, inactive)
+		}
+	}
+
+	// Express the solved columns with the inactivated ones only
+	for _, c := range pivotOrder {
+		row := pivotRows[c]
+		row.eliminate(isSolved, pivotRows, c)
+		if coef := row.coefs[c]; coef != 1 {
+			inv := gf256Div(1, coef)
+			for col, v := range row.coefs {
+				row.coefs[col] = gf256Mul(v, inv)
+			}
+			symbolMul(row.value, inv)
+		}
+	}
+
+	// Gaussian elimination of the inactivated columns, with the rows that solved no column
+	inactiveIndex := make(map[int]int, len(inactivated))
+	for i, c := range inactivated {
+		inactiveIndex[c] = i
+	}
+	u := len(inactivated)
+	var system [][]uint8
+	var values [][]byte
+	for _, row := range rows {
+		if isPivot[row] {
+			continue
+		}
+		row.eliminate(isSolved, pivotRows, -1)
+		equation := make([]uint8, u)
+		for c, coef := range row.coefs {
+			equation[inactiveIndex[c]] = coef
+		}
+		system = append(system, equation)
+		values = append(values, row.value)
+	}
+	for col := 0; col < u; col++ {
+		pivot := -1
+		for r := col; r < len(system); r++ {
+			if system[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, errRaptorQSingularSystem
+		}
+		system[col], system[pivot] = system[pivot], system[col]
+		values[col], values[pivot] = values[pivot], values[col]
+		if coef := system[col][col]; coef != 1 {
+			symbolDiv(system[col], coef)
+			symbolDiv(values[col], coef)
+		}
+		for r := range system {
+			if r != col && system[r][col] != 0 {
+				coef := system[r][col]
+				symbolSubScaled(system[r], coef, system[col])
+				symbolSubScaled(values[r], coef, values[col])
+			}
+		}
+	}
+
+	intermediate := make([][]byte, p.l)
+	for i, c := range inactivated {
+		intermediate[c] = values[i]
+	}
+	for _, c := range pivotOrder {
+		row := pivotRows[c]
+		symbol := row.value
+		for col, coef := range row.coefs {
+			if col != c {
+				symbolSubScaled(symbol, coef, intermediate[col])
+			}
+		}
+		intermediate[c] = symbol
+	}
+	for c := range intermediate {
+		if intermediate[c] == nil {
+			intermediate[c] = make([]byte, symbolLen)
+		}
+	}
+	return intermediate, nil
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nextPrime returns the smallest prime greater or equal to n
+func nextPrime(n int) int {
+	for !isPrime(n) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package fec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+var _ BlockFECScheme = &RaptorQFECScheme{}
+
+var RaptorQNotEnoughSymbols = errors.New("RaptorQ FEC Scheme: not enough symbols to recover the FEC Group")
+
+// RaptorQFECScheme is a rateless systematic block FEC scheme, using the RaptorQ code of RFC 6330 (see raptorq.go).
+// The source symbols of a block of K packets have the encoding symbol IDs 0 to K-1, and its repair symbols the
+// encoding symbol IDs K and above, carried in the FEC scheme specific field.
+// Unlike Reed-Solomon, no encoder has to be built for each (n, k), and the sender can generate repair symbols
+// for a block as long as the redundancy controller asks for more. The receiver decodes with a high probability as
+// soon as it received K symbols, and most of the decoding works on sparse equations.
+type RaptorQFECScheme struct{}
+
+// NewRaptorQFECScheme returns a new RaptorQFECScheme
+func NewRaptorQFECScheme() *RaptorQFECScheme {
+	return &RaptorQFECScheme{}
+}
+
+// GetRepairSymbols generates numberOfSymbols new repair symbols for the FEC block. Their encoding symbol IDs follow
+// the ones of the repair symbols generated before for the same block, so that every call brings new symbols.
+// The symbol number only identifies the FEC frames of a symbol on the wire, it wraps around after 256 symbols.
+func (f *RaptorQFECScheme) GetRepairSymbols(fecGroup FECContainer, numberOfSymbols uint, blockNumber protocol.FECBlockNumber) ([]*RepairSymbol, error) {
+	packets := fecGroup.GetPackets()
+	params, err := raptorQParametersOf(len(packets))
+	if err != nil {
+		return nil, err
+	}
+	maxLen := 0
+	for _, packet := range packets {
+		maxLen = utils.Max(maxLen, len(packet))
+	}
+
+	firstESI := uint32(params.k)
+	var intermediate [][]byte
+	if block, ok := fecGroup.(*FECBlock); ok {
+		if block.nextRepairESI > firstESI {
+			firstESI = block.nextRepairESI
+		}
+		intermediate = block.intermediateSymbols
+	}
+	if uint64(firstESI)+uint64(numberOfSymbols) > raptorQMaxESI+1 {
+		return nil, fmt.Errorf("impossible to send %d more repair symbols in FEC block %d", numberOfSymbols, blockNumber)
+	}
+	// the number of intermediate symbols grows with K: they are computed again if the block has new packets
+	if len(intermediate) != params.l || len(intermediate[0]) != maxLen {
+		rows := params.constraintRows(maxLen)
+		for i, packet := range packets {
+			value := make([]byte, maxLen)
+			copy(value, packet)
+			rows = append(rows, params.ltRow(uint32(i), value))
+		}
+		if intermediate, err = raptorQSolve(params, rows, maxLen); err != nil {
+			return nil, err
+		}
+	}
+	if block, ok := fecGroup.(*FECBlock); ok {
+		block.nextRepairESI = firstESI + uint32(numberOfSymbols)
+		block.intermediateSymbols = intermediate
+	}
+
+	symbols := make([]*RepairSymbol, numberOfSymbols)
+	for i := range symbols {
+		esi := firstESI + uint32(i)
+		symbols[i] = &RepairSymbol{
+			FECSchemeSpecific: esi,
+			SymbolNumber:      byte(esi - uint32(params.k)),
+			Data:              params.encodingSymbol(esi, intermediate),
+			Convolutional:     f.Convolutional(),
+		}
+	}
+	return symbols, nil
+}
+
+func (f *RaptorQFECScheme) RecoverPackets(fecGroup *FECBlock) ([][]byte, error) {
+	if len(fecGroup.RepairSymbols) == 0 {
+		return nil, RaptorQNotEnoughSymbols
+	}
+	n := fecGroup.TotalNumberOfPackets
+	var missing []int
+	for i := 0; i < n; i++ {
+		if i >= len(fecGroup.packets) || fecGroup.packets[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	params, err := raptorQParametersOf(n)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolLen := 0
+	for _, symbol := range fecGroup.RepairSymbols {
+		symbolLen = utils.Max(symbolLen, len(symbol.Data))
+	}
+	rows := params.constraintRows(symbolLen)
+	for i := 0; i < n && i < len(fecGroup.packets); i++ {
+		if fecGroup.packets[i] != nil {
+			value := make([]byte, symbolLen)
+			copy(value, fecGroup.packets[i])
+			rows = append(rows, params.ltRow(uint32(i), value))
+		}
+	}
+	for _, symbol := range fecGroup.RepairSymbols {
+		value := make([]byte, symbolLen)
+		copy(value, symbol.Data)
+		rows = append(rows, params.ltRow(symbol.FECSchemeSpecific, value))
+	}
+	intermediate, err := raptorQSolve(params, rows, symbolLen)
+	if err == errRaptorQSingularSystem {
+		return nil, RaptorQNotEnoughSymbols
+	} else if err != nil {
+		return nil, err
+	}
+	recovered := make([][]byte, len(missing))
+	for i, index := range missing {
+		recovered[i] = params.encodingSymbol(uint32(index), intermediate)
+	}
+	return recovered, nil
+}
+
+func (*RaptorQFECScheme) CanRecoverPackets(fecGroup *FECBlock) bool {
+	return len(fecGroup.RepairSymbols) != 0 &&
+		fecGroup.TotalNumberOfPackets != 0 &&
+		fecGroup.CurrentNumberOfPackets() < fecGroup.TotalNumberOfPackets && // there is nothing to recover if this is not true
+		fecGroup.CurrentNumberOfPackets()+len(fecGroup.RepairSymbols) >= fecGroup.TotalNumberOfPackets // impossible to recover if this is not true
+}
+
+func (*RaptorQFECScheme) Convolutional() bool {
+	return false
+}
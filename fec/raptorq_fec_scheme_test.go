@@ -0,0 +1,143 @@
+package fec
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RaptorQ FEC Scheme", func() {
+	var (
+		packets   [][]byte
+		fecGroup  *FECBlock
+		fecScheme *RaptorQFECScheme
+	)
+
+	// receivedGroup returns the FEC Group as seen by a receiver that received the packets at indexes received and the repair symbols
+	receivedGroup := func(received []int, symbols []*RepairSymbol) *FECBlock {
+		group := NewFECGroup(42, versionIETFQUIC)
+		for _, i := range received {
+			group.AddPacket(packets[i], &wire.Header{
+				PacketNumber: protocol.PacketNumber(i + 1),
+				FECPayloadID: protocol.NewBlockSourceFECPayloadID(42, uint8(i)),
+			})
+		}
+		for _, s := range symbols {
+			group.AddRepairSymbol(s)
+		}
+		group.TotalNumberOfPackets = len(packets)
+		group.TotalNumberOfRepairSymbols = len(symbols)
+		return group
+	}
+
+	BeforeEach(func() {
+		packets = [][]byte{
+			{0xDE, 0xAD, 0xBE, 0xEF},
+			{0xCA, 0xFE},
+			{0x01, 0x23, 0x45, 0x67, 0x89},
+			{0x13, 0x37},
+		}
+		fecGroup = NewFECGroup(42, versionIETFQUIC)
+		for i, p := range packets {
+			fecGroup.AddPacket(p, &wire.Header{
+				PacketNumber: protocol.PacketNumber(i + 1),
+				FECPayloadID: protocol.NewBlockSourceFECPayloadID(42, uint8(i)),
+			})
+		}
+		fecScheme = NewRaptorQFECScheme()
+	})
+
+	// pad returns the packet padded with zeroes to the size of the repair symbols
+	pad := func(p []byte) []byte {
+		return append(append([]byte{}, p...), bytes.Repeat([]byte{0}, 5-len(p))...)
+	}
+
+	It("generates the requested number of repair symbols", func() {
+		symbols, err := fecScheme.GetRepairSymbols(fecGroup, 10, 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(symbols).To(HaveLen(10))
+		for i, s := range symbols {
+			Expect(s.SymbolNumber).To(Equal(byte(i)))
+			// the encoding symbol IDs 0 to 3 are the ones of the source symbols
+			Expect(s.FECSchemeSpecific).To(Equal(uint32(4 + i)))
+			Expect(s.Data).To(HaveLen(5))
+		}
+		Expect(symbols[0].Data).ToNot(Equal(symbols[1].Data))
+	})
+
+	It("generates more repair symbols than a Reed-Solomon FEC Group can carry", func() {
+		symbols, err := fecScheme.GetRepairSymbols(fecGroup, 300, 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(symbols).To(HaveLen(300))
+		Expect(symbols[299].FECSchemeSpecific).To(Equal(uint32(4 + 299)))
+		Expect(symbols[299].SymbolNumber).To(Equal(byte(299 % 256)))
+	})
+
+	It("generates new repair symbols on every call for the same FEC Group", func() {
+		first, err := fecScheme.GetRepairSymbols(fecGroup, 2, 42)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := fecScheme.GetRepairSymbols(fecGroup, 2, 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second[0].FECSchemeSpecific).To(Equal(uint32(6)))
+		Expect(second[1].FECSchemeSpecific).To(Equal(uint32(7)))
+		Expect(second[0].Data).ToNot(Equal(first[0].Data))
+		// the symbols of both calls determine the block
+		recovered, err := fecScheme.RecoverPackets(receivedGroup(nil, append(first, second...)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{pad(packets[0]), pad(packets[1]), pad(packets[2]), pad(packets[3])}))
+	})
+
+	It("refuses to reuse the encoding symbol IDs of a FEC Group", func() {
+		fecGroup.nextRepairESI = raptorQMaxESI - 1
+		_, err := fecScheme.GetRepairSymbols(fecGroup, 3, 42)
+		Expect(err).To(HaveOccurred())
+		symbols, err := fecScheme.GetRepairSymbols(fecGroup, 2, 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(symbols[1].FECSchemeSpecific).To(Equal(uint32(raptorQMaxESI)))
+	})
+
+	It("states that it cannot recover packets without enough symbols", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 1, 42)
+		Expect(fecScheme.CanRecoverPackets(receivedGroup([]int{0, 2}, symbols))).To(BeFalse())
+		Expect(fecScheme.CanRecoverPackets(receivedGroup([]int{0, 1, 2, 3}, symbols))).To(BeFalse())
+		Expect(fecScheme.CanRecoverPackets(receivedGroup([]int{0, 1, 2}, nil))).To(BeFalse())
+	})
+
+	It("recovers a lost packet with any repair symbol", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 3, 42)
+		group := receivedGroup([]int{0, 2, 3}, symbols[2:])
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{pad(packets[1])}))
+	})
+
+	It("recovers the last packets of the FEC Group", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 2, 42)
+		group := receivedGroup([]int{0, 1}, symbols)
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{pad(packets[2]), pad(packets[3])}))
+	})
+
+	It("recovers a FEC Group from repair symbols only", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		group := receivedGroup(nil, symbols[2:])
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{pad(packets[0]), pad(packets[1]), pad(packets[2]), pad(packets[3])}))
+	})
+
+	It("errors when the symbols are not enough to decode", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 1, 42)
+		group := receivedGroup([]int{0, 1}, []*RepairSymbol{symbols[0], symbols[0]})
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		_, err := fecScheme.RecoverPackets(group)
+		Expect(err).To(MatchError(RaptorQNotEnoughSymbols))
+	})
+})
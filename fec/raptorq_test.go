@@ -0,0 +1,107 @@
+package fec
+
+import (
+	"math/rand"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RaptorQ code", func() {
+	It("uses the parameters of RFC 6330 for small blocks", func() {
+		for _, expected := range []raptorQParameters{
+			{k: 10, s: 7, h: 10, w: 17},
+			{k: 12, s: 7, h: 10, w: 19},
+			{k: 18, s: 11, h: 10, w: 29},
+			{k: 20, s: 11, h: 10, w: 31},
+			{k: 26, s: 11, h: 10, w: 37},
+		} {
+			params, err := raptorQParametersOf(expected.k)
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]int{params.s, params.h, params.w}).To(Equal([]int{expected.s, expected.h, expected.w}))
+			Expect(params.l).To(Equal(expected.k + expected.s + expected.h))
+			Expect(params.p).To(BeNumerically(">=", params.h))
+			Expect(isPrime(params.p1)).To(BeTrue())
+		}
+	})
+
+	It("refuses invalid numbers of source symbols", func() {
+		_, err := raptorQParametersOf(0)
+		Expect(err).To(HaveOccurred())
+		_, err = raptorQParametersOf(raptorQMaxSourceSymbols + 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("is systematic", func() {
+		const k = 50
+		params, err := raptorQParametersOf(k)
+		Expect(err).ToNot(HaveOccurred())
+		r := rand.New(rand.NewSource(1))
+		sources := make([][]byte, k)
+		rows := params.constraintRows(100)
+		for i := range sources {
+			sources[i] = make([]byte, 100)
+			r.Read(sources[i])
+			rows = append(rows, params.ltRow(uint32(i), append([]byte{}, sources[i]...)))
+		}
+		intermediate, err := raptorQSolve(params, rows, 100)
+		Expect(err).ToNot(HaveOccurred())
+		for i, source := range sources {
+			Expect(params.encodingSymbol(uint32(i), intermediate)).To(Equal(source))
+		}
+	})
+
+	It("picks its LT symbols among the intermediate symbols", func() {
+		params, err := raptorQParametersOf(100)
+		Expect(err).ToNot(HaveOccurred())
+		for x := uint32(0); x < 1000; x++ {
+			columns := params.ltColumns(x)
+			Expect(len(columns)).To(BeNumerically(">=", 3))
+			for _, c := range columns {
+				Expect(c).To(BeNumerically("<", params.l))
+			}
+		}
+	})
+
+	It("recovers a large block from half of its packets", func() {
+		const k = 250
+		r := rand.New(rand.NewSource(2))
+		packets := make([][]byte, k)
+		sender := NewFECGroup(7, versionIETFQUIC)
+		for i := range packets {
+			packets[i] = make([]byte, 1+r.Intn(200))
+			r.Read(packets[i])
+			sender.AddPacket(packets[i], &wire.Header{
+				PacketNumber: protocol.PacketNumber(i + 1),
+				FECPayloadID: protocol.NewBlockSourceFECPayloadID(7, uint8(i)),
+			})
+		}
+		scheme := NewRaptorQFECScheme()
+		symbols, err := scheme.GetRepairSymbols(sender, k/2+2, 7)
+		Expect(err).ToNot(HaveOccurred())
+
+		receiver := NewFECGroup(7, versionIETFQUIC)
+		for i := 0; i < k; i += 2 {
+			receiver.AddPacket(packets[i], &wire.Header{
+				PacketNumber: protocol.PacketNumber(i + 1),
+				FECPayloadID: protocol.NewBlockSourceFECPayloadID(7, uint8(i)),
+			})
+		}
+		for _, s := range symbols {
+			receiver.AddRepairSymbol(s)
+		}
+		receiver.TotalNumberOfPackets = k
+		receiver.TotalNumberOfRepairSymbols = len(symbols)
+		Expect(scheme.CanRecoverPackets(receiver)).To(BeTrue())
+		recovered, err := scheme.RecoverPackets(receiver)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(HaveLen(k / 2))
+		for i, p := range recovered {
+			lost := packets[2*i+1]
+			Expect(p[:len(lost)]).To(Equal(lost))
+			Expect(symbolIsZero(p[len(lost):])).To(BeTrue())
+		}
+	})
+})
@@ -22,6 +22,21 @@ func NewConstantRedundancyController(nDataSymbols uint, nRepairSymbols uint, nIn
 	}
 }
 
+// NewFECProtectionLevelRedundancyController returns the default redundancy controller of a FEC protection level:
+// 1 repair symbol for 20 packets at the low level, 2 for 10 at the medium level and 2 interleaved blocks of 5 source
+// and 5 repair symbols at the high level. It returns nil for the default level and the level without protection.
+func NewFECProtectionLevelRedundancyController(level protocol.FECProtectionLevel) RedundancyController {
+	switch level {
+	case protocol.FECProtectionLow:
+		return NewConstantRedundancyController(20, 1, 1, 6)
+	case protocol.FECProtectionMedium:
+		return NewConstantRedundancyController(10, 2, 1, 4)
+	case protocol.FECProtectionHigh:
+		return NewConstantRedundancyController(5, 5, 2, 2)
+	}
+	return nil
+}
+
 func (*constantRedundancyController) OnPacketLost(protocol.PacketNumber) {}
 
 func (*constantRedundancyController) OnPacketReceived(protocol.PacketNumber) {}
@@ -0,0 +1,356 @@
+package fec
+
+import (
+	"math"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+const (
+	// the number of loss and reception events between two fits of the model
+	geSampleSize = 200
+	// the weight of the previous samples in the counters when a new sample begins
+	geMemory = 0.5
+	// below this number of bursts in the counters, the estimations of the BurstEstimators are preferred
+	geMinimumBursts = 3
+	// the deepest interleaving tried when choosing the parameters, whatever the maximum number of interleaved blocks
+	geMaxSearchedInterleavedBlocks = 16
+	// once the state of the model is nearly independent between two packets of a block, deeper interleaving does not
+	// change the losses of the block anymore
+	geIndependenceThreshold = 1e-3
+	// the weight of a new sample in the estimations of a LossBurstEstimator
+	lossBurstSmoothingFactor = 0.125
+)
+
+// A BurstEstimator estimates the loss bursts of a path, as the OLIA congestion controller does
+type BurstEstimator interface {
+	// returns the smoothed number of consecutive lost packets, or 0 if unknown
+	GetEstimatedBurstLength() float64
+	// returns the smoothed number of packets delivered between two losses, or 0 if unknown
+	GetSmoothedBytesBetweenTwoLosses() float64
+}
+
+// A BurstAwareRedundancyController uses the estimations of the BurstEstimators of the paths whose losses it follows.
+// Every path gives it its own estimator, the congestion controller of the path if it estimates the loss bursts.
+type BurstAwareRedundancyController interface {
+	RedundancyController
+	// SetBurstEstimator sets the estimator of the path pathID, or removes it if e is nil
+	SetBurstEstimator(pathID protocol.PathID, e BurstEstimator)
+}
+
+// A LossBurstEstimator is the BurstEstimator of a path whose congestion controller does not estimate the loss bursts.
+// It smooths the numbers of consecutive lost packets and of packets delivered between two bursts of losses.
+type LossBurstEstimator struct {
+	hasLost              bool
+	inBurst              bool
+	lastLostPacketNumber protocol.PacketNumber
+	currentBurstLength   float64
+	deliveredSinceBurst  float64
+	// the smoothed estimations, 0 until they have a first sample
+	burstLength, deliveredBetweenLosses float64
+}
+
+var _ BurstEstimator = &LossBurstEstimator{}
+
+// NewLossBurstEstimator returns a LossBurstEstimator, to be fed with the losses and the deliveries of its path
+func NewLossBurstEstimator() *LossBurstEstimator {
+	return &LossBurstEstimator{}
+}
+
+func (e *LossBurstEstimator) OnPacketLost(pn protocol.PacketNumber) {
+	if e.inBurst && pn == e.lastLostPacketNumber+1 {
+		e.currentBurstLength++
+	} else {
+		if e.inBurst {
+			e.burstLength = smoothBurstEstimation(e.burstLength, e.currentBurstLength)
+		}
+		if e.hasLost {
+			e.deliveredBetweenLosses = smoothBurstEstimation(e.deliveredBetweenLosses, e.deliveredSinceBurst)
+		}
+		e.currentBurstLength = 1
+		e.deliveredSinceBurst = 0
+	}
+	e.hasLost = true
+	e.inBurst = true
+	e.lastLostPacketNumber = pn
+}
+
+func (e *LossBurstEstimator) OnPacketReceived(protocol.PacketNumber) {
+	if e.inBurst {
+		e.burstLength = smoothBurstEstimation(e.burstLength, e.currentBurstLength)
+		e.inBurst = false
+	}
+	e.deliveredSinceBurst++
+}
+
+func (e *LossBurstEstimator) GetEstimatedBurstLength() float64 {
+	return e.burstLength
+}
+
+func (e *LossBurstEstimator) GetSmoothedBytesBetweenTwoLosses() float64 {
+	return e.deliveredBetweenLosses
+}
+
+func smoothBurstEstimation(estimation, sample float64) float64 {
+	if estimation == 0 {
+		return sample
+	}
+	return (1-lossBurstSmoothingFactor)*estimation + lossBurstSmoothingFactor*sample
+}
+
+// gilbertElliottRedundancyController fits a two-state Gilbert-Elliott model to the losses of its path: the packets are
+// delivered in the good state and lost in the bad state, p is the probability to go from the good to the bad state and
+// r the probability to go back. It chooses the number of source and repair symbols and the interleaving depth with the
+// lowest overhead whose residual loss under the model stays below the target.
+// Warning: like the average redundancy controller, it assumes that the packet numbers increase by one.
+type gilbertElliottRedundancyController struct {
+	maxNumberOfSourceSymbols     uint8
+	maxNumberOfRepairSymbols     uint8
+	maxNumberOfInterleavedBlocks uint8
+	targetResidualLoss           float64
+
+	// the burst estimators of the paths whose losses the controller follows, set while the paths are created
+	burstEstimatorsMutex sync.Mutex
+	burstEstimators      map[protocol.PathID]BurstEstimator
+
+	hasLost              bool
+	lastLostPacketNumber protocol.PacketNumber
+	eventsCounter        uint
+	// decayed counts of delivered packets, lost packets, and bursts of losses
+	received, lost, bursts float64
+
+	nSourceSymbols     uint
+	nRepairSymbols     uint
+	nInterleavedBlocks uint
+}
+
+var _ BurstAwareRedundancyController = &gilbertElliottRedundancyController{}
+
+// NewGilbertElliottRedundancyController returns a redundancy controller protecting the packets such that the residual
+// loss expected after the FEC recovery stays below targetResidualLoss, if possible within the given maximums.
+func NewGilbertElliottRedundancyController(maxNumberOfSourceSymbols, maxNumberOfRepairSymbols, maxNumberOfInterleavedBlocks uint8, targetResidualLoss float64) RedundancyController {
+	atLeastOne := func(n uint8) uint8 {
+		if n == 0 {
+			return 1
+		}
+		return n
+	}
+	return &gilbertElliottRedundancyController{
+		maxNumberOfSourceSymbols:     atLeastOne(maxNumberOfSourceSymbols),
+		maxNumberOfRepairSymbols:     atLeastOne(maxNumberOfRepairSymbols),
+		maxNumberOfInterleavedBlocks: atLeastOne(maxNumberOfInterleavedBlocks),
+		targetResidualLoss:           targetResidualLoss,
+		nSourceSymbols:               uint(atLeastOne(maxNumberOfSourceSymbols)),
+		nRepairSymbols:               1,
+		nInterleavedBlocks:           1,
+		burstEstimators:              make(map[protocol.PathID]BurstEstimator),
+	}
+}
+
+func (c *gilbertElliottRedundancyController) SetBurstEstimator(pathID protocol.PathID, e BurstEstimator) {
+	c.burstEstimatorsMutex.Lock()
+	defer c.burstEstimatorsMutex.Unlock()
+	if e == nil {
+		delete(c.burstEstimators, pathID)
+		return
+	}
+	c.burstEstimators[pathID] = e
+}
+
+func (c *gilbertElliottRedundancyController) OnPacketLost(pn protocol.PacketNumber) {
+	if !c.hasLost || pn != c.lastLostPacketNumber+1 {
+		c.bursts++
+	}
+	c.lost++
+	c.hasLost = true
+	c.lastLostPacketNumber = pn
+	c.incrementCounter()
+}
+
+func (c *gilbertElliottRedundancyController) OnPacketReceived(protocol.PacketNumber) {
+	c.received++
+	c.incrementCounter()
+}
+
+func (c *gilbertElliottRedundancyController) GetNumberOfDataSymbols() uint {
+	return c.nSourceSymbols
+}
+
+func (c *gilbertElliottRedundancyController) GetNumberOfRepairSymbols() uint {
+	return c.nRepairSymbols
+}
+
+func (c *gilbertElliottRedundancyController) GetNumberOfInterleavedBlocks() uint {
+	return c.nInterleavedBlocks
+}
+
+// GetWindowStepSize sends the repair symbols of a convolutional FEC scheme at the same rate as for a block
+func (c *gilbertElliottRedundancyController) GetWindowStepSize() uint {
+	return c.nSourceSymbols
+}
+
+func (c *gilbertElliottRedundancyController) PushParamerters(paras TransParams) {}
+
+func (c *gilbertElliottRedundancyController) incrementCounter() {
+	c.eventsCounter++
+	if c.eventsCounter == geSampleSize {
+		c.computeEstimations()
+		c.eventsCounter = 0
+		c.received *= geMemory
+		c.lost *= geMemory
+		c.bursts *= geMemory
+	}
+}
+
+func (c *gilbertElliottRedundancyController) computeEstimations() {
+	p, r, ok := c.fitModel()
+	if !ok {
+		return
+	}
+	c.nSourceSymbols, c.nRepairSymbols, c.nInterleavedBlocks = c.chooseParameters(p, r)
+}
+
+// fitModel returns the transition probabilities of the model
+func (c *gilbertElliottRedundancyController) fitModel() (p float64, r float64, ok bool) {
+	if c.bursts < geMinimumBursts {
+		if burstLength, deliveredBetweenLosses, ok := c.estimateBursts(); ok {
+			return 1 / deliveredBetweenLosses, 1 / burstLength, true
+		}
+	}
+	if c.received == 0 {
+		return 0, 0, false
+	}
+	if c.bursts == 0 {
+		// no loss
+		return 0, 1, true
+	}
+	return math.Min(1, c.bursts/c.received), c.bursts / c.lost, true
+}
+
+// estimateBursts returns the mean of the estimations of the burst estimators of the paths, if they have some
+func (c *gilbertElliottRedundancyController) estimateBursts() (burstLength float64, deliveredBetweenLosses float64, ok bool) {
+	c.burstEstimatorsMutex.Lock()
+	defer c.burstEstimatorsMutex.Unlock()
+	n := 0
+	for _, e := range c.burstEstimators {
+		length, delivered := e.GetEstimatedBurstLength(), e.GetSmoothedBytesBetweenTwoLosses()
+		if length >= 1 && delivered >= 1 {
+			burstLength += length
+			deliveredBetweenLosses += delivered
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return burstLength / float64(n), deliveredBetweenLosses / float64(n), true
+}
+
+// chooseParameters returns the number of source and repair symbols and the number of interleaved blocks with the lowest
+// overhead meeting the target residual loss, or the lowest residual loss if the target cannot be met.
+// The interleaving depths are tried up to geMaxSearchedInterleavedBlocks, and only while they change the model.
+func (c *gilbertElliottRedundancyController) chooseParameters(p float64, r float64) (uint, uint, uint) {
+	maxSource, maxRepair := int(c.maxNumberOfSourceSymbols), int(c.maxNumberOfRepairSymbols)
+	maxInterleaved := int(c.maxNumberOfInterleavedBlocks)
+	if maxInterleaved > geMaxSearchedInterleavedBlocks {
+		maxInterleaved = geMaxSearchedInterleavedBlocks
+	}
+	bestSource, bestRepair, bestInterleaved := maxSource, 1, 1
+	bestOverhead, bestResidual := math.Inf(1), math.Inf(1)
+	for d := 1; d <= maxInterleaved; d++ {
+		lossDistributions := gilbertElliottLossDistributions(p, r, d, maxSource+maxRepair)
+		for n := 2; n <= maxSource+maxRepair; n++ {
+			residuals := residualLosses(lossDistributions[n], maxRepair)
+			for repair := 1; repair <= maxRepair && repair < n; repair++ {
+				source := n - repair
+				if source > maxSource {
+					continue
+				}
+				residual := residuals[repair]
+				overhead := float64(repair) / float64(source)
+				meets := residual <= c.targetResidualLoss
+				if meets && overhead < bestOverhead || !meets && math.IsInf(bestOverhead, 1) && residual < bestResidual {
+					bestSource, bestRepair, bestInterleaved = source, repair, d
+					bestResidual = residual
+					if meets {
+						bestOverhead = overhead
+					}
+				}
+			}
+		}
+		if math.Pow(math.Abs(1-p-r), float64(d)) < geIndependenceThreshold {
+			break
+		}
+	}
+	return uint(bestSource), uint(bestRepair), uint(bestInterleaved)
+}
+
+// gilbertElliottLossDistributions returns, for every n up to maxN, the probability distribution of the number of lost
+// packets among n packets of a block interleaved with d-1 other blocks, i.e. sent every d packets
+func gilbertElliottLossDistributions(p float64, r float64, d int, maxN int) [][]float64 {
+	distributions := make([][]float64, maxN+1)
+	distributions[0] = []float64{1}
+	if p == 0 {
+		for n := 1; n <= maxN; n++ {
+			distributions[n] = make([]float64, n+1)
+			distributions[n][0] = 1
+		}
+		return distributions
+	}
+	// the transitions between two packets of the block are the d-step transitions of the chain
+	piBad := p / (p + r)
+	mixing := 1 - math.Pow(1-p-r, float64(d))
+	pd := piBad * mixing
+	rd := (1 - piBad) * mixing
+	// good[l] and bad[l] are the probabilities to have lost l packets and to be in the good or bad state
+	good := []float64{1 - piBad}
+	bad := []float64{0, piBad}
+	for n := 1; n <= maxN; n++ {
+		distributions[n] = make([]float64, n+1)
+		for l := range good {
+			distributions[n][l] += good[l]
+		}
+		for l := range bad {
+			distributions[n][l] += bad[l]
+		}
+		nextGood := make([]float64, n+1)
+		nextBad := make([]float64, n+2)
+		for l := range good {
+			nextGood[l] += good[l] * (1 - pd)
+			nextBad[l+1] += good[l] * pd
+		}
+		for l := range bad {
+			nextGood[l] += bad[l] * rd
+			nextBad[l+1] += bad[l] * (1 - rd)
+		}
+		good, bad = nextGood, nextBad
+	}
+	return distributions
+}
+
+// residualLosses returns the residualLoss of the block for every number of repair symbols up to maxRepair
+func residualLosses(lossDistribution []float64, maxRepair int) []float64 {
+	n := len(lossDistribution) - 1
+	residuals := make([]float64, maxRepair+1)
+	// the residual loss with repair symbols is the one with repair+1 symbols, plus the blocks losing repair+1 packets
+	tail := 0.0
+	for l := n; l >= 1; l-- {
+		tail += lossDistribution[l] * float64(l) / float64(n)
+		if l-1 <= maxRepair {
+			residuals[l-1] = tail
+		}
+	}
+	return residuals
+}
+
+// residualLoss returns the expected ratio of packets that cannot be recovered by repair repair symbols of an MDS code,
+// given the distribution of the number of lost packets of the block
+func residualLoss(lossDistribution []float64, repair int) float64 {
+	n := len(lossDistribution) - 1
+	residual := 0.0
+	for l := repair + 1; l <= n; l++ {
+		residual += lossDistribution[l] * float64(l) / float64(n)
+	}
+	return residual
+}
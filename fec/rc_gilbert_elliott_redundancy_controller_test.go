@@ -0,0 +1,171 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockBurstEstimator struct {
+	burstLength, deliveredBetweenLosses float64
+}
+
+func (e *mockBurstEstimator) GetEstimatedBurstLength() float64 { return e.burstLength }
+
+func (e *mockBurstEstimator) GetSmoothedBytesBetweenTwoLosses() float64 {
+	return e.deliveredBetweenLosses
+}
+
+var _ = Describe("Gilbert-Elliott redundancy controller", func() {
+	var controller *gilbertElliottRedundancyController
+
+	// replay repeats a pattern of received packets followed by lost packets
+	replay := func(received, lost int, times int) {
+		pn := protocol.PacketNumber(1)
+		for i := 0; i < times; i++ {
+			for j := 0; j < received; j++ {
+				controller.OnPacketReceived(pn)
+				pn++
+			}
+			for j := 0; j < lost; j++ {
+				controller.OnPacketLost(pn)
+				pn++
+			}
+		}
+	}
+
+	// expectedResidualLoss returns the residual loss of the current parameters under the model p, r
+	expectedResidualLoss := func(p, r float64) float64 {
+		n := int(controller.GetNumberOfDataSymbols() + controller.GetNumberOfRepairSymbols())
+		distributions := gilbertElliottLossDistributions(p, r, int(controller.GetNumberOfInterleavedBlocks()), n)
+		return residualLoss(distributions[n], int(controller.GetNumberOfRepairSymbols()))
+	}
+
+	BeforeEach(func() {
+		controller = NewGilbertElliottRedundancyController(20, 10, 4, 0.01).(*gilbertElliottRedundancyController)
+	})
+
+	It("starts with the largest blocks and a single repair symbol", func() {
+		Expect(controller.GetNumberOfDataSymbols()).To(Equal(uint(20)))
+		Expect(controller.GetNumberOfRepairSymbols()).To(Equal(uint(1)))
+		Expect(controller.GetNumberOfInterleavedBlocks()).To(Equal(uint(1)))
+	})
+
+	It("computes the distribution of the number of losses", func() {
+		distributions := gilbertElliottLossDistributions(0.1, 0.5, 1, 10)
+		for n, distribution := range distributions {
+			Expect(distribution).To(HaveLen(n + 1))
+			sum, mean := 0.0, 0.0
+			for l, probability := range distribution {
+				sum += probability
+				mean += float64(l) * probability
+			}
+			Expect(sum).To(BeNumerically("~", 1, 1e-9))
+			// the mean loss rate is the probability of the bad state
+			Expect(mean).To(BeNumerically("~", float64(n)*0.1/0.6, 1e-9))
+		}
+	})
+
+	It("fits the model to the losses", func() {
+		replay(36, 4, 50)
+		p, r, ok := controller.fitModel()
+		Expect(ok).To(BeTrue())
+		Expect(p).To(BeNumerically("~", 1.0/36, 1e-3))
+		Expect(r).To(BeNumerically("~", 1.0/4, 1e-3))
+	})
+
+	It("meets the target residual loss", func() {
+		replay(9, 1, 100)
+		p, r, _ := controller.fitModel()
+		Expect(controller.GetNumberOfRepairSymbols()).To(BeNumerically(">", 1))
+		Expect(expectedResidualLoss(p, r)).To(BeNumerically("<=", 0.01))
+	})
+
+	It("protects bursty losses more than random losses with the same loss rate", func() {
+		replay(9, 1, 100)
+		randomOverhead := float64(controller.GetNumberOfRepairSymbols()) / float64(controller.GetNumberOfDataSymbols())
+		controller = NewGilbertElliottRedundancyController(20, 10, 4, 0.01).(*gilbertElliottRedundancyController)
+		replay(36, 4, 100)
+		p, r, _ := controller.fitModel()
+		burstyOverhead := float64(controller.GetNumberOfRepairSymbols()) / float64(controller.GetNumberOfDataSymbols())
+		Expect(burstyOverhead).To(BeNumerically(">", randomOverhead))
+		Expect(controller.GetNumberOfInterleavedBlocks()).To(BeNumerically(">", 1))
+		Expect(expectedResidualLoss(p, r)).To(BeNumerically("<=", 0.01))
+	})
+
+	It("uses the smallest overhead when there is no loss", func() {
+		replay(geSampleSize, 0, 1)
+		Expect(controller.GetNumberOfDataSymbols()).To(Equal(uint(20)))
+		Expect(controller.GetNumberOfRepairSymbols()).To(Equal(uint(1)))
+	})
+
+	It("uses the burst estimator until enough bursts have been seen", func() {
+		controller.SetBurstEstimator(1, &mockBurstEstimator{burstLength: 3, deliveredBetweenLosses: 30})
+		replay(geSampleSize, 0, 1)
+		Expect(controller.GetNumberOfRepairSymbols()).To(BeNumerically(">", 1))
+		p, r, _ := controller.fitModel()
+		Expect(p).To(Equal(1.0 / 30))
+		Expect(r).To(Equal(1.0 / 3))
+	})
+
+	It("keeps the burst estimator of every path", func() {
+		controller.SetBurstEstimator(1, &mockBurstEstimator{burstLength: 2, deliveredBetweenLosses: 20})
+		controller.SetBurstEstimator(2, &mockBurstEstimator{burstLength: 4, deliveredBetweenLosses: 40})
+		// a path without estimation yet is ignored
+		controller.SetBurstEstimator(3, &mockBurstEstimator{})
+		p, r, ok := controller.fitModel()
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(1.0 / 30))
+		Expect(r).To(Equal(1.0 / 3))
+		controller.SetBurstEstimator(2, nil)
+		p, r, _ = controller.fitModel()
+		Expect(p).To(Equal(1.0 / 20))
+		Expect(r).To(Equal(1.0 / 2))
+	})
+
+	It("computes the residual losses of all the numbers of repair symbols at once", func() {
+		distribution := gilbertElliottLossDistributions(0.1, 0.5, 2, 12)[12]
+		residuals := residualLosses(distribution, 5)
+		Expect(residuals).To(HaveLen(6))
+		for repair, residual := range residuals {
+			Expect(residual).To(BeNumerically("~", residualLoss(distribution, repair), 1e-12))
+		}
+	})
+
+	It("stops deepening the interleaving once the losses of a block are independent", func() {
+		controller = NewGilbertElliottRedundancyController(20, 10, 255, 0.01).(*gilbertElliottRedundancyController)
+		// with p + r = 1, the state of the model does not depend on the previous one
+		_, _, interleaved := controller.chooseParameters(0.1, 0.9)
+		Expect(interleaved).To(Equal(uint(1)))
+		_, _, interleaved = controller.chooseParameters(1.0/36, 1.0/4)
+		Expect(interleaved).To(BeNumerically("<=", geMaxSearchedInterleavedBlocks))
+	})
+
+	Context("loss burst estimator", func() {
+		It("has no estimation before the second burst", func() {
+			e := NewLossBurstEstimator()
+			Expect(e.GetEstimatedBurstLength()).To(BeZero())
+			e.OnPacketLost(1)
+			e.OnPacketLost(2)
+			e.OnPacketReceived(3)
+			Expect(e.GetEstimatedBurstLength()).To(Equal(2.0))
+			Expect(e.GetSmoothedBytesBetweenTwoLosses()).To(BeZero())
+		})
+
+		It("smooths the bursts and the packets delivered between them", func() {
+			e := NewLossBurstEstimator()
+			pn := protocol.PacketNumber(1)
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 9; j++ {
+					e.OnPacketReceived(pn)
+					pn++
+				}
+				e.OnPacketLost(pn)
+				pn++
+			}
+			e.OnPacketReceived(pn)
+			Expect(e.GetEstimatedBurstLength()).To(Equal(1.0))
+			Expect(e.GetSmoothedBytesBetweenTwoLosses()).To(Equal(9.0))
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package fec
 
-import "github.com/lucas-clemente/quic-go/internal/protocol"
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
 
 // a class that implemented this interface is a redundancy controller
 type RedundancyController interface {
@@ -21,3 +24,10 @@ type RedundancyController interface {
 	// added by zhaolee
 	PushParamerters(TransParams)
 }
+
+// A DecodingFeedbackRedundancyController is told how the peer decoded the FEC blocks it sized, as reported in the
+// SymbolAckFrames, such that it can tune the redundancy from the real decoding outcomes rather than from the losses.
+type DecodingFeedbackRedundancyController interface {
+	RedundancyController
+	OnFECBlockDecoded(wire.FECBlockStatus)
+}
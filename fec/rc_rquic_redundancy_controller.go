@@ -37,6 +37,7 @@ type rquicRedundancyController struct {
 	NumberOfSourceSymbols     uint8
 	NumberOfRepairSymbols     uint8
 	NumberOfInterleavedBlocks uint8
+	WindowStepSize            uint8
 
 	timeflag time.Time
 	gamma    float64
@@ -47,12 +48,34 @@ type rquicRedundancyController struct {
 	packetRec  uint64
 }
 
+// NewrQuicRedundancyController returns a rQUIC redundancy controller starting with NumberOfSourceSymbols source
+// symbols (RCInit if zero) and sending NumberOfRepairSymbols repair symbols, with the default interleaving and
+// convolutional window step.
 func NewrQuicRedundancyController(NumberOfSourceSymbols, NumberOfRepairSymbols uint8) RedundancyController {
+	return NewrQuicRedundancyControllerWithInterleaving(NumberOfSourceSymbols, NumberOfRepairSymbols, 0, 0)
+}
+
+// NewrQuicRedundancyControllerWithInterleaving returns a rQUIC redundancy controller starting with NumberOfSourceSymbols
+// source symbols (RCInit if zero), and using the given number of interleaved FEC Groups and convolutional window step.
+// The interleaving and the window step take the protocol defaults if they are zero.
+func NewrQuicRedundancyControllerWithInterleaving(NumberOfSourceSymbols, NumberOfRepairSymbols, NumberOfInterleavedBlocks, WindowStepSize uint8) RedundancyController {
+	gamma := RCInit
+	if NumberOfSourceSymbols != 0 {
+		gamma = float64(NumberOfSourceSymbols)
+	}
+	if NumberOfInterleavedBlocks == 0 {
+		NumberOfInterleavedBlocks = protocol.DefaultNumberOfInterleavedFECGroups
+	}
+	if WindowStepSize == 0 {
+		WindowStepSize = protocol.DefaultConvolutionalStepSize
+	}
 	return &rquicRedundancyController{
-		NumberOfSourceSymbols: NumberOfRepairSymbols,
-		NumberOfRepairSymbols: NumberOfRepairSymbols,
-		timeflag:              time.Now(),
-		gamma:                 float64(RCInit),
+		NumberOfSourceSymbols:     NumberOfSourceSymbols,
+		NumberOfRepairSymbols:     NumberOfRepairSymbols,
+		NumberOfInterleavedBlocks: NumberOfInterleavedBlocks,
+		WindowStepSize:            WindowStepSize,
+		timeflag:                  time.Now(),
+		gamma:                     gamma,
 	}
 }
 
@@ -78,13 +101,11 @@ func (r *rquicRedundancyController) GetNumberOfRepairSymbols() uint {
 }
 
 func (r *rquicRedundancyController) GetNumberOfInterleavedBlocks() uint {
-	// return uint(r.NumberOfInterleavedBlocks)
-	return 1
+	return uint(r.NumberOfInterleavedBlocks)
 }
 
 func (r *rquicRedundancyController) GetWindowStepSize() uint {
-	// don't care this temporary
-	return 2
+	return uint(r.WindowStepSize)
 }
 
 func (r *rquicRedundancyController) PushParamerters(paras TransParams) {
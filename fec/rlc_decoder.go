@@ -0,0 +1,154 @@
+package fec
+
+import "github.com/lucas-clemente/quic-go/internal/protocol"
+
+// rlcDecoder is the decoder of the random linear codes FEC schemes over the field F.
+// It performs an on-the-fly Gauss-Jordan elimination: each equation is reduced as soon as it is added, and the
+// system is kept in reduced row echelon form. The pivot of each equation is its first unknown variable, with a coefficient
+// of 1, and the other equations of the system have a zero coefficient for it. A variable is recovered as soon as its
+// pivot equation has no other unknown, so the decoding cost is proportional to the new information brought by each
+// equation or known variable instead of the size of the whole system.
+type rlcDecoder[C uint8 | uint16, F rlcField[C]] struct {
+	pivots    map[protocol.FECEncodingSymbolID]*rlcRow[C, F] // the equations of the system, indexed by their pivot
+	recovered []*rlcRow[C, F]                                // the solved equations that were not returned by RecoverPackets yet
+	// the values of the recovered variables, used as constants by the next equations until they are received as known variables
+	recoveredValues map[protocol.FECEncodingSymbolID][]byte
+}
+
+func newRLCDecoder[C uint8 | uint16, F rlcField[C]]() rlcDecoder[C, F] {
+	return rlcDecoder[C, F]{
+		pivots:          make(map[protocol.FECEncodingSymbolID]*rlcRow[C, F]),
+		recoveredValues: make(map[protocol.FECEncodingSymbolID][]byte),
+	}
+}
+
+func (d *rlcDecoder[C, F]) CanRecoverPackets() bool {
+	return len(d.recovered) > 0
+}
+
+func (d *rlcDecoder[C, F]) RecoverPackets(ignoreBelow protocol.FECEncodingSymbolID) ([][]byte, error) {
+	var retVal [][]byte
+	for _, row := range d.recovered {
+		if row.first >= ignoreBelow {
+			retVal = append(retVal, row.constantTerm)
+		}
+	}
+	d.recovered = nil
+	for id := range d.recoveredValues {
+		if id < ignoreBelow {
+			delete(d.recoveredValues, id)
+		}
+	}
+	return retVal, nil
+}
+
+// addEquation adds the equation, whose coefficients were generated by the FEC scheme, to the system
+// pre: len(unknownVariables) > 0 && unknownVariables is sorted in ascending order
+func (d *rlcDecoder[C, F]) addEquation(eq *Equation, coefficients []C, unknownVariables []protocol.FECEncodingSymbolID, variables []*Variable) {
+	if len(d.pivots) == 0 || unknownVariables[0] > d.highestUnknownVariable() {
+		// flush the system: the equations that we will receive won't help for this system anymore.
+		// TODO: with jitter, this is not necessarily true that we won't receive help for this system
+		d.flush(unknownVariables[0])
+	}
+
+	begin, _ := eq.Bounds()
+	row := &rlcRow[C, F]{first: begin, coefs: coefficients}
+	row.constantTerm = make([]byte, row.field.symbolLength(len(eq.repairSymbol.Data)))
+	copy(row.constantTerm, eq.repairSymbol.Data)
+	for i, coef := range coefficients {
+		if coef == 0 {
+			continue
+		}
+		id := begin + protocol.FECEncodingSymbolID(i)
+		// we assume that it is faster to do this than using a hashmap to determine if the variable is known or not.
+		if candidate := variables[id%protocol.FECEncodingSymbolID(len(variables))]; candidate != nil && candidate.ID == id {
+			// the variable is not unknown: consider it as a constant
+			row.substitute(id, candidate.Packet)
+		} else if value, ok := d.recoveredValues[id]; ok {
+			// the variable has been recovered but the receiver did not handle it yet
+			row.substitute(id, value)
+		}
+	}
+	d.insert(row)
+}
+
+func (d *rlcDecoder[C, F]) AddKnownVariable(v *Variable) bool {
+	delete(d.recoveredValues, v.ID)
+	if row, ok := d.pivots[v.ID]; ok {
+		// as the system is reduced, no other equation depends on this variable: only its pivot equation must be updated
+		delete(d.pivots, v.ID)
+		row.substitute(v.ID, v.Packet)
+		d.insert(row)
+		return true
+	}
+	updated := false
+	for _, row := range d.pivots {
+		if row.coef(v.ID) != 0 {
+			row.substitute(v.ID, v.Packet)
+			row.trim()
+			d.solveIfPossible(row)
+			updated = true
+		}
+	}
+	return updated
+}
+
+// insert reduces the equation with the pivots of the system and adds it as a new pivot equation
+func (d *rlcDecoder[C, F]) insert(row *rlcRow[C, F]) {
+	row.trim()
+	// the pivot equations only have zero coefficients for the other pivots, so adding one of them to the equation
+	// never brings back the pivots that have already been eliminated
+	for id := row.first; len(row.coefs) > 0 && id <= row.last(); id++ {
+		if pivot, ok := d.pivots[id]; ok {
+			row.addScaled(row.coef(id), pivot)
+		}
+	}
+	row.trim()
+	if len(row.coefs) == 0 {
+		// the equation is a linear combination of the system, it does not bring any new information
+		return
+	}
+	row.normalize()
+	lead := row.first
+	for _, pivot := range d.pivots {
+		if coef := pivot.coef(lead); coef != 0 {
+			pivot.addScaled(coef, row)
+			pivot.trim()
+			d.solveIfPossible(pivot)
+		}
+	}
+	d.pivots[lead] = row
+	d.solveIfPossible(row)
+}
+
+// solveIfPossible marks the variable of a pivot equation as recovered if it is the only unknown of the equation
+// pre: row has been trimmed
+func (d *rlcDecoder[C, F]) solveIfPossible(row *rlcRow[C, F]) {
+	if len(row.coefs) != 1 {
+		return
+	}
+	// the coefficient of the pivot is 1, so the constant term is the value of the variable
+	delete(d.pivots, row.first)
+	d.recovered = append(d.recovered, row)
+	d.recoveredValues[row.first] = row.constantTerm
+}
+
+func (d *rlcDecoder[C, F]) highestUnknownVariable() protocol.FECEncodingSymbolID {
+	var highest protocol.FECEncodingSymbolID
+	for _, row := range d.pivots {
+		if row.last() > highest {
+			highest = row.last()
+		}
+	}
+	return highest
+}
+
+// flush removes all the equations of the system, and the recovered variables that are below firstUnknown
+func (d *rlcDecoder[C, F]) flush(firstUnknown protocol.FECEncodingSymbolID) {
+	d.pivots = make(map[protocol.FECEncodingSymbolID]*rlcRow[C, F])
+	for id := range d.recoveredValues {
+		if id < firstUnknown {
+			delete(d.recoveredValues, id)
+		}
+	}
+}
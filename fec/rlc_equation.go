@@ -0,0 +1,138 @@
+package fec
+
+import "github.com/lucas-clemente/quic-go/internal/protocol"
+
+// rlcField is the arithmetic of the RLC decoder over a Galois field whose elements are C. The constant terms of the
+// equations are symbols of bytes, the coefficients are vectors of elements.
+type rlcField[C uint8 | uint16] interface {
+	// symbolLength returns the length of a symbol able to contain n bytes
+	symbolLength(n int) int
+	symbolAddScaled(symbol1 []byte, coef C, symbol2 []byte)
+	symbolDiv(symbol []byte, coef C)
+	vectorAddScaled(v1 []C, coef C, v2 []C)
+	vectorDiv(v []C, coef C)
+}
+
+// gf256Field is the arithmetic of the RLC decoder over GF(2^8)
+type gf256Field struct{}
+
+func (gf256Field) symbolLength(n int) int { return n }
+
+func (gf256Field) symbolAddScaled(symbol1 []byte, coef uint8, symbol2 []byte) {
+	symbolAddScaled(symbol1, coef, symbol2)
+}
+
+func (gf256Field) symbolDiv(symbol []byte, coef uint8) { symbolDiv(symbol, coef) }
+
+func (gf256Field) vectorAddScaled(v1 []uint8, coef uint8, v2 []uint8) { symbolAddScaled(v1, coef, v2) }
+
+func (gf256Field) vectorDiv(v []uint8, coef uint8) { symbolDiv(v, coef) }
+
+// gf65536Field is the arithmetic of the RLC decoder over GF(2^16)
+type gf65536Field struct{}
+
+func (gf65536Field) symbolLength(n int) int { return gf65536SymbolLength(n) }
+
+func (gf65536Field) symbolAddScaled(symbol1 []byte, coef uint16, symbol2 []byte) {
+	gf65536SymbolAddScaled(symbol1, coef, symbol2)
+}
+
+func (gf65536Field) symbolDiv(symbol []byte, coef uint16) { gf65536SymbolDiv(symbol, coef) }
+
+func (gf65536Field) vectorAddScaled(v1 []uint16, coef uint16, v2 []uint16) {
+	gf65536VectorAddScaled(v1, coef, v2)
+}
+
+func (gf65536Field) vectorDiv(v []uint16, coef uint16) { gf65536VectorDiv(v, coef) }
+
+// rlcRow is an equation of the system of the RLC decoder over the field F:
+// coefs[0] * x(first) + coefs[1] * x(first+1) + ... + coefs[n-1] * x(first+n-1) = constantTerm
+// Its variables are consecutive, as the ones of a RLC encoding window.
+type rlcRow[C uint8 | uint16, F rlcField[C]] struct {
+	first        protocol.FECEncodingSymbolID
+	coefs        []C
+	constantTerm []byte
+	field        F
+}
+
+// last returns the ID of the last variable of the equation
+// pre: len(r.coefs) > 0
+func (r *rlcRow[C, F]) last() protocol.FECEncodingSymbolID {
+	return r.first + protocol.FECEncodingSymbolID(len(r.coefs)) - 1
+}
+
+func (r *rlcRow[C, F]) coef(id protocol.FECEncodingSymbolID) C {
+	if id < r.first || id-r.first >= protocol.FECEncodingSymbolID(len(r.coefs)) {
+		return 0
+	}
+	return r.coefs[id-r.first]
+}
+
+// substitute moves the known variable id to the constant term
+func (r *rlcRow[C, F]) substitute(id protocol.FECEncodingSymbolID, value []byte) {
+	if coef := r.coef(id); coef != 0 {
+		r.growConstantTerm(len(value))
+		r.field.symbolAddScaled(r.constantTerm, coef, value)
+		r.coefs[id-r.first] = 0
+	}
+}
+
+// trim removes the leading and trailing zero coefficients. The equation has no unknown left if its coefficients are empty.
+func (r *rlcRow[C, F]) trim() {
+	start := 0
+	for start < len(r.coefs) && r.coefs[start] == 0 {
+		start++
+	}
+	end := len(r.coefs)
+	for end > start && r.coefs[end-1] == 0 {
+		end--
+	}
+	r.first += protocol.FECEncodingSymbolID(start)
+	r.coefs = r.coefs[start:end]
+}
+
+// addScaled performs r += coef * other
+func (r *rlcRow[C, F]) addScaled(coef C, other *rlcRow[C, F]) {
+	if coef == 0 || len(other.coefs) == 0 {
+		return
+	}
+	if len(r.coefs) == 0 {
+		r.first = other.first
+		r.coefs = make([]C, len(other.coefs))
+	} else if other.first < r.first || other.last() > r.last() {
+		// make place for the variables of other
+		first, last := r.first, r.last()
+		if other.first < first {
+			first = other.first
+		}
+		if other.last() > last {
+			last = other.last()
+		}
+		coefs := make([]C, last-first+1)
+		copy(coefs[r.first-first:], r.coefs)
+		r.first, r.coefs = first, coefs
+	}
+	offset := other.first - r.first
+	r.field.vectorAddScaled(r.coefs[offset:offset+protocol.FECEncodingSymbolID(len(other.coefs))], coef, other.coefs)
+	r.growConstantTerm(len(other.constantTerm))
+	r.field.symbolAddScaled(r.constantTerm, coef, other.constantTerm)
+}
+
+// growConstantTerm pads the constant term with zeroes so that it can hold n bytes
+func (r *rlcRow[C, F]) growConstantTerm(n int) {
+	if n = r.field.symbolLength(n); len(r.constantTerm) < n {
+		constantTerm := make([]byte, n)
+		copy(constantTerm, r.constantTerm)
+		r.constantTerm = constantTerm
+	}
+}
+
+// normalize divides the equation by the coefficient of its first variable
+// pre: r has been trimmed and has at least one unknown
+func (r *rlcRow[C, F]) normalize() {
+	coef := r.coefs[0]
+	if coef != 1 {
+		r.field.vectorDiv(r.coefs, coef)
+		r.field.symbolDiv(r.constantTerm, coef)
+	}
+}
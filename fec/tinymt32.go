@@ -0,0 +1,81 @@
+package fec
+
+// TinyMT32 pseudo-random number generator, as specified in RFC 8682.
+// It is the PRNG used by the sliding window RLC FEC scheme of RFC 8681 to generate the coding coefficients.
+
+const (
+	tinyMT32Mat1 uint32 = 0x8f7011ee
+	tinyMT32Mat2 uint32 = 0xfc78ff1f
+	tinyMT32Tmat uint32 = 0x3793fdff
+
+	tinyMT32Sh0  = 1
+	tinyMT32Sh1  = 10
+	tinyMT32Sh8  = 8
+	tinyMT32Mask = 0x7fffffff
+
+	tinyMT32MinLoop = 8
+	tinyMT32PreLoop = 8
+)
+
+type tinyMT32 struct {
+	status [4]uint32
+}
+
+// newTinyMT32 returns a TinyMT32 PRNG initialized with seed
+func newTinyMT32(seed uint32) *tinyMT32 {
+	s := &tinyMT32{status: [4]uint32{seed, tinyMT32Mat1, tinyMT32Mat2, tinyMT32Tmat}}
+	for i := uint32(1); i < tinyMT32MinLoop; i++ {
+		prev := s.status[(i-1)&3]
+		s.status[i&3] ^= i + 1812433253*(prev^(prev>>30))
+	}
+	s.periodCertification()
+	for i := 0; i < tinyMT32PreLoop; i++ {
+		s.nextState()
+	}
+	return s
+}
+
+// periodCertification avoids the all-zero state, which has a period of 1
+func (s *tinyMT32) periodCertification() {
+	if s.status[0]&tinyMT32Mask == 0 && s.status[1] == 0 && s.status[2] == 0 && s.status[3] == 0 {
+		s.status = [4]uint32{'T', 'I', 'N', 'Y'}
+	}
+}
+
+func (s *tinyMT32) nextState() {
+	y := s.status[3]
+	x := (s.status[0] & tinyMT32Mask) ^ s.status[1] ^ s.status[2]
+	x ^= x << tinyMT32Sh0
+	y ^= (y >> tinyMT32Sh0) ^ x
+	s.status[0] = s.status[1]
+	s.status[1] = s.status[2]
+	s.status[2] = x ^ (y << tinyMT32Sh1)
+	s.status[3] = y
+	// -(y & 1) is all ones if the lowest bit of y is set
+	s.status[1] ^= -(y & 1) & tinyMT32Mat1
+	s.status[2] ^= -(y & 1) & tinyMT32Mat2
+}
+
+func (s *tinyMT32) temper() uint32 {
+	t0 := s.status[3]
+	t1 := s.status[0] + (s.status[2] >> tinyMT32Sh8)
+	t0 ^= t1
+	t0 ^= -(t1 & 1) & tinyMT32Tmat
+	return t0
+}
+
+// uint32 returns the next pseudo-random 32 bits integer
+func (s *tinyMT32) uint32() uint32 {
+	s.nextState()
+	return s.temper()
+}
+
+// rand256 returns a pseudo-random integer in [0, 255]
+func (s *tinyMT32) rand256() uint8 {
+	return uint8(s.uint32() & 0xFF)
+}
+
+// rand16 returns a pseudo-random integer in [0, 15]
+func (s *tinyMT32) rand16() uint8 {
+	return uint8(s.uint32() & 0x0F)
+}
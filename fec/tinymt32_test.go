@@ -0,0 +1,30 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TinyMT32", func() {
+	It("generates the sequence of the RFC 8682 test vector", func() {
+		// first 10 integers of the sequence generated with seed 1, RFC 8682 Section 2.2
+		expected := []uint32{
+			2545341989, 981918433, 3715302833, 2387538352, 3591001365,
+			3820442102, 2114400566, 2196103051, 2783359912, 764534509,
+		}
+		prng := newTinyMT32(1)
+		for _, v := range expected {
+			Expect(prng.uint32()).To(Equal(v))
+		}
+	})
+
+	It("generates integers in [0, 255] and [0, 15]", func() {
+		prng := newTinyMT32(1)
+		Expect(prng.rand256()).To(Equal(uint8(2545341989 & 0xFF)))
+		Expect(prng.rand16()).To(Equal(uint8(981918433 & 0x0F)))
+	})
+
+	It("generates different sequences for different seeds", func() {
+		Expect(newTinyMT32(1).uint32()).ToNot(Equal(newTinyMT32(2).uint32()))
+	})
+})
@@ -0,0 +1,225 @@
+package fec
+
+import (
+	"errors"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+var _ BlockFECScheme = &XOR2DFECScheme{}
+
+var XOR2DFECSchemeCannotRecoverPacket = errors.New("XOR2DFECScheme: cannot recover packet")
+var XOR2DFECSchemeCannotGetRepairSymbol = errors.New("XOR2DFECScheme: cannot get repair symbol")
+
+// XOR2DFECScheme is a two-dimensional parity block FEC scheme. The packets of a FEC Group are laid out row by row in a
+// grid, and each repair symbol is the XOR of a row or of a column of the grid. The column parities recover the bursts
+// that fit in a row, the row parities recover the scattered losses, and the receiver decodes iteratively: a packet
+// recovered with a row parity can leave a single missing packet in its column, and conversely.
+// The layout of the grid is chosen for each FEC Group according to the number of repair symbols asked by the
+// redundancy controller, and is carried in the FEC scheme specific field of the repair symbols.
+type XOR2DFECScheme struct{}
+
+// NewXOR2DFECScheme returns a new XOR2DFECScheme
+func NewXOR2DFECScheme() *XOR2DFECScheme {
+	return &XOR2DFECScheme{}
+}
+
+func (f *XOR2DFECScheme) GetRepairSymbols(fecGroup FECContainer, numberOfSymbols uint, _ protocol.FECBlockNumber) ([]*RepairSymbol, error) {
+	packets := fecGroup.GetPackets()
+	if len(packets) == 0 || numberOfSymbols == 0 {
+		return nil, XOR2DFECSchemeCannotGetRepairSymbol
+	}
+	maxLen := 0
+	for _, packet := range packets {
+		maxLen = utils.Max(maxLen, len(packet))
+	}
+	layout := chooseXOR2DLayout(len(packets), utils.Min(int(numberOfSymbols), 0xFF))
+	symbols := make([]*RepairSymbol, layout.numberOfRepairSymbols(len(packets)))
+	for i := range symbols {
+		data := make([]byte, maxLen)
+		for _, offset := range layout.members(i, len(packets)) {
+			// the addition over GF(2^8) is a XOR
+			symbolAddScaled(data, 1, packets[offset])
+		}
+		symbols[i] = &RepairSymbol{
+			FECSchemeSpecific: layout.fecSchemeSpecific(),
+			SymbolNumber:      byte(i),
+			Data:              data,
+			Convolutional:     f.Convolutional(),
+		}
+	}
+	return symbols, nil
+}
+
+func (f *XOR2DFECScheme) RecoverPackets(fecGroup *FECBlock) ([][]byte, error) {
+	if len(fecGroup.RepairSymbols) == 0 {
+		return nil, XOR2DFECSchemeCannotRecoverPacket
+	}
+	recovered, numberOfRecovered, _ := f.decode(fecGroup, true)
+	if numberOfRecovered == 0 {
+		return nil, XOR2DFECSchemeCannotRecoverPacket
+	}
+	retVal := make([][]byte, 0, numberOfRecovered)
+	for _, packet := range recovered {
+		if packet != nil {
+			retVal = append(retVal, packet)
+		}
+	}
+	return retVal, nil
+}
+
+// CanRecoverPackets returns true if all the missing packets of the FEC Group can be recovered. As the receiver removes the
+// FEC Group once it has been decoded, a partial recovery is only done when all the repair symbols have been received.
+func (f *XOR2DFECScheme) CanRecoverPackets(fecGroup *FECBlock) bool {
+	if len(fecGroup.RepairSymbols) == 0 ||
+		fecGroup.TotalNumberOfPackets == 0 ||
+		fecGroup.CurrentNumberOfPackets() >= fecGroup.TotalNumberOfPackets { // there is nothing to recover
+		return false
+	}
+	_, numberOfRecovered, numberOfMissing := f.decode(fecGroup, false)
+	return numberOfRecovered > 0 && (numberOfMissing == 0 || len(fecGroup.RepairSymbols) >= fecGroup.TotalNumberOfRepairSymbols)
+}
+
+func (*XOR2DFECScheme) Convolutional() bool {
+	return false
+}
+
+// decode runs the iterative decoding of the FEC Group: each repair symbol protecting a single missing packet recovers it,
+// until no repair symbol can help anymore. recovered holds the recovered packets at their offset in the FEC Group if
+// computeValues is true, numberOfMissing is the number of packets that are still missing after the decoding.
+// pre: len(fecGroup.RepairSymbols) > 0
+func (f *XOR2DFECScheme) decode(fecGroup *FECBlock, computeValues bool) (recovered [][]byte, numberOfRecovered int, numberOfMissing int) {
+	n := fecGroup.TotalNumberOfPackets
+	layout := parseXOR2DLayout(fecGroup.RepairSymbols[0].FECSchemeSpecific)
+	values := make([][]byte, n)
+	known := make([]bool, n)
+	for i := range known {
+		if i < len(fecGroup.packets) && fecGroup.packets[i] != nil {
+			values[i] = fecGroup.packets[i]
+			known[i] = true
+		} else {
+			numberOfMissing++
+		}
+	}
+	if computeValues {
+		recovered = make([][]byte, n)
+	}
+	for progress := true; progress && numberOfMissing > 0; {
+		progress = false
+		for _, symbol := range fecGroup.RepairSymbols {
+			members := layout.members(int(symbol.SymbolNumber), n)
+			missing := -1
+			for _, offset := range members {
+				if !known[offset] {
+					if missing >= 0 {
+						// more than one missing packet, this symbol cannot help for now
+						missing = -1
+						break
+					}
+					missing = offset
+				}
+			}
+			if missing < 0 {
+				continue
+			}
+			if computeValues {
+				value := make([]byte, len(symbol.Data))
+				copy(value, symbol.Data)
+				for _, offset := range members {
+					if offset != missing {
+						symbolAddScaled(value, 1, values[offset])
+					}
+				}
+				values[missing] = value
+				recovered[missing] = value
+			}
+			known[missing] = true
+			numberOfRecovered++
+			numberOfMissing--
+			progress = true
+		}
+	}
+	return recovered, numberOfRecovered, numberOfMissing
+}
+
+// xor2DLayout describes the grid in which the packets of a FEC Group are laid out, row by row. If rowParity is false,
+// there are only column parities, which is an interleaved one-dimensional parity.
+// The repair symbols are the parities of the rows, if any, followed by the parities of the columns.
+type xor2DLayout struct {
+	columns   int
+	rowParity bool
+}
+
+// chooseXOR2DLayout returns the squarest grid for n packets that needs at most r repair symbols. If r is too small for a
+// two-dimensional parity, the packets are only protected by r column parities.
+// pre: n > 0 && r > 0
+func chooseXOR2DLayout(n int, r int) xor2DLayout {
+	var best xor2DLayout
+	bestSide := 0
+	for columns := 1; columns <= n; columns++ {
+		rows := (n + columns - 1) / columns
+		if rows+columns > r {
+			continue
+		}
+		if side := utils.Max(rows, columns); best.columns == 0 || side < bestSide {
+			best = xor2DLayout{columns: columns, rowParity: true}
+			bestSide = side
+		}
+	}
+	if best.columns == 0 {
+		best = xor2DLayout{columns: utils.Min(r, n)}
+	}
+	return best
+}
+
+func parseXOR2DLayout(fecSchemeSpecific uint32) xor2DLayout {
+	return xor2DLayout{
+		columns:   int(fecSchemeSpecific & 0xFF),
+		rowParity: fecSchemeSpecific&0x100 != 0,
+	}
+}
+
+func (l xor2DLayout) fecSchemeSpecific() uint32 {
+	fss := uint32(l.columns)
+	if l.rowParity {
+		fss |= 0x100
+	}
+	return fss
+}
+
+func (l xor2DLayout) numberOfRows(n int) int {
+	if l.columns == 0 {
+		return 0
+	}
+	return (n + l.columns - 1) / l.columns
+}
+
+func (l xor2DLayout) numberOfRepairSymbols(n int) int {
+	if l.rowParity {
+		return l.numberOfRows(n) + l.columns
+	}
+	return l.columns
+}
+
+// members returns the offsets of the packets protected by the repair symbol number i of a FEC Group of n packets
+func (l xor2DLayout) members(i int, n int) []int {
+	var offsets []int
+	if l.rowParity {
+		rows := l.numberOfRows(n)
+		if i < rows {
+			for offset := i * l.columns; offset < (i+1)*l.columns && offset < n; offset++ {
+				offsets = append(offsets, offset)
+			}
+			return offsets
+		}
+		i -= rows
+	}
+	if i >= l.columns {
+		return nil
+	}
+	for offset := i; offset < n; offset += l.columns {
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
@@ -0,0 +1,155 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("2D XOR FEC Scheme", func() {
+	var (
+		packets   [][]byte
+		fecGroup  *FECBlock
+		fecScheme *XOR2DFECScheme
+	)
+
+	// receivedGroup returns the FEC Group as seen by a receiver that lost the packets at indexes lost
+	receivedGroup := func(lost []int, symbols []*RepairSymbol) *FECBlock {
+		isLost := make(map[int]bool)
+		for _, i := range lost {
+			isLost[i] = true
+		}
+		group := NewFECGroup(42, versionIETFQUIC)
+		for i, p := range packets {
+			if !isLost[i] {
+				group.AddPacket(p, &wire.Header{
+					PacketNumber: protocol.PacketNumber(i + 1),
+					FECPayloadID: protocol.NewBlockSourceFECPayloadID(42, uint8(i)),
+				})
+			}
+		}
+		for _, s := range symbols {
+			group.AddRepairSymbol(s)
+		}
+		group.TotalNumberOfPackets = len(packets)
+		group.TotalNumberOfRepairSymbols = 6
+		return group
+	}
+
+	BeforeEach(func() {
+		// a 3x3 grid
+		packets = nil
+		for i := 0; i < 9; i++ {
+			packets = append(packets, []byte{byte(i), 0x42, byte(i * 3), 0x13})
+		}
+		fecGroup = NewFECGroup(42, versionIETFQUIC)
+		for i, p := range packets {
+			fecGroup.AddPacket(p, &wire.Header{
+				PacketNumber: protocol.PacketNumber(i + 1),
+				FECPayloadID: protocol.NewBlockSourceFECPayloadID(42, uint8(i)),
+			})
+		}
+		fecScheme = NewXOR2DFECScheme()
+	})
+
+	Context("layout", func() {
+		It("chooses the squarest grid", func() {
+			Expect(chooseXOR2DLayout(9, 6)).To(Equal(xor2DLayout{columns: 3, rowParity: true}))
+			Expect(chooseXOR2DLayout(9, 10)).To(Equal(xor2DLayout{columns: 3, rowParity: true}))
+			Expect(chooseXOR2DLayout(10, 7)).To(Equal(xor2DLayout{columns: 3, rowParity: true}))
+		})
+
+		It("only uses column parities when there are not enough repair symbols", func() {
+			Expect(chooseXOR2DLayout(9, 5)).To(Equal(xor2DLayout{columns: 5}))
+			Expect(chooseXOR2DLayout(9, 1)).To(Equal(xor2DLayout{columns: 1}))
+			Expect(chooseXOR2DLayout(2, 1)).To(Equal(xor2DLayout{columns: 1}))
+		})
+
+		It("gives the packets protected by each repair symbol", func() {
+			layout := xor2DLayout{columns: 3, rowParity: true}
+			Expect(layout.numberOfRepairSymbols(8)).To(Equal(6))
+			Expect(layout.members(0, 8)).To(Equal([]int{0, 1, 2}))
+			Expect(layout.members(2, 8)).To(Equal([]int{6, 7}))
+			Expect(layout.members(3, 8)).To(Equal([]int{0, 3, 6}))
+			Expect(layout.members(5, 8)).To(Equal([]int{2, 5}))
+			Expect(layout.members(6, 8)).To(BeEmpty())
+		})
+
+		It("is carried in the FEC scheme specific field", func() {
+			for _, layout := range []xor2DLayout{{columns: 3, rowParity: true}, {columns: 255}} {
+				Expect(parseXOR2DLayout(layout.fecSchemeSpecific())).To(Equal(layout))
+			}
+		})
+	})
+
+	It("generates the row and column parities", func() {
+		symbols, err := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(symbols).To(HaveLen(6))
+		for i, s := range symbols {
+			Expect(s.SymbolNumber).To(Equal(byte(i)))
+			Expect(s.Convolutional).To(BeFalse())
+		}
+		Expect(symbols[0].Data).To(Equal([]byte{0 ^ 1 ^ 2, 0x42, 0 ^ 3 ^ 6, 0x13}))
+		Expect(symbols[3].Data).To(Equal([]byte{0 ^ 3 ^ 6, 0x42, 0 ^ 9 ^ 18, 0x13}))
+	})
+
+	It("refuses to generate repair symbols for an empty FEC Group", func() {
+		_, err := fecScheme.GetRepairSymbols(NewFECGroup(1, versionIETFQUIC), 6, 1)
+		Expect(err).To(MatchError(XOR2DFECSchemeCannotGetRepairSymbol))
+		_, err = fecScheme.GetRepairSymbols(fecGroup, 0, 42)
+		Expect(err).To(MatchError(XOR2DFECSchemeCannotGetRepairSymbol))
+	})
+
+	It("recovers a burst with the column parities", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		group := receivedGroup([]int{3, 4, 5}, symbols[3:])
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[3], packets[4], packets[5]}))
+	})
+
+	It("recovers scattered losses iteratively", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		// the first row and the first column both miss two packets: the packet 1 must be recovered by the second column,
+		// then the packet 0 by the first row, and finally the packet 3 by the first column
+		group := receivedGroup([]int{0, 1, 3}, symbols)
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[0], packets[1], packets[3]}))
+	})
+
+	It("waits for the other repair symbols before a partial recovery", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		// a square of losses cannot be recovered, but the packet 8 can
+		lost := []int{0, 1, 3, 4, 8}
+		Expect(fecScheme.CanRecoverPackets(receivedGroup(lost, symbols[:5]))).To(BeFalse())
+		group := receivedGroup(lost, symbols)
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[8]}))
+	})
+
+	It("states that it cannot recover packets when nothing can be recovered", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		Expect(fecScheme.CanRecoverPackets(receivedGroup(nil, symbols))).To(BeFalse())
+		Expect(fecScheme.CanRecoverPackets(receivedGroup([]int{0}, nil))).To(BeFalse())
+		group := receivedGroup([]int{0, 1, 3, 4}, symbols)
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeFalse())
+		_, err := fecScheme.RecoverPackets(group)
+		Expect(err).To(MatchError(XOR2DFECSchemeCannotRecoverPacket))
+	})
+
+	It("recovers the missing packets at the end of the FEC Group", func() {
+		symbols, _ := fecScheme.GetRepairSymbols(fecGroup, 6, 42)
+		group := receivedGroup([]int{7, 8}, symbols)
+		Expect(fecScheme.CanRecoverPackets(group)).To(BeTrue())
+		recovered, err := fecScheme.RecoverPackets(group)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([][]byte{packets[7], packets[8]}))
+	})
+})
@@ -95,6 +95,7 @@ func (f *FECFramer) popFECFrame(maxBytes protocol.ByteCount) (*wire.FECFrame, pr
 		NumberOfRepairSymbols: byte(symbol.NumberOfRepairSymbols),
 		Convolutional:         symbol.Convolutional,
 		EncodingSymbolID:      symbol.EncodingSymbolID,
+		RepairFECPayloadID:    symbol.RepairFECPayloadID,
 	}
 
 	f.currentOffsetInSymbol += protocol.ByteCount(lenDataToSend)
@@ -134,6 +135,14 @@ func (f *FECFramer) pushRepairSymbols(symbols []*fec.RepairSymbol) {
 	f.transmissionQueue = append(f.transmissionQueue, symbols...)
 }
 
+// nextSourcePathID returns the path carrying the source symbols of the next repair symbol to send
+func (f *FECFramer) nextSourcePathID() (protocol.PathID, bool) {
+	if len(f.transmissionQueue) == 0 {
+		return 0, false
+	}
+	return f.transmissionQueue[0].SourcePathID, true
+}
+
 // return len(f.transmissionQueue) > 0
 func (f *FECFramer) hasFECDataToSend() bool {
 	return len(f.transmissionQueue) > 0
@@ -53,8 +53,6 @@ import (
 //
 //		Figure 5: Receiver Operation with RTP Repair Flows
 
-// TODO: give a maximum number of buffers and remove older buffers if full (FIFO ?), to avoid memory explosion
-
 // Reciever的作用是：对于frame：收到frame之后将其缓存，并适时拼凑成symbol；对于Packet：收到之后恢复并向上递交
 type FECFrameworkReceiver struct {
 	fecGroupsBuffer *fecGroupsBuffer
@@ -70,8 +68,19 @@ type FECFrameworkReceiver struct {
 	session      *session
 	doRecovery   bool // Debug parameter: if false, the recovered packets won't be used by the session, like if it has not been recovered
 	fecScheme    fec.BlockFECScheme
-	AdaptiveCtrl *fec.AdaptiveController
 	blockTracker *fec.BlockTracker
+	// the number of packets recovered by this receiver
+	numberOfRecoveredPackets utils.AtomicUint64
+	// the number of repair symbols of the FEC blocks whose source symbols were all received
+	numberOfUselessRepairSymbols utils.AtomicUint64
+	// the decoding statuses of the FEC blocks, waiting to be sent in a SymbolAckFrame
+	blockStatuses []wire.FECBlockStatus
+	// bounds the memory taken by the buffered source and repair symbols of the FEC blocks
+	budget *fecReceiveBudget
+	// the number of FEC blocks given up before they were complete or recovered, and among them, the number of blocks
+	// known to miss packets
+	numberOfEvictedFECGroups       utils.AtomicUint64
+	numberOfUnrecoverableFECGroups utils.AtomicUint64
 }
 
 func NewFECFrameworkReceiver(s *session, fecScheme fec.BlockFECScheme) *FECFrameworkReceiver {
@@ -85,7 +94,16 @@ func NewFECFrameworkReceiver(s *session, fecScheme fec.BlockFECScheme) *FECFrame
 		doRecovery:       true,
 		fecScheme:        fecScheme,
 		blockTracker:     fec.NewBlockTracker(fec.DEFAULT_MAX_TRACE_BLOCKS),
+		budget:           newFECReceiveBudget(maxFECReceiveBufferSize(s), protocol.MaxFECReceiveBufferAge),
+	}
+}
+
+// maxFECReceiveBufferSize returns the number of bytes of symbols that the FEC receivers of the session may buffer
+func maxFECReceiveBufferSize(s *session) protocol.ByteCount {
+	if s.config == nil {
+		return protocol.DefaultMaxFECReceiveBufferSize
 	}
+	return protocol.ByteCount(s.config.MaxFECReceiveBufferSize)
 }
 
 // 将给定的packet添加到fecGroupsBuffer中,由packetUnpacker调用
@@ -98,16 +116,91 @@ func (f *FECFrameworkReceiver) handlePacket(data []byte, header *wire.Header) {
 	if !ok {
 		group := fec.NewFECGroup(fecBlockNumber, f.session.version)
 		group.AddPacket(data, header)
-		f.fecGroupsBuffer.addFECGroup(group)
+		f.addFECGroup(group)
 
 	}
 	f.fecGroupsBuffer.addPacketInFECGroup(data, header)
+	now := time.Now()
+	f.budget.add(fecBufferKey{id: uint64(fecBlockNumber)}, protocol.ByteCount(len(data)), now)
 	f.updateStateForSomeFECGroup(fecBlockNumber)
+	f.evictFECGroups(now)
+}
+
+// GetSymbolACKFrame returns a SymbolAckFrame if new repair symbols have been received or FEC blocks decoded. The
+// block statuses that don't fit in maxLength are left for the next frames, and nil is returned if the frame doesn't fit.
+func (f *FECFrameworkReceiver) GetSymbolACKFrame(maxLength protocol.ByteCount) *wire.SymbolAckFrame {
+	length, err := (&wire.SymbolAckFrame{SymbolReceived: f.blockTracker.CountReceivedSymbol()}).MinLength(f.session.version)
+	if err != nil || length > maxLength {
+		return nil
+	}
+	frame := f.blockTracker.GetSymbolACKFrame()
+	n := 0
+	if room := maxLength - length; room > 1 {
+		// the number of statuses, then 6 bytes per status
+		n = utils.Min(utils.Min(len(f.blockStatuses), protocol.MaxFECBlockStatuses), int(room-1)/6)
+	}
+	if n == 0 {
+		return frame
+	}
+	if frame == nil {
+		frame = &wire.SymbolAckFrame{SymbolReceived: f.blockTracker.CountReceivedSymbol()}
+	}
+	frame.BlockStatuses = append([]wire.FECBlockStatus(nil), f.blockStatuses[:n]...)
+	f.blockStatuses = f.blockStatuses[n:]
+	return frame
+}
 
+// addFECGroup adds the group in the buffer. If the buffer was full, the oldest group is given up.
+func (f *FECFrameworkReceiver) addFECGroup(group *fec.FECBlock) {
+	if evicted := f.fecGroupsBuffer.addFECGroup(group); evicted != nil {
+		f.giveUpFECGroup(evicted.FECBlockNumber, evicted)
+	}
 }
 
-func (f *FECFrameworkReceiver) GetSymbolACKFrame() *wire.SymbolAckFrame {
-	return f.blockTracker.GetSymbolACKFrame()
+// evictFECGroups gives up the oldest FEC blocks while the buffered symbols exceed the budget or are too old
+func (f *FECFrameworkReceiver) evictFECGroups(now time.Time) {
+	for _, key := range f.budget.popEvictions(now) {
+		fecBlockNumber := protocol.FECBlockNumber(key.id)
+		f.giveUpFECGroup(fecBlockNumber, f.fecGroupsBuffer.fecGroups[fecBlockNumber])
+	}
+}
+
+// giveUpFECGroup drops the symbols buffered for the FEC block fecBlockNumber, whose group may be nil if only FEC frames
+// were received, and reports its decoding to the peer
+func (f *FECFrameworkReceiver) giveUpFECGroup(fecBlockNumber protocol.FECBlockNumber, group *fec.FECBlock) {
+	delete(f.fecGroupsBuffer.fecGroups, fecBlockNumber)
+	delete(f.waitingFECFrames, fecBlockNumber)
+	f.blockTracker.Forget(fecBlockNumber)
+	f.budget.remove(fecBufferKey{id: uint64(fecBlockNumber)})
+	f.numberOfEvictedFECGroups.Increment(1)
+	// the number of packets of a block is only known with its repair symbols
+	if group == nil || group.TotalNumberOfPackets == 0 {
+		return
+	}
+	status := wire.FECBlockFailed
+	if group.CurrentNumberOfPackets() == group.TotalNumberOfPackets {
+		status = wire.FECBlockComplete
+		f.numberOfUselessRepairSymbols.Increment(uint64(len(group.RepairSymbols)))
+	} else {
+		f.numberOfUnrecoverableFECGroups.Increment(1)
+	}
+	f.addBlockStatus(fecBlockNumber, status, 0)
+}
+
+// removeFECGroup removes the FEC block fecBlockNumber, recovered or complete, from the buffers
+func (f *FECFrameworkReceiver) removeFECGroup(fecBlockNumber protocol.FECBlockNumber) {
+	delete(f.fecGroupsBuffer.fecGroups, fecBlockNumber)
+	delete(f.waitingFECFrames, fecBlockNumber)
+	f.budget.remove(fecBufferKey{id: uint64(fecBlockNumber)})
+}
+
+// addBlockStatus reports the decoding of a FEC block to the peer in the next SymbolAckFrame
+func (f *FECFrameworkReceiver) addBlockStatus(fecBlockNumber protocol.FECBlockNumber, status wire.FECBlockDecodingStatus, numberOfRecoveredPackets int) {
+	f.blockStatuses = append(f.blockStatuses, wire.FECBlockStatus{
+		FECBlockNumber:           fecBlockNumber,
+		Status:                   status,
+		NumberOfRecoveredPackets: uint8(utils.Min(numberOfRecoveredPackets, 0xFF)),
+	})
 }
 
 // Recovers a packet from this FEC group if possible. If a packet has been recovered or if this FEC group is useless (there is no missing packet in the buffer),
@@ -146,21 +239,31 @@ func (f *FECFrameworkReceiver) updateStateForSomeFECGroup(fecBlockNumber protoco
 		}
 		if len(recoveredPackets) > 0 {
 			// log.Printf("recovered %d packets !", len(recoveredPackets))
-			// modify
-			fec.NumberofRecoveredPacket += len(recoveredPackets)
+			f.numberOfRecoveredPackets.Increment(uint64(len(recoveredPackets)))
 		}
 		for _, packet := range recoveredPackets {
 			f.parseAndSendRecoveredPacket(packet)
 		}
-		delete(f.fecGroupsBuffer.fecGroups, fecBlockNumber)
-		// RC := f.AdaptiveCtrl.GetAdaptiveRC()
+		f.removeFECGroup(fecBlockNumber)
+		f.addBlockStatus(fecBlockNumber, wire.FECBlockRecovered, len(recoveredPackets))
+		return nil
 	}
 	if group.TotalNumberOfPackets > 0 && group.CurrentNumberOfPackets() == group.TotalNumberOfPackets && len(group.RepairSymbols) == group.TotalNumberOfRepairSymbols {
-		delete(f.fecGroupsBuffer.fecGroups, fecBlockNumber)
+		f.numberOfUselessRepairSymbols.Increment(uint64(len(group.RepairSymbols)))
+		f.removeFECGroup(fecBlockNumber)
+		f.addBlockStatus(fecBlockNumber, wire.FECBlockComplete, 0)
 	}
 	return nil
 }
 
+// addStats adds the FEC statistics of the receiver to stats
+func (f *FECFrameworkReceiver) addStats(stats *FECStats) {
+	stats.RecoveredPacketsBlock += f.numberOfRecoveredPackets.Get()
+	stats.UselessRepairSymbols += f.numberOfUselessRepairSymbols.Get()
+	stats.EvictedFECGroups += f.numberOfEvictedFECGroups.Get()
+	stats.UnrecoverableFECGroups += f.numberOfUnrecoverableFECGroups.Get()
+}
+
 // Transforms the recoveredPacket and sends it to the session, like a normal received packet
 //
 //	传输恢复的数据包并将其发送到session，就像正常接收的数据包一样，每次一个包
@@ -211,12 +314,15 @@ func (f *FECFrameworkReceiver) handleFECFrame(frame *wire.FECFrame) {
 	copy(newData, frame.Data)
 	frame.Data = newData
 	f.putWaitingFrame(frame)
+	now := time.Now()
+	f.budget.add(fecBufferKey{id: uint64(frame.FECBlockNumber)}, protocol.ByteCount(len(frame.Data)), now)
 	symbol, numberOfPacketsInFECGroup, numberOfRepairSymbols := f.getRepairSymbolForSomeFECGroup(frame)
 	if symbol != nil {
 		// 也就是确实获取到了一个完整的symbol，那就尝试恢复，恢复成功后向上层推送
 		f.handleRepairSymbol(symbol, numberOfPacketsInFECGroup, numberOfRepairSymbols)
 	}
 	f.blockTracker.ReceivedNewFECFrame(frame)
+	f.evictFECGroups(now)
 }
 
 // pre: the payload argument must be a full packet payload
@@ -227,7 +333,7 @@ func (f *FECFrameworkReceiver) handleRepairSymbol(symbol *fec.RepairSymbol, numb
 	if !ok {
 		group = fec.NewFECGroup(symbol.FECBlockNumber, f.session.version)
 		group.AddRepairSymbol(symbol)
-		f.fecGroupsBuffer.addFECGroup(group)
+		f.addFECGroup(group)
 	} else {
 		group.AddRepairSymbol(symbol)
 	}
@@ -399,11 +505,14 @@ func newFecGroupsBuffer(maxSize uint) *fecGroupsBuffer {
 	}
 }
 
-func (b *fecGroupsBuffer) addFECGroup(group *fec.FECBlock) {
+// addFECGroup adds the group in the buffer and returns the group removed to make room for it, if any
+func (b *fecGroupsBuffer) addFECGroup(group *fec.FECBlock) *fec.FECBlock {
+	var evicted *fec.FECBlock
 	number := group.FECBlockNumber
 	if b.size == b.maxSize {
 		toRemove := b.head
 		b.head = b.head.next
+		evicted = b.fecGroups[toRemove.fecBlockNumber]
 		delete(b.fecGroups, toRemove.fecBlockNumber)
 		b.size--
 	}
@@ -417,6 +526,7 @@ func (b *fecGroupsBuffer) addFECGroup(group *fec.FECBlock) {
 	}
 	b.fecGroups[number] = group
 	b.size++
+	return evicted
 }
 
 // TODO: should return true if it has been added, and false if not.
@@ -0,0 +1,95 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// fecBufferKey identifies the symbols buffered by a FEC receiver together: the source and repair symbols of a FEC
+// block, or a source or repair symbol of a convolutional FEC scheme
+type fecBufferKey struct {
+	repair bool
+	id     uint64
+}
+
+type fecBufferEntry struct {
+	bytes    protocol.ByteCount
+	received time.Time
+	// distinguishes the entries of a key removed and then buffered again
+	sequence uint64
+}
+
+type fecBufferQueueNode struct {
+	key      fecBufferKey
+	sequence uint64
+}
+
+// fecReceiveBudget accounts for the bytes of the symbols buffered by a FEC receiver. It returns the oldest entries
+// to evict when the buffered symbols exceed the budget of the session, or when they have been buffered for too long.
+type fecReceiveBudget struct {
+	maxBytes protocol.ByteCount
+	maxAge   time.Duration
+
+	bytes   protocol.ByteCount
+	entries map[fecBufferKey]*fecBufferEntry
+	// FIFO queue of the entries, from the oldest to the newest. The removed entries are skipped when popped.
+	queue        []fecBufferQueueNode
+	nextSequence uint64
+}
+
+func newFECReceiveBudget(maxBytes protocol.ByteCount, maxAge time.Duration) *fecReceiveBudget {
+	if maxBytes == 0 {
+		maxBytes = protocol.DefaultMaxFECReceiveBufferSize
+	}
+	return &fecReceiveBudget{
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		entries:  make(map[fecBufferKey]*fecBufferEntry),
+	}
+}
+
+// add accounts for n bytes buffered for the key key
+func (b *fecReceiveBudget) add(key fecBufferKey, n protocol.ByteCount, now time.Time) {
+	entry, ok := b.entries[key]
+	if !ok {
+		entry = &fecBufferEntry{received: now, sequence: b.nextSequence}
+		b.nextSequence++
+		b.entries[key] = entry
+		b.queue = append(b.queue, fecBufferQueueNode{key: key, sequence: entry.sequence})
+	}
+	entry.bytes += n
+	b.bytes += n
+}
+
+// remove is called when the symbols of the key key are not buffered anymore
+func (b *fecReceiveBudget) remove(key fecBufferKey) {
+	entry, ok := b.entries[key]
+	if !ok {
+		return
+	}
+	b.bytes -= entry.bytes
+	delete(b.entries, key)
+}
+
+// popEvictions removes the oldest entries until the buffered symbols fit in the budget and none is older than the
+// maximum age, and returns their keys
+func (b *fecReceiveBudget) popEvictions(now time.Time) []fecBufferKey {
+	var evicted []fecBufferKey
+	for len(b.queue) > 0 {
+		node := b.queue[0]
+		entry, ok := b.entries[node.key]
+		if !ok || entry.sequence != node.sequence {
+			// already removed
+			b.queue = b.queue[1:]
+			continue
+		}
+		if b.bytes <= b.maxBytes && (b.maxAge == 0 || now.Sub(entry.received) <= b.maxAge) {
+			break
+		}
+		b.queue = b.queue[1:]
+		b.remove(node.key)
+		evicted = append(evicted, node.key)
+	}
+	return evicted
+}
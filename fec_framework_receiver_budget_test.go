@@ -0,0 +1,64 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC receive budget", func() {
+	var (
+		budget *fecReceiveBudget
+		now    time.Time
+	)
+
+	key := func(id uint64) fecBufferKey { return fecBufferKey{id: id} }
+
+	BeforeEach(func() {
+		budget = newFECReceiveBudget(1000, time.Second)
+		now = time.Now()
+	})
+
+	It("uses the default budget", func() {
+		Expect(newFECReceiveBudget(0, 0).maxBytes).To(Equal(protocol.DefaultMaxFECReceiveBufferSize))
+	})
+
+	It("evicts nothing while the symbols fit", func() {
+		budget.add(key(1), 400, now)
+		budget.add(key(2), 600, now)
+		Expect(budget.popEvictions(now)).To(BeEmpty())
+	})
+
+	It("evicts the oldest entries when the budget is exceeded", func() {
+		budget.add(key(1), 400, now)
+		budget.add(key(2), 400, now)
+		budget.add(key(1), 100, now)
+		budget.add(key(3), 400, now)
+		Expect(budget.popEvictions(now)).To(Equal([]fecBufferKey{key(1)}))
+		Expect(budget.bytes).To(Equal(protocol.ByteCount(800)))
+	})
+
+	It("evicts the entries buffered for too long", func() {
+		budget.add(key(1), 10, now)
+		budget.add(key(2), 10, now.Add(time.Second))
+		Expect(budget.popEvictions(now.Add(1500 * time.Millisecond))).To(Equal([]fecBufferKey{key(1)}))
+	})
+
+	It("skips the removed entries", func() {
+		budget.add(key(1), 600, now)
+		budget.add(key(2), 300, now)
+		budget.remove(key(1))
+		budget.add(key(1), 800, now)
+		// the first entry of the key 1 has been removed, the key 2 is now the oldest
+		Expect(budget.popEvictions(now)).To(Equal([]fecBufferKey{key(2)}))
+		Expect(budget.bytes).To(Equal(protocol.ByteCount(800)))
+	})
+
+	It("distinguishes source and repair symbols with the same ID", func() {
+		budget.add(key(1), 600, now)
+		budget.add(fecBufferKey{repair: true, id: 1}, 600, now)
+		Expect(budget.popEvictions(now)).To(Equal([]fecBufferKey{key(1)}))
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/ackhandler"
 	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -52,32 +53,56 @@ import (
 //
 //		Figure 5: Receiver Operation with RTP Repair Flows
 
-// TODO: give a maximum number of buffers and remove older buffers if full (FIFO ?), to avoid memory explosion
-
 type FECFrameworkReceiverConvolutional struct {
-	variablesBuffer      []*fec.Variable
-	equations            map[protocol.FECPayloadID]*fec.Equation
-	waitingFECFrames     map[protocol.FECPayloadID][]map[protocol.FecFrameOffset]*wire.FECFrame // fec frames that only contain parts of a FEC payload and that wait for the next parts access: [fecPayloadID][repairSymbol][Offset]
-	recoveredPackets     chan *receivedPacket
-	session              *session // the session that uses this handler (TODO: find another way than passing the session directly to get the version number, perspective and remoteAddress informations
-	doRecovery           bool     // Debug parameter: if false, the recovered packets won't be used by the session, like if it has not been recovered
-	fecScheme            fec.ConvolutionalFECScheme
-	numberOfVarsInBuffer int
-	highestVarID         protocol.FECEncodingSymbolID
-	highestRemoved       protocol.FECEncodingSymbolID
+	variablesBuffer []*fec.Variable
+	equations       map[protocol.FECPayloadID]*fec.Equation
+	// the number of source symbols still missed by the equation of every repair symbol, and the repair symbols waiting
+	// for every missing source symbol
+	numberOfUnknownVariables map[protocol.FECPayloadID]int
+	waitingEquations         map[protocol.FECEncodingSymbolID][]protocol.FECPayloadID
+	waitingFECFrames         map[protocol.FECPayloadID][]map[protocol.FecFrameOffset]*wire.FECFrame // fec frames that only contain parts of a FEC payload and that wait for the next parts access: [fecPayloadID][repairSymbol][Offset]
+	recoveredPackets         chan *receivedPacket
+	session                  *session // the session that uses this handler (TODO: find another way than passing the session directly to get the version number, perspective and remoteAddress informations
+	doRecovery               bool     // Debug parameter: if false, the recovered packets won't be used by the session, like if it has not been recovered
+	fecScheme                fec.ConvolutionalFECScheme
+	numberOfVarsInBuffer     int
+	highestVarID             protocol.FECEncodingSymbolID
+	highestRemoved           protocol.FECEncodingSymbolID
+	// the number of packets recovered by this receiver
+	numberOfRecoveredPackets utils.AtomicUint64
+	// the number of repair symbols received while all the source symbols of their encoding window were known
+	numberOfUselessRepairSymbols utils.AtomicUint64
+	// the encoding symbol IDs of the source symbols received or recovered, acknowledged in the SymbolAckFrames
+	receivedSymbols ackhandler.ReceivedSymbolHistory
+	// the number of source symbols received or recovered, and whether new repair symbols were received, since the
+	// last SymbolAckFrame
+	numberOfUnackedSymbols int
+	hasNewRepairSymbols    bool
+	numberOfRepairSymbols  protocol.NumberOfAckedSymbol
+	// bounds the memory taken by the buffered source symbols, and the repair symbols and their FEC frames
+	budget *fecReceiveBudget
+	// the number of repair symbols given up, and among them, the number of repair symbols whose encoding window
+	// still missed source symbols
+	numberOfEvictedFECGroups       utils.AtomicUint64
+	numberOfUnrecoverableFECGroups utils.AtomicUint64
 }
 
 func NewFECFrameworkReceiverConvolutional(s *session, fecScheme fec.ConvolutionalFECScheme) *FECFrameworkReceiverConvolutional {
-	buffer := make([]*fec.Variable, 500)
+	// the buffer must be able to hold the variables of the largest encoding windows
+	buffer := make([]*fec.Variable, utils.Max(500, 2*int(protocol.MaxFECWindowSize)))
 	return &FECFrameworkReceiverConvolutional{
 		variablesBuffer: buffer,
 		// TODO: find a good value for the packets buffer size rather than 1000
-		waitingFECFrames: make(map[protocol.FECPayloadID][]map[protocol.FecFrameOffset]*wire.FECFrame),
-		equations:        make(map[protocol.FECPayloadID]*fec.Equation),
-		session:          s,
-		recoveredPackets: s.recoveredPackets,
-		doRecovery:       true,
-		fecScheme:        fecScheme,
+		waitingFECFrames:         make(map[protocol.FECPayloadID][]map[protocol.FecFrameOffset]*wire.FECFrame),
+		equations:                make(map[protocol.FECPayloadID]*fec.Equation),
+		numberOfUnknownVariables: make(map[protocol.FECPayloadID]int),
+		waitingEquations:         make(map[protocol.FECEncodingSymbolID][]protocol.FECPayloadID),
+		session:                  s,
+		recoveredPackets:         s.recoveredPackets,
+		doRecovery:               true,
+		fecScheme:                fecScheme,
+		receivedSymbols:          ackhandler.NewReceivedSymbolHistory(),
+		budget:                   newFECReceiveBudget(maxFECReceiveBufferSize(s), protocol.MaxFECReceiveBufferAge),
 	}
 }
 
@@ -94,9 +119,11 @@ func (f *FECFrameworkReceiverConvolutional) addToVariablesBuffer(data []byte, he
 			f.highestRemoved = id
 		}
 		f.numberOfVarsInBuffer--
+		f.budget.remove(fecBufferKey{id: uint64(id)})
 	}
 	dataCpy := make([]byte, len(data))
 	copy(dataCpy, data)
+	f.budget.add(fecBufferKey{id: uint64(esid)}, protocol.ByteCount(len(dataCpy)), time.Now())
 	variable := &fec.Variable{
 		Packet: dataCpy,
 		ID:     esid,
@@ -122,12 +149,153 @@ func (f *FECFrameworkReceiverConvolutional) handlePacket(data []byte, header *wi
 	if !header.FECFlag {
 		return
 	}
+	// the recovered packets are handled here too
+	f.receivedSymbol(header.GetFECPayloadID().GetConvolutionalEncodingSymbolID())
 
 	if f.addToVariablesBuffer(data, header) {
+		f.knownVariable(header.GetFECPayloadID().GetConvolutionalEncodingSymbolID())
 		if f.fecScheme.AddKnownVariable(f.getFromVariablesBuffer(header.GetFECPayloadID().GetConvolutionalEncodingSymbolID())) {
 			f.updateStateForSomeEncodingSymbolID(header.GetFECPayloadID().GetConvolutionalEncodingSymbolID())
 		}
 	}
+	f.evictSymbols(time.Now())
+}
+
+// evictSymbols gives up the oldest source and repair symbols while the buffered symbols exceed the budget or are
+// too old. The source symbols given up are ignored by the next recoveries, as when the variables buffer is full.
+func (f *FECFrameworkReceiverConvolutional) evictSymbols(now time.Time) {
+	for _, key := range f.budget.popEvictions(now) {
+		if !key.repair {
+			f.evictVariable(protocol.FECEncodingSymbolID(key.id))
+			continue
+		}
+		fecPayloadID := protocol.FECPayloadID(key.id)
+		f.numberOfEvictedFECGroups.Increment(1)
+		if f.numberOfUnknownVariables[fecPayloadID] > 0 {
+			f.numberOfUnrecoverableFECGroups.Increment(1)
+		}
+		f.releaseEquation(fecPayloadID)
+	}
+}
+
+// waitForVariables records that the equation of the repair symbol fecPayloadID misses the source symbols ids
+func (f *FECFrameworkReceiverConvolutional) waitForVariables(fecPayloadID protocol.FECPayloadID, ids []protocol.FECEncodingSymbolID) {
+	f.numberOfUnknownVariables[fecPayloadID] = len(ids)
+	for _, id := range ids {
+		f.waitingEquations[id] = append(f.waitingEquations[id], fecPayloadID)
+	}
+}
+
+// knownVariable is called when the source symbol id is received or recovered. The equations that don't miss any
+// source symbol anymore are released.
+func (f *FECFrameworkReceiverConvolutional) knownVariable(id protocol.FECEncodingSymbolID) {
+	waiting := f.waitingEquations[id]
+	delete(f.waitingEquations, id)
+	for _, fecPayloadID := range waiting {
+		n, ok := f.numberOfUnknownVariables[fecPayloadID]
+		if !ok {
+			continue
+		}
+		if n <= 1 {
+			f.releaseEquation(fecPayloadID)
+		} else {
+			f.numberOfUnknownVariables[fecPayloadID] = n - 1
+		}
+	}
+}
+
+// releaseEquation removes the repair symbol fecPayloadID and its equation from the buffers and from the budget, once
+// it is not needed anymore or given up
+func (f *FECFrameworkReceiverConvolutional) releaseEquation(fecPayloadID protocol.FECPayloadID) {
+	if equation, ok := f.equations[fecPayloadID]; ok && f.numberOfUnknownVariables[fecPayloadID] > 0 {
+		begin, end := equation.Bounds()
+		for i := begin; i <= end; i++ {
+			f.stopWaiting(i, fecPayloadID)
+		}
+	}
+	delete(f.equations, fecPayloadID)
+	delete(f.numberOfUnknownVariables, fecPayloadID)
+	delete(f.waitingFECFrames, fecPayloadID)
+	f.budget.remove(fecBufferKey{repair: true, id: uint64(fecPayloadID)})
+}
+
+// stopWaiting removes the repair symbol fecPayloadID from the repair symbols waiting for the source symbol id
+func (f *FECFrameworkReceiverConvolutional) stopWaiting(id protocol.FECEncodingSymbolID, fecPayloadID protocol.FECPayloadID) {
+	waiting, ok := f.waitingEquations[id]
+	if !ok {
+		return
+	}
+	var remaining []protocol.FECPayloadID
+	for _, other := range waiting {
+		if other != fecPayloadID {
+			remaining = append(remaining, other)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(f.waitingEquations, id)
+	} else {
+		f.waitingEquations[id] = remaining
+	}
+}
+
+// evictVariable removes the source symbol id from the variables buffer
+func (f *FECFrameworkReceiverConvolutional) evictVariable(id protocol.FECEncodingSymbolID) {
+	index := id % protocol.FECEncodingSymbolID(len(f.variablesBuffer))
+	if variable := f.variablesBuffer[index]; variable == nil || variable.ID != id {
+		return
+	}
+	f.variablesBuffer[index] = nil
+	if id > f.highestRemoved {
+		f.highestRemoved = id
+	}
+	f.numberOfVarsInBuffer--
+}
+
+// receivedSymbol records that the source symbol id has been received or recovered. The symbols older than the largest
+// encoding window are forgotten, as no repair symbol protects them anymore.
+func (f *FECFrameworkReceiverConvolutional) receivedSymbol(id protocol.FECEncodingSymbolID) {
+	if err := f.receivedSymbols.ReceiveSymbol(protocol.SymbolNumber(id)); err != nil {
+		utils.Debugf("FEC receiver: %s", err.Error())
+		return
+	}
+	if id > protocol.FECEncodingSymbolID(protocol.MaxFECWindowSize) {
+		f.receivedSymbols.DeleteUpTo(protocol.SymbolNumber(id) - protocol.SymbolNumber(protocol.MaxFECWindowSize))
+	}
+	f.numberOfUnackedSymbols++
+}
+
+// GetSymbolACKFrame returns a SymbolAckFrame with the latest ranges of received and recovered source symbols, if new
+// repair symbols or SourceSymbolsBeforeSymbolAck new source symbols have been received since the last frame. The
+// oldest ranges are left out to fit in maxLength, and nil is returned if no range fits.
+func (f *FECFrameworkReceiverConvolutional) GetSymbolACKFrame(maxLength protocol.ByteCount) *wire.SymbolAckFrame {
+	if !f.hasNewRepairSymbols && f.numberOfUnackedSymbols < protocol.SourceSymbolsBeforeSymbolAck {
+		return nil
+	}
+	ranges := f.receivedSymbols.GetSymbolRanges()
+	if len(ranges) > protocol.MaxSymbolAckRanges {
+		ranges = ranges[:protocol.MaxSymbolAckRanges]
+	}
+	frame := &wire.SymbolAckFrame{
+		SymbolReceived: f.numberOfRepairSymbols,
+		SymbolRanges:   ranges,
+	}
+	for {
+		length, err := frame.MinLength(f.session.version)
+		if err != nil {
+			return nil
+		}
+		if length <= maxLength {
+			break
+		}
+		if len(frame.SymbolRanges) <= 1 {
+			// the frame is sent in a later packet
+			return nil
+		}
+		frame.SymbolRanges = frame.SymbolRanges[:len(frame.SymbolRanges)-1]
+	}
+	f.numberOfUnackedSymbols = 0
+	f.hasNewRepairSymbols = false
+	return frame
 }
 
 // Recovers a packet from this FEC group if possible. If a packet has been recovered or if this FEC group is useless (there is no missing packet in the buffer),
@@ -145,6 +313,7 @@ func (f *FECFrameworkReceiverConvolutional) updateStateForSomeEncodingSymbolID(_
 
 		if len(recoveredPackets) > 0 {
 			utils.Infof("recovered %d packets !", len(recoveredPackets))
+			f.numberOfRecoveredPackets.Increment(uint64(len(recoveredPackets)))
 		}
 
 		for _, packet := range recoveredPackets {
@@ -197,10 +366,13 @@ func (f *FECFrameworkReceiverConvolutional) handleFECFrame(frame *wire.FECFrame)
 	frame.Data = newData
 	f.putWaitingFrame(frame)
 	fecPayloadID := frame.GetFECPayloadID()
+	now := time.Now()
+	f.budget.add(fecBufferKey{repair: true, id: uint64(fecPayloadID)}, protocol.ByteCount(len(frame.Data)), now)
 	symbol := f.getRepairSymbolForSomeFECGroup(frame)
 	if symbol != nil {
 		f.handleRepairSymbol(symbol, fecPayloadID)
 	}
+	f.evictSymbols(now)
 }
 
 // pre: the payload argument must be a full packet payload
@@ -210,8 +382,10 @@ func (f *FECFrameworkReceiverConvolutional) handleRepairSymbol(symbol *fec.Repai
 	esid := fecPayloadID.GetConvolutionalEncodingSymbolID()
 	equation, ok := f.equations[fecPayloadID]
 	if !ok {
-		equation = fec.NewEquation(symbol)
+		equation = fec.NewEquationForScheme(f.fecScheme, symbol)
 		f.equations[fecPayloadID] = equation
+		f.numberOfRepairSymbols++
+		f.hasNewRepairSymbols = true
 		if f.highestVarID < equation.ID() {
 			f.highestVarID = equation.ID()
 		}
@@ -224,12 +398,24 @@ func (f *FECFrameworkReceiverConvolutional) handleRepairSymbol(symbol *fec.Repai
 			}
 		}
 		if len(unknownVariables) > 0 {
+			f.waitForVariables(fecPayloadID, unknownVariables)
 			f.fecScheme.AddEquation(equation, unknownVariables, f.variablesBuffer)
 			f.updateStateForSomeEncodingSymbolID(esid)
+		} else {
+			f.numberOfUselessRepairSymbols.Increment(1)
+			f.releaseEquation(fecPayloadID)
 		}
 	}
 }
 
+// addStats adds the FEC statistics of the receiver to stats
+func (f *FECFrameworkReceiverConvolutional) addStats(stats *FECStats) {
+	stats.RecoveredPacketsConvolutional += f.numberOfRecoveredPackets.Get()
+	stats.UselessRepairSymbols += f.numberOfUselessRepairSymbols.Get()
+	stats.EvictedFECGroups += f.numberOfEvictedFECGroups.Get()
+	stats.UnrecoverableFECGroups += f.numberOfUnrecoverableFECGroups.Get()
+}
+
 // TODO: waitingFrames should be an array of frames sorted by offset, absent frames should be nil in the array
 
 // Looks in the waitingFECFrames and returns the full payload for the specified FEC group and removes the frames from the waitingFrames if all the frames are present.
@@ -267,7 +453,7 @@ func (f *FECFrameworkReceiverConvolutional) getRepairSymbolForSomeFECGroup(recei
 				EncodingSymbolID:      receivedFrame.EncodingSymbolID,
 				SymbolNumber:          receivedFrame.RepairSymbolNumber,
 				Data:                  receivedFrame.Data,
-				NumberOfPackets:       receivedFrame.NumberOfPackets,
+				NumberOfPackets:       uint16(receivedFrame.NumberOfPackets),
 				NumberOfRepairSymbols: receivedFrame.NumberOfRepairSymbols,
 			}
 			return symbol
@@ -309,7 +495,7 @@ func (f *FECFrameworkReceiverConvolutional) getRepairSymbolForSomeFECGroup(recei
 			EncodingSymbolID:      receivedFrame.EncodingSymbolID,
 			Data:                  payloadData,
 			SymbolNumber:          receivedFrame.RepairSymbolNumber,
-			NumberOfPackets:       nPackets,
+			NumberOfPackets:       uint16(nPackets),
 			NumberOfRepairSymbols: nRepairSymbols,
 		}
 	}
@@ -0,0 +1,130 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Convolutional FEC receiver", func() {
+	var receiver *FECFrameworkReceiverConvolutional
+
+	BeforeEach(func() {
+		sess := &session{version: protocol.VersionMP, recoveredPackets: make(chan *receivedPacket, 10), config: &Config{}}
+		receiver = NewFECFrameworkReceiverConvolutional(sess, fec.NewRandomLinearFECScheme())
+	})
+
+	Context("SymbolAckFrames", func() {
+		It("waits for enough new source symbols", func() {
+			for i := 1; i < protocol.SourceSymbolsBeforeSymbolAck; i++ {
+				receiver.receivedSymbol(protocol.FECEncodingSymbolID(i))
+				Expect(receiver.GetSymbolACKFrame(protocol.MaxPacketSize)).To(BeNil())
+			}
+			receiver.receivedSymbol(protocol.FECEncodingSymbolID(protocol.SourceSymbolsBeforeSymbolAck))
+			frame := receiver.GetSymbolACKFrame(protocol.MaxPacketSize)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.SymbolRanges).To(HaveLen(1))
+			Expect(frame.SymbolRanges[0].Last).To(Equal(protocol.PacketNumber(protocol.SourceSymbolsBeforeSymbolAck)))
+			Expect(receiver.GetSymbolACKFrame(protocol.MaxPacketSize)).To(BeNil())
+		})
+
+		It("acknowledges new repair symbols at once", func() {
+			receiver.receivedSymbol(1)
+			receiver.numberOfRepairSymbols++
+			receiver.hasNewRepairSymbols = true
+			frame := receiver.GetSymbolACKFrame(protocol.MaxPacketSize)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.SymbolReceived).To(Equal(protocol.NumberOfAckedSymbol(1)))
+			Expect(receiver.GetSymbolACKFrame(protocol.MaxPacketSize)).To(BeNil())
+		})
+
+		It("leaves the oldest ranges out to fit in the packet", func() {
+			// every other symbol is missing
+			for i := 0; i < 2*protocol.SourceSymbolsBeforeSymbolAck; i += 2 {
+				receiver.receivedSymbol(protocol.FECEncodingSymbolID(i + 1))
+			}
+			full := receiver.GetSymbolACKFrame(protocol.MaxPacketSize)
+			Expect(full.SymbolRanges).To(HaveLen(protocol.SourceSymbolsBeforeSymbolAck))
+			receiver.hasNewRepairSymbols = true
+			fullLength, err := full.MinLength(protocol.VersionMP)
+			Expect(err).ToNot(HaveOccurred())
+			frame := receiver.GetSymbolACKFrame(fullLength - 8)
+			Expect(frame).ToNot(BeNil())
+			Expect(frame.SymbolRanges).To(Equal(full.SymbolRanges[:len(full.SymbolRanges)-1]))
+		})
+
+		It("waits for a later packet if no range fits", func() {
+			for i := 1; i <= protocol.SourceSymbolsBeforeSymbolAck; i++ {
+				receiver.receivedSymbol(protocol.FECEncodingSymbolID(i))
+			}
+			Expect(receiver.GetSymbolACKFrame(4)).To(BeNil())
+			Expect(receiver.GetSymbolACKFrame(protocol.MaxPacketSize)).ToNot(BeNil())
+		})
+	})
+
+	Context("buffer budget", func() {
+		receive := func(id protocol.FECEncodingSymbolID) {
+			receiver.handlePacket(make([]byte, 100), &wire.Header{FECFlag: true, FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(id)})
+		}
+
+		// receiveRepair receives a repair symbol protecting the n source symbols up to last
+		receiveRepair := func(last protocol.FECEncodingSymbolID, n byte) fecBufferKey {
+			frame := &wire.FECFrame{
+				Convolutional:     true,
+				FECSchemeSpecific: 1,
+				EncodingSymbolID:  last,
+				FinBit:            true,
+				NumberOfPackets:   n,
+				Data:              make([]byte, 100),
+			}
+			receiver.handleFECFrame(frame)
+			return fecBufferKey{repair: true, id: uint64(frame.GetFECPayloadID())}
+		}
+
+		BeforeEach(func() {
+			receiver.doRecovery = false
+		})
+
+		It("releases a repair symbol once all its source symbols are known", func() {
+			receive(1)
+			receive(2)
+			key := receiveRepair(3, 3)
+			Expect(receiver.budget.entries).To(HaveKey(key))
+			Expect(receiver.equations).To(HaveLen(1))
+			receive(3)
+			Expect(receiver.budget.entries).ToNot(HaveKey(key))
+			Expect(receiver.equations).To(BeEmpty())
+			Expect(receiver.waitingEquations).To(BeEmpty())
+		})
+
+		It("releases the useless repair symbols at once", func() {
+			receive(1)
+			receive(2)
+			key := receiveRepair(2, 2)
+			Expect(receiver.numberOfUselessRepairSymbols.Get()).To(Equal(uint64(1)))
+			Expect(receiver.budget.entries).ToNot(HaveKey(key))
+			Expect(receiver.equations).To(BeEmpty())
+		})
+
+		It("only counts the evicted repair symbols that miss source symbols as unrecoverable", func() {
+			receive(1)
+			receive(2)
+			receiveRepair(3, 3)
+			receive(3)
+			receiveRepair(10, 2)
+			// the source symbol 1 leaves the variables buffer
+			receive(protocol.FECEncodingSymbolID(1 + len(receiver.variablesBuffer)))
+			Expect(receiver.getFromVariablesBuffer(1)).To(BeNil())
+			receiver.budget.maxBytes = 0
+			receiver.evictSymbols(time.Now())
+			Expect(receiver.numberOfEvictedFECGroups.Get()).To(Equal(uint64(1)))
+			Expect(receiver.numberOfUnrecoverableFECGroups.Get()).To(Equal(uint64(1)))
+			Expect(receiver.equations).To(BeEmpty())
+			Expect(receiver.waitingEquations).To(BeEmpty())
+		})
+	})
+})
@@ -3,6 +3,8 @@ package quic
 import (
 	"errors"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -53,178 +55,532 @@ import (
 
 // 核心是用Framer发送(push)；用Container装载，Container的实例有window和group；用Scheduler标注已经发送。因为Scheduler不考虑RLC，因此单独定义window
 type FECFrameworkSender struct {
-	fecScheme            fec.FECScheme
-	fecScheduler         fec.FECScheduler
-	fecFramer            *FECFramer
+	fecScheme fec.FECScheme
+	// Every path protects the packets it carries in its own FEC blocks, sized by its redundancy controller.
+	// The packets of every FEC protection level having its own redundancy controller are protected by other FEC blocks.
+	fecSchedulers map[fecContainerKey]fec.FECScheduler
+	// The redundancy controllers of the FEC protection levels, shared by all the paths
+	levelRedundancyControllers map[protocol.FECProtectionLevel]fec.RedundancyController
+	// set once the use of FEC protection levels with a convolutional FEC scheme has been reported
+	reportedConvolutionalLevels bool
+	blockNumbers                *fec.FECBlockNumberGenerator
+	fecFramer                   *FECFramer
+	// Used for the packets of unknown paths
 	redundancyController fec.RedundancyController
+	// The path carrying the last source symbol added to the FEC window
+	lastSourcePathID protocol.PathID
 
 	nextEncodingSymbolID protocol.FECEncodingSymbolID
 	fecWindow            *fec.FECWindow
+	version              protocol.VersionNumber
 	sess                 *session
-	numberOfSymbols      int
 	numberOfSymbolsAcked int
+	// the FEC statistics of the sender, read by Session.FECStats while the session sends packets
+	numberOfSourceSymbols utils.AtomicUint64
+	numberOfSymbols       utils.AtomicUint64
+	numberOfRepairBytes   utils.AtomicUint64
+	historyMutex          sync.Mutex
+	redundancyHistory     []RedundancyParameters
+	// the last redundancy parameters recorded for the FEC blocks of every container, or for the FEC window
+	lastRedundancyParameters map[fecContainerKey]RedundancyParameters
+	// The FEC blocks whose decoding has not been reported by the peer yet, with the containers they belong to
+	sentFECBlockContainers map[protocol.FECBlockNumber]fecContainerKey
+	// The time at which the FEC blocks of a container, or the FEC window, must be flushed for the unreliable stream data
+	// they protect to be recovered before its deadline. The FEC window is stored under protocol.InitialPathID.
+	deadlineFlushTimes map[fecContainerKey]time.Time
 	// TempCount            int
 }
 
+// fecContainerKey identifies the FEC blocks protecting the packets sent on a path with a FEC protection level
+type fecContainerKey struct {
+	pathID protocol.PathID
+	level  protocol.FECProtectionLevel
+}
+
+// the number of FEC blocks remembered until the peer reports their decoding
+const maxTrackedSentFECBlocks = 1000
+
 var FECFrameworkSenderPacketHandledWithWrongFECGroup = errors.New("FECFrameworkSender: A packet with the wrong FEC Group Number has been added")
+var FECFrameworkSenderNoFECScheme = errors.New("FECFrameworkSender: no FEC scheme in common with the peer to generate repair symbols")
 
 func (f *FECFrameworkSender) handleSymbolACKFrame(frame *wire.SymbolAckFrame) {
 	utils.Debugf("Acked Symbol: %d", frame.SymbolReceived)
 	f.numberOfSymbolsAcked = int(frame.SymbolReceived)
-	// log.Print("frame.SymbolReceived: %d", frame.SymbolReceived)
-	// todo
+	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
+		f.releaseAcknowledgedSymbols(frame.SymbolRanges)
+	}
+	for _, status := range frame.BlockStatuses {
+		f.handleFECBlockStatus(status)
+	}
+}
+
+// releaseAcknowledgedSymbols removes the source symbols received or recovered by the peer from the FEC window,
+// up to the first one it misses: the next repair symbols only protect the symbols that may be lost
+func (f *FECFrameworkSender) releaseAcknowledgedSymbols(ranges []wire.SymbolRange) {
+	first := f.fecWindow.FirstEncodingSymbolID()
+	for _, r := range ranges {
+		if protocol.FECEncodingSymbolID(r.First) <= first && first <= protocol.FECEncodingSymbolID(r.Last) {
+			f.fecWindow.ReleaseUpTo(protocol.FECEncodingSymbolID(r.Last))
+			return
+		}
+	}
+}
+
+// handleFECBlockStatus gives the decoding outcome of a FEC block to the redundancy controller that sized it
+func (f *FECFrameworkSender) handleFECBlockStatus(status wire.FECBlockStatus) {
+	redundancyController := f.redundancyController
+	if key, ok := f.sentFECBlockContainers[status.FECBlockNumber]; ok {
+		delete(f.sentFECBlockContainers, status.FECBlockNumber)
+		redundancyController = f.getContainerRedundancyController(key)
+	}
+	if c, ok := redundancyController.(fec.DecodingFeedbackRedundancyController); ok {
+		c.OnFECBlockDecoded(status)
+	}
+}
+
+// sentFECBlock remembers the container of a FEC block whose repair symbols are sent
+func (f *FECFrameworkSender) sentFECBlock(fecBlockNumber protocol.FECBlockNumber, key fecContainerKey) {
+	f.sentFECBlockContainers[fecBlockNumber] = key
+	// the decoding of a block is not reported if its SymbolAckFrame is lost
+	if fecBlockNumber >= maxTrackedSentFECBlocks {
+		delete(f.sentFECBlockContainers, fecBlockNumber-maxTrackedSentFECBlocks)
+	}
 }
 
 // TODO define a window size and a spacing
 // TODO: define convolutional design in FEC frames
-func NewFECFrameworkSender(fecScheme fec.FECScheme, fecScheduler fec.FECScheduler, fecFramer *FECFramer, redundancyController fec.RedundancyController, version protocol.VersionNumber, session *session) *FECFrameworkSender {
-	window := fec.NewFECWindow(uint8(redundancyController.GetNumberOfDataSymbols()), version)
+func NewFECFrameworkSender(fecScheme fec.FECScheme, fecFramer *FECFramer, redundancyController fec.RedundancyController, version protocol.VersionNumber, session *session) *FECFrameworkSender {
+	window := fec.NewFECWindow(getFECWindowSize(redundancyController, session), version)
 
 	return &FECFrameworkSender{
-		fecScheme:            fecScheme,
-		fecScheduler:         fecScheduler,
-		fecFramer:            fecFramer,
-		redundancyController: redundancyController,
-		nextEncodingSymbolID: 1,
-		fecWindow:            window,
-		sess:                 session,
+		fecScheme:                  fecScheme,
+		fecSchedulers:              make(map[fecContainerKey]fec.FECScheduler),
+		levelRedundancyControllers: make(map[protocol.FECProtectionLevel]fec.RedundancyController),
+		blockNumbers:               &fec.FECBlockNumberGenerator{},
+		fecFramer:                  fecFramer,
+		redundancyController:       redundancyController,
+		nextEncodingSymbolID:       1,
+		fecWindow:                  window,
+		version:                    version,
+		sess:                       session,
+		sentFECBlockContainers:     make(map[protocol.FECBlockNumber]fecContainerKey),
+		deadlineFlushTimes:         make(map[fecContainerKey]time.Time),
+		lastRedundancyParameters:   make(map[fecContainerKey]RedundancyParameters),
+	}
+}
+
+// getFECWindowSize returns the size of the convolutional window set by the Config of the session, or else asked by the
+// redundancy controller, bounded by protocol.MaxFECWindowSize
+func getFECWindowSize(redundancyController fec.RedundancyController, sess *session) uint16 {
+	size := int(redundancyController.GetNumberOfDataSymbols())
+	if sess != nil && sess.config != nil && sess.config.ConvolutionalWindowSize != 0 {
+		size = int(sess.config.ConvolutionalWindowSize)
+	}
+	return uint16(utils.Min(size, int(protocol.MaxFECWindowSize)))
+}
+
+// getPath returns the path pathID of the session, or nil if it doesn't exist
+func (f *FECFrameworkSender) getPath(pathID protocol.PathID) *path {
+	if f.sess == nil {
+		return nil
+	}
+	return f.sess.paths[pathID]
+}
+
+// getRedundancyController returns the redundancy controller of the path pathID
+func (f *FECFrameworkSender) getRedundancyController(pathID protocol.PathID) fec.RedundancyController {
+	if pth := f.getPath(pathID); pth != nil && pth.redundancyController != nil {
+		return pth.redundancyController
+	}
+	return f.redundancyController
+}
+
+// getLevelRedundancyController returns the redundancy controller of the FEC protection level level, or nil if the
+// level uses the redundancy controllers of the paths
+func (f *FECFrameworkSender) getLevelRedundancyController(level protocol.FECProtectionLevel) fec.RedundancyController {
+	if level == protocol.FECProtectionDefault {
+		return nil
+	}
+	redundancyController, ok := f.levelRedundancyControllers[level]
+	if !ok {
+		if f.sess != nil && f.sess.config != nil {
+			redundancyController = f.sess.config.FECProtectionLevels[level]
+		}
+		if redundancyController == nil {
+			redundancyController = fec.NewFECProtectionLevelRedundancyController(level)
+		}
+		f.levelRedundancyControllers[level] = redundancyController
+	}
+	return redundancyController
+}
+
+// containerKey returns the key of the FEC blocks protecting the packets sent on the path pathID with the protection
+// level level. The levels without their own redundancy controller share the FEC blocks of the default level.
+func (f *FECFrameworkSender) containerKey(pathID protocol.PathID, level protocol.FECProtectionLevel) fecContainerKey {
+	if f.getLevelRedundancyController(level) == nil {
+		level = protocol.FECProtectionDefault
+	}
+	return fecContainerKey{pathID: pathID, level: level}
+}
+
+// getContainerRedundancyController returns the redundancy controller sizing the FEC blocks of the key key
+func (f *FECFrameworkSender) getContainerRedundancyController(key fecContainerKey) fec.RedundancyController {
+	if redundancyController := f.getLevelRedundancyController(key.level); redundancyController != nil {
+		return redundancyController
+	}
+	return f.getRedundancyController(key.pathID)
+}
+
+// getFECScheduler returns the scheduler of the FEC blocks of the key key
+func (f *FECFrameworkSender) getFECScheduler(key fecContainerKey) fec.FECScheduler {
+	fecScheduler, ok := f.fecSchedulers[key]
+	if !ok {
+		fecScheduler = fec.NewRoundRobinSchedulerWithBlockNumbers(f.getContainerRedundancyController(key), f.version, f.blockNumbers)
+		f.fecSchedulers[key] = fecScheduler
+	}
+	return fecScheduler
+}
+
+// setRedundancyController makes all the paths use the redundancy controller c. The FEC protection levels keep theirs.
+func (f *FECFrameworkSender) setRedundancyController(c fec.RedundancyController) {
+	f.redundancyController = c
+	for key, fecScheduler := range f.fecSchedulers {
+		if key.level == protocol.FECProtectionDefault {
+			fecScheduler.SetRedundancyController(c)
+		}
 	}
 }
 
 // 取出下一个fecContainer并将packet添加进去；
 // 如果是FECScheme是ConvolutionalFECScheme则Container是FECWindow；
 // 否则是FECGroup
-func (f *FECFrameworkSender) HandlePacket(packet []byte, hdr *wire.Header) {
+// The packet carries stream frames of the FEC protection level level.
+func (f *FECFrameworkSender) HandlePacket(packet []byte, hdr *wire.Header, level protocol.FECProtectionLevel) {
 
-	hdr.FECPayloadID = f.GetNextSourceFECPayloadID()
+	hdr.FECPayloadID = f.GetNextSourceFECPayloadIDForLevel(hdr.PathID, level)
+	redundancyController := f.getRedundancyController(hdr.PathID)
 
 	var fecContainer fec.FECContainer
 	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
+		// The encoding symbol IDs of a window are contiguous, so all the paths and the protection levels share it
+		f.reportConvolutionalLevel(level)
 		fecContainer = f.fecWindow
+		f.lastSourcePathID = hdr.PathID
 	} else {
-		fecContainer = f.fecScheduler.GetNextFECGroup()
+		fecContainer = f.getFECScheduler(f.containerKey(hdr.PathID, level)).GetNextFECGroup()
 	}
 
 	switch fc := fecContainer.(type) {
 	case *fec.FECWindow:
-		if uint(fc.WindowSize) != f.redundancyController.GetNumberOfDataSymbols() {
-			fc.SetSize(int(f.redundancyController.GetNumberOfDataSymbols()))
+		if size := getFECWindowSize(redundancyController, f.sess); fc.WindowSize != size {
+			fc.SetSize(int(size))
 		}
 	}
 
 	fecContainer.AddPacket(packet, hdr)
 	f.nextEncodingSymbolID++
+	f.numberOfSourceSymbols.Increment(1)
+}
+
+// reportConvolutionalLevel reports once that the packets of the FEC protection level level are protected by the FEC
+// window of the convolutional FEC scheme like the packets of the default level, as documented on Stream.SetFECProtection
+func (f *FECFrameworkSender) reportConvolutionalLevel(level protocol.FECProtectionLevel) {
+	if level == protocol.FECProtectionDefault || level == protocol.FECProtectionNone || f.reportedConvolutionalLevels {
+		return
+	}
+	f.reportedConvolutionalLevels = true
+	utils.Errorf("FEC protection levels are not supported by convolutional FEC schemes, the packets of the level %d are protected as the default level", level)
 }
 
 // handles this packet for Forward Error Correction.
 // Returns the packet as it should be sent
 // TODO: the framework should receive a packet without a valid FEC Group and should insert it itself.
-func (f *FECFrameworkSender) HandlePacketAndMaybePushRS(packet []byte, hdr *wire.Header) ([]byte, error) {
+func (f *FECFrameworkSender) HandlePacketAndMaybePushRS(packet []byte, hdr *wire.Header, level protocol.FECProtectionLevel) ([]byte, error) {
 	//TODO: decide if we here assume that the packet already has the correct header, fecGroupNumber or not
 	//if hdr.FECPayloadID != f.GetNextSourceFECPayloadID() {
 	//	log.Printf("%d VS %d", hdr.FECPayloadID, f.GetNextSourceFECPayloadID())
 	//	return nil, FECFrameworkSenderPacketHandledWithWrongFECGroup
 	//}
 
-	f.HandlePacket(packet, hdr)
+	f.HandlePacket(packet, hdr, level)
 
 	// TODO: remove these ugly ifs
 
-	// mainly for rQUIC
-	// wairning: we don't consider multipath, so the path number is default in protocol.InitialPathID(0)
-	smoothedRTT := f.sess.paths[protocol.InitialPathID].rttStats.SmoothedRTT()
-	sntPkts, sntRetrans, sntLost := f.sess.paths[protocol.InitialPathID].sentPacketHandler.GetStatistics()
-	rcvPkts, recoveredPkts := f.sess.paths[protocol.InitialPathID].receivedPacketHandler.GetStatistics()
-	f.redundancyController.PushParamerters(fec.TransParams{
-		SntPkts:       sntPkts,
-		SntRetrans:    sntRetrans,
-		SntLost:       sntLost,
-		RcvPkts:       rcvPkts,
-		RecoveredPkts: recoveredPkts,
-		SmoothedRTT:   smoothedRTT,
-	})
-	// end
+	// The repair symbols are sized to the losses of the path carrying the source symbols, or to the protection level
+	key := f.containerKey(hdr.PathID, level)
+	redundancyController := f.getRedundancyController(hdr.PathID)
+	if pth := f.getPath(hdr.PathID); pth != nil {
+		pth.pushRedundancyParameters()
+	}
 
 	var fecContainer fec.FECContainer
+	var fecScheduler fec.FECScheduler
 	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
 		fecContainer = f.fecWindow
 	} else {
-		fecContainer = f.fecScheduler.GetNextFECGroup()
+		redundancyController = f.getContainerRedundancyController(key)
+		fecScheduler = f.getFECScheduler(key)
+		fecContainer = fecScheduler.GetNextFECGroup()
 	}
 
-	if fecContainer.ShouldBeSent(f.redundancyController) {
+	if fecContainer.ShouldBeSent(redundancyController) {
 		if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
-
+			// the levels share the FEC window
+			f.recordRedundancyParameters(fecContainerKey{pathID: hdr.PathID}, redundancyController, time.Now())
 		} else {
 			fecGroup := fecContainer.(*fec.FECBlock)
 			fecGroup.TotalNumberOfPackets = fecContainer.CurrentNumberOfPackets()
-			defer f.fecScheduler.SentFECBlock(fecGroup.FECBlockNumber)
+			f.sentFECBlock(fecGroup.FECBlockNumber, key)
+			f.recordRedundancyParameters(key, redundancyController, time.Now())
+			defer fecScheduler.SentFECBlock(fecGroup.FECBlockNumber)
 		}
 		// 测试编码时间
 		// start := time.Now()
-		f.GenerateRepairSymbols(fecContainer, f.redundancyController.GetNumberOfRepairSymbols(), hdr.FECPayloadID)
+		f.GenerateRepairSymbols(fecContainer, redundancyController.GetNumberOfRepairSymbols(), hdr.FECPayloadID)
 		// elapsed := time.Since(start)
 		// utils.Infof(fmt.Sprintf("encode time: %s", elapsed))
 		// log.Printf("encode time: %s,encode length: %d", elapsed, len(fecContainer.GetPackets()))
-		// log.Printf("rc souce: %d, rc repair: %d", f.redundancyController.GetNumberOfDataSymbols(), f.redundancyController.GetNumberOfRepairSymbols())
+		// log.Printf("rc souce: %d, rc repair: %d", redundancyController.GetNumberOfDataSymbols(), redundancyController.GetNumberOfRepairSymbols())
 
 		// 最后检查，并更新所有packet的NRS和NSS
 		fecContainer.PrepareToSend() // will never error thanks to the ShouldBeSent check
 		symbols := fecContainer.GetRepairSymbols()
-		f.fecFramer.pushRepairSymbols(symbols)
-		f.numberOfSymbols += len(symbols)
-		utils.Debugf("numberOfSymbols has been sent: %d", f.numberOfSymbols)
+		setSourcePathID(symbols, hdr.PathID)
+		f.pushRepairSymbols(symbols)
+		utils.Debugf("numberOfSymbols has been sent: %d", f.numberOfSymbols.Get())
+		if deadlineKey := f.deadlineKey(hdr.PathID, level); !f.hasUnprotectedSymbols(deadlineKey) {
+			delete(f.deadlineFlushTimes, deadlineKey)
+		}
 	}
 
 	// if f.redundancyController.GetNumberOfDataSymbols()
 
+	if err := f.flushForDeadlines(time.Now()); err != nil {
+		return nil, err
+	}
 	return packet, nil
 }
 
+// deadlineKey returns the key of the flush time of the FEC container protecting the packets of the path pathID
+// with the protection level level
+func (f *FECFrameworkSender) deadlineKey(pathID protocol.PathID, level protocol.FECProtectionLevel) fecContainerKey {
+	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
+		// the paths and the protection levels share the FEC window
+		return fecContainerKey{pathID: protocol.InitialPathID}
+	}
+	return f.containerKey(pathID, level)
+}
+
+// hasUnprotectedSymbols returns true if source symbols of the FEC container of the key key wait for their repair symbols
+func (f *FECFrameworkSender) hasUnprotectedSymbols(key fecContainerKey) bool {
+	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
+		return f.fecWindow.HasSomethingToSend() && f.fecWindow.CurrentNumberOfPackets() > 0
+	}
+	return len(f.getFECScheduler(key).CurrentFECGroups()) > 0
+}
+
+// protectUntilDeadline is called before handling a packet of the path pathID carrying unreliable stream data of the
+// protection level level that must arrive within deadline. If a retransmission would arrive too late, the repair
+// symbols protecting the packet are flushed early enough to arrive before the deadline, rather than when the FEC
+// container is full.
+func (f *FECFrameworkSender) protectUntilDeadline(pathID protocol.PathID, level protocol.FECProtectionLevel, deadline time.Duration) {
+	pth := f.getPath(pathID)
+	if pth == nil || deadline <= 0 {
+		return
+	}
+	rtt := pth.smoothedOrInitialRTT()
+	if fec.CanRetransmitBeforeDeadline(deadline, rtt) {
+		return
+	}
+	key := f.deadlineKey(pathID, level)
+	flushTime := fec.DeadlineFlushTime(time.Now(), deadline, rtt)
+	if t, ok := f.deadlineFlushTimes[key]; !ok || flushTime.Before(t) {
+		f.deadlineFlushTimes[key] = flushTime
+	}
+}
+
+// nextDeadlineFlush returns the time at which FEC containers must be flushed, or the zero time if there is none
+func (f *FECFrameworkSender) nextDeadlineFlush() time.Time {
+	var next time.Time
+	for _, t := range f.deadlineFlushTimes {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// flushForDeadlines sends the repair symbols of the FEC containers whose flush time has come
+func (f *FECFrameworkSender) flushForDeadlines(now time.Time) error {
+	for key, flushTime := range f.deadlineFlushTimes {
+		if now.Before(flushTime) {
+			continue
+		}
+		delete(f.deadlineFlushTimes, key)
+		var symbols []*fec.RepairSymbol
+		if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
+			fw := f.fecWindow
+			if !fw.HasSomethingToSend() || fw.CurrentNumberOfPackets() == 0 {
+				continue
+			}
+			rs, err := f.flushRepairSymbols(fw, fecContainerKey{pathID: f.lastSourcePathID})
+			if err != nil {
+				return err
+			}
+			fw.SetRepairSymbols(rs)
+			fw.PrepareToSend()
+			setSourcePathID(rs, f.lastSourcePathID)
+			symbols = rs
+		} else {
+			fecScheduler := f.getFECScheduler(key)
+			for _, group := range fecScheduler.CurrentFECGroups() {
+				rs, err := f.flushRepairSymbols(group, key)
+				if err != nil {
+					return err
+				}
+				group.SetRepairSymbols(rs)
+				fecScheduler.SentFECBlock(group.FECBlockNumber)
+				f.sentFECBlock(group.FECBlockNumber, key)
+				setSourcePathID(rs, key.pathID)
+				symbols = append(symbols, rs...)
+			}
+		}
+		f.pushRepairSymbols(symbols)
+	}
+	return nil
+}
+
+// flushRepairSymbols generates the repair symbols of a FEC container before it is full. They are numerous enough for
+// its source symbols to be recovered before their deadline despite the losses of the path of the key key, if the FEC
+// scheme can generate them.
+func (f *FECFrameworkSender) flushRepairSymbols(container fec.FECContainer, key fecContainerKey) ([]*fec.RepairSymbol, error) {
+	n := f.getContainerRedundancyController(key).GetNumberOfRepairSymbols()
+	needed := n
+	if pth := f.getPath(key.pathID); pth != nil {
+		needed = uint(utils.MaxUint64(uint64(n), uint64(fec.DeadlineRepairSymbols(uint(container.CurrentNumberOfPackets()), pth.lossRate(), protocol.DeadlineFECResidualLoss, protocol.MaxDeadlineRepairSymbols))))
+	}
+	rs, err := fec.FlushCurrentSymbols(f.fecScheme, container, needed)
+	if err != nil && needed > n {
+		// e.g. the XOR FEC scheme only generates one repair symbol
+		return fec.FlushCurrentSymbols(f.fecScheme, container, n)
+	}
+	return rs, err
+}
+
 func (f *FECFrameworkSender) PushRemainingFrames() error {
 	rs, err := f.GetRepairSymbols(f.redundancyController.GetNumberOfRepairSymbols())
 	if err != nil {
 		return err
 	}
-	f.fecFramer.pushRepairSymbols(rs)
+	f.pushRepairSymbols(rs)
 	return nil
 }
 
+// pushRepairSymbols gives the repair symbols to the FEC framer and counts them
+func (f *FECFrameworkSender) pushRepairSymbols(symbols []*fec.RepairSymbol) {
+	f.fecFramer.pushRepairSymbols(symbols)
+	f.numberOfSymbols.Increment(uint64(len(symbols)))
+	for _, symbol := range symbols {
+		f.numberOfRepairBytes.Increment(uint64(len(symbol.Data)))
+	}
+}
+
+// recordRedundancyParameters adds the parameters of the redundancy controller sizing the repair symbols of the key
+// key to the redundancy history, if they changed since the last repair symbols of the key
+func (f *FECFrameworkSender) recordRedundancyParameters(key fecContainerKey, redundancyController fec.RedundancyController, now time.Time) {
+	params := newRedundancyParameters(key, redundancyController, now)
+	f.historyMutex.Lock()
+	defer f.historyMutex.Unlock()
+	if last, ok := f.lastRedundancyParameters[key]; ok && last.sameParameters(params) {
+		return
+	}
+	f.lastRedundancyParameters[key] = params
+	f.redundancyHistory = append(f.redundancyHistory, params)
+	if len(f.redundancyHistory) > maxRedundancyHistory {
+		f.redundancyHistory = f.redundancyHistory[1:]
+	}
+}
+
+// stats returns the FEC statistics of the sender
+func (f *FECFrameworkSender) stats() FECStats {
+	f.historyMutex.Lock()
+	history := make([]RedundancyParameters, len(f.redundancyHistory))
+	copy(history, f.redundancyHistory)
+	f.historyMutex.Unlock()
+	return FECStats{
+		SourceSymbolsProtected: f.numberOfSourceSymbols.Get(),
+		RepairSymbolsSent:      f.numberOfSymbols.Get(),
+		RepairBytesSent:        ByteCount(f.numberOfRepairBytes.Get()),
+		RedundancyHistory:      history,
+	}
+}
+
+// GetRepairSymbols flushes the FEC window, or the current FEC block of every path and protection level.
+// No more than the number of repair symbols of the path carrying the source symbols are generated, the FEC blocks of
+// the protection levels having their own redundancy controller get the number of repair symbols of their level.
 func (f *FECFrameworkSender) GetRepairSymbols(numberOfRepairSymbols uint) ([]*fec.RepairSymbol, error) {
 
 	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
 		fw := f.fecWindow
-		if fw.HasSomethingToSend() {
-			rs, err := fec.FlushCurrentSymbols(f.fecScheme, fw, numberOfRepairSymbols)
+		// the window is empty when the peer acknowledged all its source symbols
+		if fw.HasSomethingToSend() && fw.CurrentNumberOfPackets() > 0 {
+			n := uint(utils.MinUint64(uint64(numberOfRepairSymbols), uint64(f.getRedundancyController(f.lastSourcePathID).GetNumberOfRepairSymbols())))
+			rs, err := fec.FlushCurrentSymbols(f.fecScheme, fw, n)
 			if err != nil {
 				log.Printf("RLC PUSH ERROR %+v", err)
 				return nil, err
 			}
 			fw.SetRepairSymbols(rs)
 			fw.PrepareToSend()
+			setSourcePathID(rs, f.lastSourcePathID)
 			return rs, nil
 		}
 
 	} else if _, ok := f.fecScheme.(fec.BlockFECScheme); ok {
-		for group := f.fecScheduler.GetNextFECGroup(); group.CurrentNumberOfPackets() > 0; group = f.fecScheduler.GetNextFECGroup() {
-			rs, err := fec.FlushCurrentSymbols(f.fecScheme, group, uint(utils.MinUint64(uint64(numberOfRepairSymbols), uint64(f.redundancyController.GetNumberOfRepairSymbols()))))
-			if err != nil {
-				return nil, err
+		var symbols []*fec.RepairSymbol
+		for key, fecScheduler := range f.fecSchedulers {
+			n := f.getContainerRedundancyController(key).GetNumberOfRepairSymbols()
+			if key.level == protocol.FECProtectionDefault {
+				n = uint(utils.MinUint64(uint64(numberOfRepairSymbols), uint64(n)))
+			}
+			if group := fecScheduler.GetNextFECGroup(); group.CurrentNumberOfPackets() > 0 {
+				rs, err := fec.FlushCurrentSymbols(f.fecScheme, group, n)
+				if err != nil {
+					return nil, err
+				}
+				group.SetRepairSymbols(rs)
+				// Scheduler删除该Block
+				fecScheduler.SentFECBlock(group.FECBlockNumber)
+				f.sentFECBlock(group.FECBlockNumber, key)
+				setSourcePathID(rs, key.pathID)
+				symbols = append(symbols, rs...)
 			}
-			group.SetRepairSymbols(rs)
-			// Scheduler删除该Block
-			f.fecScheduler.SentFECBlock(group.FECBlockNumber)
-			return rs, nil
 		}
+		return symbols, nil
 	}
 	return nil, nil
 }
 
-func (f *FECFrameworkSender) GetNextSourceFECPayloadID() protocol.FECPayloadID {
+// setSourcePathID records the path carrying the source symbols protected by symbols
+func setSourcePathID(symbols []*fec.RepairSymbol, pathID protocol.PathID) {
+	for _, symbol := range symbols {
+		symbol.SourcePathID = pathID
+	}
+}
+
+// GetNextSourceFECPayloadID returns the FEC Payload ID of the next source symbol sent on the path pathID
+func (f *FECFrameworkSender) GetNextSourceFECPayloadID(pathID protocol.PathID) protocol.FECPayloadID {
+	return f.GetNextSourceFECPayloadIDForLevel(pathID, protocol.FECProtectionDefault)
+}
+
+// GetNextSourceFECPayloadIDForLevel returns the FEC Payload ID of the next source symbol sent on the path pathID
+// carrying stream frames of the FEC protection level level
+func (f *FECFrameworkSender) GetNextSourceFECPayloadIDForLevel(pathID protocol.PathID, level protocol.FECProtectionLevel) protocol.FECPayloadID {
 	if _, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
 		return protocol.NewConvolutionalSourceFECPayloadID(f.nextEncodingSymbolID)
 	} else {
-		return protocol.NewBlockSourceFECPayloadID(f.fecScheduler.GetNextFECBlockNumber(), f.fecScheduler.GetNextFECGroupOffset())
+		fecScheduler := f.getFECScheduler(f.containerKey(pathID, level))
+		return protocol.NewBlockSourceFECPayloadID(fecScheduler.GetNextFECBlockNumber(), fecScheduler.GetNextFECGroupOffset())
 	}
 }
 
@@ -234,8 +590,10 @@ func (f *FECFrameworkSender) GenerateRepairSymbols(container fec.FECContainer, n
 	if fs, ok := f.fecScheme.(fec.ConvolutionalFECScheme); ok {
 		symbols, err = fs.GetRepairSymbols(container, numberOfSymbols, id.GetConvolutionalEncodingSymbolID())
 
+	} else if fs, ok := f.fecScheme.(fec.BlockFECScheme); ok {
+		symbols, err = fs.GetRepairSymbols(container, numberOfSymbols, id.GetBlockNumber())
 	} else {
-		symbols, err = f.fecScheme.(fec.BlockFECScheme).GetRepairSymbols(container, numberOfSymbols, id.GetBlockNumber())
+		return FECFrameworkSenderNoFECScheme
 	}
 	if err != nil {
 		return err
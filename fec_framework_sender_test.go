@@ -1,5 +1,13 @@
 package quic
 
+import (
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
 // import (
 // 	"bytes"
 // 	"fmt"
@@ -181,3 +189,41 @@ package quic
 // 	}
 
 // })
+
+var _ = Describe("FEC framework sender", func() {
+	Context("convolutional window", func() {
+		var sess *session
+
+		BeforeEach(func() {
+			sess = &session{config: &Config{}}
+		})
+
+		newSender := func() *FECFrameworkSender {
+			return NewFECFrameworkSender(fec.NewRandomLinearFECScheme(), newFECFramer(nil, protocol.VersionMP), fec.NewConstantRedundancyController(10, 2, 1, 4), protocol.VersionMP, sess)
+		}
+
+		handlePacket := func(sender *FECFrameworkSender) {
+			sender.HandlePacket(make([]byte, 100), &wire.Header{PathID: 1, FECFlag: true}, protocol.FECProtectionDefault)
+		}
+
+		It("holds the number of source symbols of the redundancy controller", func() {
+			sender := newSender()
+			Expect(sender.fecWindow.WindowSize).To(Equal(uint16(10)))
+			handlePacket(sender)
+			Expect(sender.fecWindow.WindowSize).To(Equal(uint16(10)))
+		})
+
+		It("holds the number of source symbols set in the Config", func() {
+			sess.config.ConvolutionalWindowSize = 50
+			sender := newSender()
+			Expect(sender.fecWindow.WindowSize).To(Equal(uint16(50)))
+			handlePacket(sender)
+			Expect(sender.fecWindow.WindowSize).To(Equal(uint16(50)))
+		})
+
+		It("is bounded by the maximum window size", func() {
+			sess.config.ConvolutionalWindowSize = protocol.MaxFECWindowSize + 1
+			Expect(newSender().fecWindow.WindowSize).To(Equal(protocol.MaxFECWindowSize))
+		})
+	})
+})
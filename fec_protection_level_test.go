@@ -0,0 +1,134 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/congestion"
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC protection levels", func() {
+	Context("FEC framework sender", func() {
+		var (
+			sender       *FECFrameworkSender
+			packetNumber protocol.PacketNumber
+		)
+
+		BeforeEach(func() {
+			scheme, err := fec.NewReedSolomonFECScheme()
+			Expect(err).ToNot(HaveOccurred())
+			sender = NewFECFrameworkSender(scheme, newFECFramer(nil, protocol.VersionWhatever), fec.NewConstantRedundancyController(10, 2, 1, 4), protocol.VersionWhatever, nil)
+			packetNumber = 0
+		})
+
+		handlePacket := func(level protocol.FECProtectionLevel) *wire.Header {
+			packetNumber++
+			hdr := &wire.Header{PathID: 1, PacketNumber: packetNumber, FECFlag: true}
+			_, err := sender.HandlePacketAndMaybePushRS(make([]byte, 100), hdr, level)
+			Expect(err).ToNot(HaveOccurred())
+			return hdr
+		}
+
+		It("protects the packets of different levels in different FEC blocks", func() {
+			defaultBlocks := make(map[protocol.FECBlockNumber]bool)
+			highBlocks := make(map[protocol.FECBlockNumber]bool)
+			for i := 0; i < 4; i++ {
+				defaultBlocks[handlePacket(protocol.FECProtectionDefault).FECPayloadID.GetBlockNumber()] = true
+				highBlocks[handlePacket(protocol.FECProtectionHigh).FECPayloadID.GetBlockNumber()] = true
+			}
+			for number := range highBlocks {
+				Expect(defaultBlocks).ToNot(HaveKey(number))
+			}
+			Expect(sender.fecSchedulers).To(HaveKey(fecContainerKey{pathID: 1}))
+			Expect(sender.fecSchedulers).To(HaveKey(fecContainerKey{pathID: 1, level: protocol.FECProtectionHigh}))
+		})
+
+		It("sizes the FEC blocks of a level with its own redundancy controller", func() {
+			rc := fec.NewConstantRedundancyController(5, 3, 1, 1)
+			sender.sess = &session{config: &Config{FECProtectionLevels: map[FECProtectionLevel]fec.RedundancyController{FECProtectionHigh: rc}}}
+			Expect(sender.getContainerRedundancyController(sender.containerKey(1, protocol.FECProtectionHigh))).To(BeIdenticalTo(rc))
+			for i := 0; i < 4; i++ {
+				handlePacket(protocol.FECProtectionHigh)
+				handlePacket(protocol.FECProtectionDefault)
+			}
+			Expect(sender.fecFramer.transmissionQueue).To(BeEmpty())
+			handlePacket(protocol.FECProtectionHigh)
+			Expect(sender.fecFramer.transmissionQueue).To(HaveLen(3))
+			history := sender.stats().RedundancyHistory
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].FECProtectionLevel).To(Equal(protocol.FECProtectionHigh))
+			Expect(history[0].NumberOfSourceSymbols).To(Equal(uint(5)))
+			// the default level still fills its block of 10 source symbols
+			for i := 0; i < 5; i++ {
+				handlePacket(protocol.FECProtectionDefault)
+			}
+			Expect(sender.fecFramer.transmissionQueue).To(HaveLen(3))
+			handlePacket(protocol.FECProtectionDefault)
+			Expect(sender.fecFramer.transmissionQueue).To(HaveLen(5))
+		})
+	})
+
+	Context("stream framer", func() {
+		var (
+			framer     *streamFramer
+			streamsMap *streamsMap
+		)
+
+		BeforeEach(func() {
+			rttStats := &congestion.RTTStats{}
+			connFC := flowcontrol.NewConnectionFlowController(protocol.MaxByteCount, protocol.MaxByteCount, rttStats, nil)
+			connFC.UpdateSendWindow(protocol.MaxByteCount)
+			streamsMap = newStreamsMap(func(id protocol.StreamID) streamI {
+				fc := flowcontrol.NewStreamFlowController(id, true, connFC, protocol.MaxByteCount, protocol.MaxByteCount, protocol.MaxByteCount, rttStats, nil)
+				return newStream(id, func() {}, func(protocol.StreamID, protocol.ByteCount) {}, fc, protocol.VersionMP)
+			}, protocol.PerspectiveClient, protocol.VersionMP)
+			streamsMap.UpdateMaxStreamLimit(10)
+			framer = newStreamFramer(nil, streamsMap, connFC, false)
+		})
+
+		openStream := func(level protocol.FECProtectionLevel) streamI {
+			str, err := streamsMap.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			str.SetUnreliable(true)
+			str.SetFECProtection(level)
+			_, err = str.Write(make([]byte, 100))
+			Expect(err).ToNot(HaveOccurred())
+			return str
+		}
+
+		levels := func(frames []*wire.StreamFrame) []protocol.FECProtectionLevel {
+			var res []protocol.FECProtectionLevel
+			for _, f := range frames {
+				res = append(res, f.FECProtectionLevel)
+			}
+			return res
+		}
+
+		It("never puts the frames of different levels in the same packet", func() {
+			openStream(protocol.FECProtectionHigh)
+			openStream(protocol.FECProtectionLow)
+			openStream(protocol.FECProtectionHigh)
+			first := framer.PopStreamFrames(protocol.MaxPacketSize, 8)
+			Expect(first).To(HaveLen(2))
+			Expect(levels(first)).To(Equal([]protocol.FECProtectionLevel{protocol.FECProtectionHigh, protocol.FECProtectionHigh}))
+			second := framer.PopStreamFrames(protocol.MaxPacketSize, 8)
+			Expect(levels(second)).To(Equal([]protocol.FECProtectionLevel{protocol.FECProtectionLow}))
+		})
+
+		It("never retransmits the frames of different levels in the same packet", func() {
+			str := openStream(protocol.FECProtectionMedium)
+			framer.AddFrameForRetransmission(&wire.StreamFrame{StreamID: str.StreamID(), Data: []byte("foo"), Unreliable: true, RetransmitDeadline: time.Hour, TimeSent: time.Now(), FECProtectionLevel: protocol.FECProtectionMedium})
+			framer.AddFrameForRetransmission(&wire.StreamFrame{StreamID: str.StreamID(), Data: []byte("bar"), Unreliable: true, RetransmitDeadline: time.Hour, TimeSent: time.Now(), FECProtectionLevel: protocol.FECProtectionHigh})
+			first := framer.PopStreamFrames(protocol.MaxPacketSize, 8)
+			// the new data of the stream has the level of the retransmission
+			Expect(levels(first)).To(Equal([]protocol.FECProtectionLevel{protocol.FECProtectionMedium, protocol.FECProtectionMedium}))
+			second := framer.PopStreamFrames(protocol.MaxPacketSize, 8)
+			Expect(levels(second)).To(Equal([]protocol.FECProtectionLevel{protocol.FECProtectionHigh}))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/fec"
+)
+
+// FECStats is a snapshot of the FEC counters of a session, returned by Session.FECStats
+type FECStats struct {
+	// SourceSymbolsProtected is the number of packets sent in a FEC block or in the FEC window
+	SourceSymbolsProtected uint64
+	RepairSymbolsSent      uint64
+	// RepairBytesSent is the size of the repair symbols sent, without the headers of the FEC frames carrying them
+	RepairBytesSent ByteCount
+	// RecoveredPacketsBlock and RecoveredPacketsConvolutional are the numbers of packets recovered by the block and
+	// by the convolutional FEC receivers
+	RecoveredPacketsBlock         uint64
+	RecoveredPacketsConvolutional uint64
+	// UselessRepairSymbols is the number of repair symbols received while all the source symbols they protect were
+	// already received or recovered
+	UselessRepairSymbols uint64
+	// EvictedFECGroups is the number of FEC blocks or repair symbols given up by the receivers to bound their memory,
+	// and UnrecoverableFECGroups the number of them that still missed source symbols
+	EvictedFECGroups       uint64
+	UnrecoverableFECGroups uint64
+	// RedundancyHistory lists the parameters of the redundancy controllers sizing the repair symbols sent, every
+	// time they changed, from the oldest to the newest. Only the last maxRedundancyHistory changes are kept.
+	RedundancyHistory []RedundancyParameters
+}
+
+// RedundancyParameters are the parameters of the redundancy controller sizing the repair symbols protecting the
+// packets of a FEC protection level sent on a path, from the given time on
+type RedundancyParameters struct {
+	Time                      time.Time
+	PathID                    PathID
+	FECProtectionLevel        FECProtectionLevel
+	NumberOfSourceSymbols     uint
+	NumberOfRepairSymbols     uint
+	NumberOfInterleavedBlocks uint
+	WindowStepSize            uint
+}
+
+// the number of changes of the redundancy parameters remembered by a FECFrameworkSender
+const maxRedundancyHistory = 1000
+
+func newRedundancyParameters(key fecContainerKey, redundancyController fec.RedundancyController, now time.Time) RedundancyParameters {
+	return RedundancyParameters{
+		Time:                      now,
+		PathID:                    key.pathID,
+		FECProtectionLevel:        key.level,
+		NumberOfSourceSymbols:     redundancyController.GetNumberOfDataSymbols(),
+		NumberOfRepairSymbols:     redundancyController.GetNumberOfRepairSymbols(),
+		NumberOfInterleavedBlocks: redundancyController.GetNumberOfInterleavedBlocks(),
+		WindowStepSize:            redundancyController.GetWindowStepSize(),
+	}
+}
+
+// sameParameters returns true if p and other only differ by their time
+func (p RedundancyParameters) sameParameters(other RedundancyParameters) bool {
+	other.Time = p.Time
+	return p == other
+}
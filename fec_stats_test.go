@@ -0,0 +1,181 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC statistics", func() {
+	var (
+		sender *FECFrameworkSender
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		framer := newFECFramer(nil, protocol.VersionWhatever)
+		sender = NewFECFrameworkSender(nil, framer, fec.NewConstantRedundancyController(10, 2, 1, 4), protocol.VersionWhatever, nil)
+		now = time.Now()
+	})
+
+	It("counts the repair symbols and their bytes", func() {
+		sender.pushRepairSymbols([]*fec.RepairSymbol{{Data: make([]byte, 100)}, {Data: make([]byte, 50)}})
+		stats := sender.stats()
+		Expect(stats.RepairSymbolsSent).To(Equal(uint64(2)))
+		Expect(stats.RepairBytesSent).To(Equal(ByteCount(150)))
+		Expect(sender.fecFramer.transmissionQueue).To(HaveLen(2))
+	})
+
+	It("records the redundancy parameters when they change", func() {
+		key := fecContainerKey{pathID: 1}
+		sender.recordRedundancyParameters(key, fec.NewConstantRedundancyController(10, 2, 1, 4), now)
+		sender.recordRedundancyParameters(key, fec.NewConstantRedundancyController(10, 2, 1, 4), now.Add(time.Second))
+		sender.recordRedundancyParameters(fecContainerKey{pathID: 3}, fec.NewConstantRedundancyController(10, 2, 1, 4), now)
+		sender.recordRedundancyParameters(key, fec.NewConstantRedundancyController(5, 5, 2, 2), now.Add(2*time.Second))
+		history := sender.stats().RedundancyHistory
+		Expect(history).To(HaveLen(3))
+		Expect(history[0]).To(Equal(RedundancyParameters{
+			Time:                      now,
+			PathID:                    1,
+			NumberOfSourceSymbols:     10,
+			NumberOfRepairSymbols:     2,
+			NumberOfInterleavedBlocks: 1,
+			WindowStepSize:            4,
+		}))
+		Expect(history[1].PathID).To(Equal(PathID(3)))
+		Expect(history[2].Time).To(Equal(now.Add(2 * time.Second)))
+		Expect(history[2].NumberOfRepairSymbols).To(Equal(uint(5)))
+	})
+
+	It("bounds the redundancy history", func() {
+		key := fecContainerKey{pathID: 1}
+		for i := 0; i <= maxRedundancyHistory; i++ {
+			sender.recordRedundancyParameters(key, fec.NewConstantRedundancyController(uint(i%2+1), 1, 1, 1), now)
+		}
+		history := sender.stats().RedundancyHistory
+		Expect(history).To(HaveLen(maxRedundancyHistory))
+		Expect(history[0].NumberOfSourceSymbols).To(Equal(uint(2)))
+	})
+
+	Context("receivers", func() {
+		var sess *session
+
+		packets := [][]byte{[]byte("foobar"), []byte("lorem ipsum"), []byte("dolor sit amet")}
+
+		BeforeEach(func() {
+			sess = &session{version: protocol.VersionMP, recoveredPackets: make(chan *receivedPacket, 10), config: &Config{}}
+		})
+
+		// blockRepairFrame returns the FEC frame of the repair symbol protecting packets in the FEC block 1
+		blockRepairFrame := func(scheme fec.BlockFECScheme) *wire.FECFrame {
+			group := fec.NewFECGroup(1, protocol.VersionMP)
+			for i, p := range packets {
+				group.AddPacket(p, &wire.Header{PacketNumber: protocol.PacketNumber(i + 1), FECPayloadID: protocol.NewBlockSourceFECPayloadID(1, byte(i))})
+			}
+			symbols, err := scheme.GetRepairSymbols(group, 1, 1)
+			Expect(err).ToNot(HaveOccurred())
+			return &wire.FECFrame{
+				FECBlockNumber:        1,
+				FinBit:                true,
+				NumberOfPackets:       byte(len(packets)),
+				NumberOfRepairSymbols: 1,
+				Data:                  symbols[0].Data,
+			}
+		}
+
+		receiveBlockPackets := func(offsets ...int) {
+			for _, i := range offsets {
+				sess.fecFrameworkReceiver.handlePacket(packets[i], &wire.Header{FECFlag: true, PacketNumber: protocol.PacketNumber(i + 1), FECPayloadID: protocol.NewBlockSourceFECPayloadID(1, byte(i))})
+			}
+		}
+
+		// convolutionalRepairFrame returns the FEC frame of the repair symbol protecting packets in the FEC window
+		convolutionalRepairFrame := func(scheme fec.ConvolutionalFECScheme) *wire.FECFrame {
+			window := fec.NewFECWindow(uint16(len(packets)), protocol.VersionMP)
+			for i, p := range packets {
+				window.AddPacket(p, &wire.Header{PacketNumber: protocol.PacketNumber(i + 1), FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(protocol.FECEncodingSymbolID(i + 1))})
+			}
+			symbols, err := scheme.GetRepairSymbols(window, 1, protocol.FECEncodingSymbolID(len(packets)))
+			Expect(err).ToNot(HaveOccurred())
+			return &wire.FECFrame{
+				Convolutional:         true,
+				FECSchemeSpecific:     symbols[0].FECSchemeSpecific,
+				EncodingSymbolID:      symbols[0].EncodingSymbolID,
+				FinBit:                true,
+				NumberOfPackets:       byte(symbols[0].NumberOfPackets),
+				NumberOfRepairSymbols: 1,
+				Data:                  symbols[0].Data,
+			}
+		}
+
+		receiveConvolutionalPackets := func(offsets ...int) {
+			for _, i := range offsets {
+				sess.fecFrameworkReceiverConvolutional.handlePacket(packets[i], &wire.Header{FECFlag: true, PacketNumber: protocol.PacketNumber(i + 1), FECPayloadID: protocol.NewConvolutionalSourceFECPayloadID(protocol.FECEncodingSymbolID(i + 1))})
+			}
+		}
+
+		It("counts the packets recovered by the block receiver", func() {
+			scheme, err := fec.NewReedSolomonFECScheme()
+			Expect(err).ToNot(HaveOccurred())
+			sess.SetFECScheme(scheme)
+			sess.fecFrameworkReceiver.doRecovery = false
+			receiveBlockPackets(0, 2)
+			sess.fecFrameworkReceiver.handleFECFrame(blockRepairFrame(scheme))
+			stats := sess.FECStats()
+			Expect(stats.RecoveredPacketsBlock).To(Equal(uint64(1)))
+			Expect(stats.RecoveredPacketsConvolutional).To(BeZero())
+			Expect(stats.UselessRepairSymbols).To(BeZero())
+		})
+
+		It("counts the useless repair symbols of the block receiver", func() {
+			scheme, err := fec.NewReedSolomonFECScheme()
+			Expect(err).ToNot(HaveOccurred())
+			sess.SetFECScheme(scheme)
+			receiveBlockPackets(0, 1, 2)
+			sess.fecFrameworkReceiver.handleFECFrame(blockRepairFrame(scheme))
+			stats := sess.FECStats()
+			Expect(stats.RecoveredPacketsBlock).To(BeZero())
+			Expect(stats.UselessRepairSymbols).To(Equal(uint64(1)))
+		})
+
+		It("counts the packets recovered by the convolutional receiver", func() {
+			scheme := fec.NewRandomLinearFECScheme()
+			sess.SetFECScheme(scheme)
+			sess.fecFrameworkReceiverConvolutional.doRecovery = false
+			receiveConvolutionalPackets(0, 1)
+			sess.fecFrameworkReceiverConvolutional.handleFECFrame(convolutionalRepairFrame(scheme))
+			stats := sess.FECStats()
+			Expect(stats.RecoveredPacketsConvolutional).To(Equal(uint64(1)))
+			Expect(stats.RecoveredPacketsBlock).To(BeZero())
+			Expect(stats.UselessRepairSymbols).To(BeZero())
+		})
+
+		It("counts the useless repair symbols of the convolutional receiver", func() {
+			scheme := fec.NewRandomLinearFECScheme()
+			sess.SetFECScheme(scheme)
+			receiveConvolutionalPackets(0, 1, 2)
+			sess.fecFrameworkReceiverConvolutional.handleFECFrame(convolutionalRepairFrame(scheme))
+			stats := sess.FECStats()
+			Expect(stats.RecoveredPacketsConvolutional).To(BeZero())
+			Expect(stats.UselessRepairSymbols).To(Equal(uint64(1)))
+		})
+
+		It("reads the counters while the receivers are created", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				for i := 0; i < 100; i++ {
+					sess.FECStats()
+				}
+				close(done)
+			}()
+			sess.SetFECScheme(fec.NewRandomLinearFECScheme())
+			<-done
+			Expect(sess.FECStats().RecoveredPacketsConvolutional).To(BeZero())
+		})
+	})
+})
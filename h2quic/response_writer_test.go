@@ -68,6 +68,9 @@ func (s *mockStream) GetReplayBufferSize() uint64 { panic("not implemented") }
 func (s *mockStream) SetMessageMode(val bool) { panic("not implemented") }
 func (s *mockStream) GetMessageMode() bool { panic("not implemented") }
 
+func (s *mockStream) SetFECProtection(level protocol.FECProtectionLevel) { panic("not implemented") }
+func (s *mockStream) GetFECProtection() protocol.FECProtectionLevel { panic("not implemented") }
+
 func (s *mockStream) Read(p []byte) (int, error) {
 	n, _ := s.dataToRead.Read(p)
 	if n == 0 { // block if there's no data
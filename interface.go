@@ -32,6 +32,30 @@ const (
 	XORFECScheme         FECSchemeID = protocol.XORFECScheme
 	ReedSolomonFECScheme FECSchemeID = protocol.ReedSolomonFECScheme
 	RLCFECScheme         FECSchemeID = protocol.RLCFECScheme
+	// RLCRFC8681FECScheme is the sliding window RLC FEC scheme over GF(2^8) of RFC 8681
+	RLCRFC8681FECScheme FECSchemeID = protocol.RLCRFC8681FECScheme
+	// RaptorQFECScheme is the RaptorQ rateless block FEC scheme, it generates any number of repair symbols for a FEC group
+	RaptorQFECScheme FECSchemeID = protocol.RaptorQFECScheme
+	// RLCGF65536FECScheme is a random linear codes FEC scheme over GF(2^16), for encoding windows of thousands of packets
+	RLCGF65536FECScheme FECSchemeID = protocol.RLCGF65536FECScheme
+	// XOR2DFECScheme is a block FEC scheme sending the XOR parities of the rows and columns of the FEC group
+	XOR2DFECScheme FECSchemeID = protocol.XOR2DFECScheme
+)
+
+// A FECProtectionLevel is the FEC protection class of the data of a stream, set with Stream.SetFECProtection
+type FECProtectionLevel = protocol.FECProtectionLevel
+
+const (
+	// FECProtectionDefault protects the stream if it is unreliable or if Config.ProtectReliableStreamFrames is set
+	FECProtectionDefault FECProtectionLevel = protocol.FECProtectionDefault
+	// FECProtectionNone never protects the stream
+	FECProtectionNone FECProtectionLevel = protocol.FECProtectionNone
+	// FECProtectionLow protects the stream with a low redundancy, e.g. for bulk data
+	FECProtectionLow FECProtectionLevel = protocol.FECProtectionLow
+	// FECProtectionMedium protects the stream with a medium redundancy, e.g. for audio
+	FECProtectionMedium FECProtectionLevel = protocol.FECProtectionMedium
+	// FECProtectionHigh protects the stream with a high redundancy, e.g. for video key frames
+	FECProtectionHigh FECProtectionLevel = protocol.FECProtectionHigh
 )
 
 const (
@@ -39,6 +63,22 @@ const (
 	Aggregate MultipathServiceType = iota
 	// Handover support when changing networks
 	Handover
+	// Redundant sends every packet on every active path, trading bandwidth for tail latency
+	Redundant
+)
+
+// The FECPathPolicy decides on which path the repair symbols are sent
+type FECPathPolicy int
+
+const (
+	// FECPathDedicated sends the repair symbols on a path dedicated to them, if the session has at least three paths (default)
+	FECPathDedicated FECPathPolicy = iota
+	// FECPathSame sends the repair symbols on the path carrying their source symbols
+	FECPathSame
+	// FECPathLowestLoss sends the repair symbols on the path with the lowest loss rate
+	FECPathLowestLoss
+	// FECPathSpread spreads the repair symbols evenly across the paths
+	FECPathSpread
 )
 
 // Stream is the interface implemented by QUIC streams
@@ -99,6 +139,13 @@ type Stream interface {
 
 	SetMessageMode(val bool)
 	GetMessageMode() bool
+
+	// Sets the FEC protection level of the data written on this stream. The data of the streams of different levels
+	// is protected by different FEC blocks, with the redundancy of their level.
+	// The levels need a block FEC scheme: a convolutional FEC scheme protects the data of all the levels but
+	// FECProtectionNone in the same FEC window, with the redundancy of the paths, as for FECProtectionDefault.
+	SetFECProtection(level FECProtectionLevel)
+	GetFECProtection() FECProtectionLevel
 }
 
 // A Session is a QUIC connection between two peers.
@@ -128,9 +175,26 @@ type Session interface {
 	// gets the FEC Scheme used by this session
 	GetFECScheme() fec.FECScheme
 
+	// SetRedundancyController makes every path of the session use c
 	SetRedundancyController(c fec.RedundancyController)
 
 	GetRedundancyController() fec.RedundancyController
+
+	// Paths returns a snapshot of the state of every path of the session, sorted by ID.
+	Paths() []PathInfo
+	// OpenPath opens a new path between a local address of the session and an address of the peer.
+	// Only the client can open paths, once the handshake completed.
+	OpenPath(local, remote net.Addr) (PathID, error)
+	// ClosePath stops using the path with the given ID. The initial path cannot be closed.
+	// Packets in flight on the path are retransmitted on the other paths.
+	ClosePath(id PathID) error
+	// SetBackupInterface marks the local network interface ifName (e.g. a metered cellular one) as backup or not.
+	// Backup paths only carry probes until all the other paths are potentially failed.
+	SetBackupInterface(ifName string, backup bool) error
+
+	// FECStats returns a snapshot of the FEC counters of the session: the symbols sent, the packets recovered and the
+	// parameters used by the redundancy controllers over time
+	FECStats() FECStats
 }
 
 // A NonFWSession is a QUIC connection between two peers half-way through the handshake.
@@ -178,12 +242,47 @@ type Config struct {
 	MaxPathID uint8
 	// Was is the service expected by the use of multiple paths?
 	MultipathService MultipathServiceType
+	// The name of the path scheduler, either a built-in one (e.g. SchedulerRoundRobin) or one added with RegisterPathScheduler.
+	// If empty, SchedulerLowLatency is used.
+	Scheduler string
 	// A Notification ID, useful for darwin platform to notify network change
 	NotifyID string
-	// The ID of the FEC Scheme that we use to decode the FEC Frames
+	// The ID of the FEC Scheme that we use to decode the FEC Frames, either a built-in one or one added with fec.RegisterScheme.
+	// If the peer does not support it, another FEC scheme supported by both peers is negotiated during the handshake.
 	FECScheme protocol.FECSchemeID
 	// A redundancy controller that controls the amount of redundancy sent at any time.
+	// It is shared by all the paths of a session.
 	RedundancyController fec.RedundancyController
+	// NewRedundancyController creates the redundancy controller of every path, such that the redundancy
+	// sent follows the losses of each path. It takes precedence over RedundancyController.
+	// If both are nil, every path gets its own rQUIC redundancy controller.
+	// fec.NewDefaultAdaptiveController switches the redundancy of each path as its losses change.
+	NewRedundancyController func() fec.RedundancyController
+	// The number of source symbols that the rQUIC redundancy controller initially protects together.
+	// If zero, protocol.DefaultNumberOfFECPackets is used.
+	NumberOfFECPackets uint8
+	// The number of repair symbols sent by the rQUIC redundancy controller for each FEC Group or window step.
+	// If zero, protocol.DefaultNumberOfRepairSymbols is used.
+	NumberOfRepairSymbols uint8
+	// The number of FEC Groups that the rQUIC redundancy controller fills in turn.
+	// If zero, protocol.DefaultNumberOfInterleavedFECGroups is used.
+	NumberOfInterleavedFECGroups uint8
+	// The number of source symbols sent between two repair steps of a convolutional FEC scheme, with the rQUIC
+	// redundancy controller. If zero, protocol.DefaultConvolutionalStepSize is used.
+	ConvolutionalStepSize uint8
+	// The number of source symbols in the encoding window of a convolutional FEC scheme, up to protocol.MaxFECWindowSize.
+	// If zero, the window holds the number of source symbols asked by the redundancy controller.
+	ConvolutionalWindowSize uint16
+	// The redundancy controllers of the FEC protection levels set with Stream.SetFECProtection, shared by all the paths.
+	// The levels without a controller use the one of fec.NewFECProtectionLevelRedundancyController.
+	// They are not used when the FEC scheme negotiated for sending is convolutional, see Stream.SetFECProtection.
+	FECProtectionLevels map[FECProtectionLevel]fec.RedundancyController
+	// The path on which the repair symbols are sent
+	FECPathPolicy FECPathPolicy
+	// The maximum number of bytes of source and repair symbols that the FEC receiver of a session buffers to recover
+	// lost packets. The oldest FEC groups are given up when it is exceeded.
+	// If zero, protocol.DefaultMaxFECReceiveBufferSize is used.
+	MaxFECReceiveBufferSize uint64
 	// If set to true, recovered frames will bew sent when source symbols are recovered
 	DisableFECRecoveredFrames bool
 
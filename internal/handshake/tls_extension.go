@@ -18,6 +18,7 @@ const (
 	statelessResetTokenParameterID
 	maxPathIDParameterID
 	fecSchemeParameterID
+	supportedFECSchemesParameterID
 )
 
 type transportParameter struct {
@@ -29,6 +29,22 @@ var _ = Describe("Transport Parameters", func() {
 				Expect(params.MaxPathID).To(Equal(protocol.PathID(3)))
 			})
 
+			It("reads the FEC schemes", func() {
+				values := map[Tag][]byte{TagFSOP: {0x2, 0, 0, 0, 0x2, 0x1, 0x0}}
+				params, err := readHelloMap(values)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(params.FECScheme).To(Equal(protocol.RLCFECScheme))
+				Expect(params.SupportedFECSchemes).To(Equal([]protocol.FECSchemeID{protocol.RLCFECScheme, protocol.ReedSolomonFECScheme, protocol.XORFECScheme}))
+			})
+
+			It("reads the FEC scheme of peers that do not advertise the supported FEC schemes", func() {
+				values := map[Tag][]byte{TagFSOP: {0x1, 0, 0, 0}}
+				params, err := readHelloMap(values)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(params.FECScheme).To(Equal(protocol.ReedSolomonFECScheme))
+				Expect(params.SupportedFECSchemes).To(BeEmpty())
+			})
+
 			It("reads if the connection ID should be omitted", func() {
 				values := map[Tag][]byte{TagTCID: {0, 0, 0, 0}}
 				params, err := readHelloMap(values)
@@ -105,6 +121,15 @@ var _ = Describe("Transport Parameters", func() {
 				Expect(entryMap).To(HaveKeyWithValue(TagFSOP, []byte{0x1, 0, 0, 0}))
 			})
 
+			It("advertises the supported FEC schemes after the preferred one", func() {
+				params := &TransportParameters{
+					FECScheme:           protocol.RLCFECScheme,
+					SupportedFECSchemes: []protocol.FECSchemeID{protocol.XORFECScheme, protocol.RLCFECScheme},
+				}
+				entryMap := params.getHelloMap()
+				Expect(entryMap).To(HaveKeyWithValue(TagFSOP, []byte{0x2, 0, 0, 0, 0x0, 0x2}))
+			})
+
 			It("requests omission of the connection ID", func() {
 				params := &TransportParameters{OmitConnectionID: true}
 				entryMap := params.getHelloMap()
@@ -220,6 +245,15 @@ var _ = Describe("Transport Parameters", func() {
 				Expect(err).To(MatchError("wrong length for max_path_id: 3 (expected 1)"))
 			})
 
+			It("reads the supported FEC schemes", func() {
+				parameters[fecSchemeParameterID] = []byte{protocol.RLCFECScheme}
+				parameters[supportedFECSchemesParameterID] = []byte{protocol.XORFECScheme, protocol.RLCFECScheme}
+				params, err := readTransportParamters(paramsMapToList(parameters))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(params.FECScheme).To(Equal(protocol.RLCFECScheme))
+				Expect(params.SupportedFECSchemes).To(Equal([]protocol.FECSchemeID{protocol.XORFECScheme, protocol.RLCFECScheme}))
+			})
+
 			It("ignores unknown parameters", func() {
 				parameters[1337] = []byte{42}
 				_, err := readTransportParamters(paramsMapToList(parameters))
@@ -260,6 +294,13 @@ var _ = Describe("Transport Parameters", func() {
 				Expect(values).To(HaveKeyWithValue(fecSchemeParameterID, []byte{protocol.ReedSolomonFECScheme}))
 			})
 
+			It("advertises the supported FEC schemes", func() {
+				params.SupportedFECSchemes = []protocol.FECSchemeID{protocol.XORFECScheme, protocol.ReedSolomonFECScheme}
+				values := paramsListToMap(params.getTransportParameters())
+				Expect(values).To(HaveLen(8))
+				Expect(values).To(HaveKeyWithValue(supportedFECSchemesParameterID, []byte{protocol.XORFECScheme, protocol.ReedSolomonFECScheme}))
+			})
+
 			It("request ommision of the connection ID", func() {
 				params.OmitConnectionID = true
 				values := paramsListToMap(params.getTransportParameters())
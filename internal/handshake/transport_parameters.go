@@ -29,6 +29,8 @@ type TransportParameters struct {
 	MaxPathID protocol.PathID
 
 	FECScheme protocol.FECSchemeID
+	// SupportedFECSchemes are the FEC schemes that can be negotiated if the peer does not support FECScheme
+	SupportedFECSchemes []protocol.FECSchemeID
 
 	CacheHandshake bool
 }
@@ -79,11 +81,16 @@ func readHelloMap(tags map[Tag][]byte) (*TransportParameters, error) {
 		params.MaxPathID = protocol.PathID(v)
 	}
 	if value, ok := tags[TagFSOP]; ok {
-		v, err := utils.LittleEndian.ReadUint32(bytes.NewBuffer(value))
+		b := bytes.NewBuffer(value)
+		v, err := utils.LittleEndian.ReadUint32(b)
 		if err != nil {
 			return nil, errMalformedTag
 		}
 		params.FECScheme = protocol.FECSchemeID(v)
+		// the supported FEC schemes follow the preferred one, one byte each
+		if b.Len() > 0 {
+			params.SupportedFECSchemes = append([]protocol.FECSchemeID{}, b.Bytes()...)
+		}
 	}
 	return params, nil
 }
@@ -102,6 +109,7 @@ func (p *TransportParameters) getHelloMap() map[Tag][]byte {
 	utils.LittleEndian.WriteUint32(mpid, uint32(p.MaxPathID))
 	fsopt := bytes.NewBuffer([]byte{})
 	utils.LittleEndian.WriteUint32(fsopt, uint32(p.FECScheme))
+	fsopt.Write(p.SupportedFECSchemes)
 
 	tags := map[Tag][]byte{
 		TagICSL: icsl.Bytes(),
@@ -167,6 +175,8 @@ func readTransportParamters(paramsList []transportParameter) (*TransportParamete
 				return nil, fmt.Errorf("wrong length for fec_scheme_parameter_id: %d (expected 1)", len(p.Value))
 			}
 			params.FECScheme = protocol.FECSchemeID(p.Value[0])
+		case supportedFECSchemesParameterID:
+			params.SupportedFECSchemes = append([]protocol.FECSchemeID{}, p.Value...)
 		}
 	}
 
@@ -204,5 +214,8 @@ func (p *TransportParameters) getTransportParameters() []transportParameter {
 	if p.OmitConnectionID {
 		params = append(params, transportParameter{omitConnectionIDParameterID, []byte{}})
 	}
+	if len(p.SupportedFECSchemes) > 0 {
+		params = append(params, transportParameter{supportedFECSchemesParameterID, p.SupportedFECSchemes})
+	}
 	return params
 }
@@ -141,6 +141,18 @@ func (mr *MockStreamIMockRecorder) GetDataForWriting(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataForWriting", reflect.TypeOf((*MockStreamI)(nil).GetDataForWriting), arg0)
 }
 
+// GetFECProtection mocks base method
+func (m *MockStreamI) GetFECProtection() protocol.FECProtectionLevel {
+	ret := m.ctrl.Call(m, "GetFECProtection")
+	ret0, _ := ret[0].(protocol.FECProtectionLevel)
+	return ret0
+}
+
+// GetFECProtection indicates an expected call of GetFECProtection
+func (mr *MockStreamIMockRecorder) GetFECProtection() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFECProtection", reflect.TypeOf((*MockStreamI)(nil).GetFECProtection))
+}
+
 // GetMessageMode mocks base method
 func (m *MockStreamI) GetMessageMode() bool {
 	ret := m.ctrl.Call(m, "GetMessageMode")
@@ -306,6 +318,16 @@ func (mr *MockStreamIMockRecorder) SetDeadline(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeadline", reflect.TypeOf((*MockStreamI)(nil).SetDeadline), arg0)
 }
 
+// SetFECProtection mocks base method
+func (m *MockStreamI) SetFECProtection(arg0 protocol.FECProtectionLevel) {
+	m.ctrl.Call(m, "SetFECProtection", arg0)
+}
+
+// SetFECProtection indicates an expected call of SetFECProtection
+func (mr *MockStreamIMockRecorder) SetFECProtection(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECProtection", reflect.TypeOf((*MockStreamI)(nil).SetFECProtection), arg0)
+}
+
 // SetMessageMode mocks base method
 func (m *MockStreamI) SetMessageMode(arg0 bool) {
 	m.ctrl.Call(m, "SetMessageMode", arg0)
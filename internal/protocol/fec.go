@@ -1,5 +1,7 @@
 package protocol
 
+import "time"
+
 // A StreamID in QUIC
 type FecFrameLength uint32
 type FecFrameOffset uint8
@@ -7,10 +9,19 @@ type FecFrameOffset uint8
 type FECPayloadID uint64
 
 const MaxNumberOfFecPackets uint32 = 32
-const MaxFECWindowSize = ^uint8(0)
+
+// MaxFECWindowSize is the maximum number of source symbols in the encoding window of a convolutional FEC scheme
+const MaxFECWindowSize uint16 = 0xFFF
 
 const DEBUG_RETRANSMIT_UNRELIABLE bool = false
 
+// DeadlineFECResidualLoss is the ratio of the unreliable stream data that may miss its deadline despite the repair
+// symbols flushed for it
+const DeadlineFECResidualLoss = 0.01
+
+// MaxDeadlineRepairSymbols is the maximum number of repair symbols flushed to protect unreliable stream data before its deadline
+const MaxDeadlineRepairSymbols = 8
+
 const MAX_RECOVERED_PACKETS_IN_ONE_ROW = 32
 
 const PROTECT_RELIABLE_STREAM_FRAMES = true
@@ -23,20 +34,52 @@ const APPLY_CONGESTION_CONTROL_ON_UNRELIABLE_STREAMS = true
 
 const APPLY_CONGESTION_CONTROL = true
 
-const USE_FEC_DEDICATED_PATH = true
-
 type FECSchemeID = byte
 
 const XORFECScheme FECSchemeID = 0
 const ReedSolomonFECScheme FECSchemeID = 1
 const RLCFECScheme FECSchemeID = 2
+const RLCRFC8681FECScheme FECSchemeID = 3
+const RaptorQFECScheme FECSchemeID = 4
+const RLCGF65536FECScheme FECSchemeID = 5
+const XOR2DFECScheme FECSchemeID = 6
+
+// A FECProtectionLevel is the FEC protection class of the data of a stream. The stream frames of different levels
+// are protected by different FEC containers, with the redundancy of their level.
+type FECProtectionLevel uint8
+
+const (
+	// FECProtectionDefault protects the stream frames if the stream is unreliable or if the session protects
+	// the reliable stream frames, with the redundancy controller of the path
+	FECProtectionDefault FECProtectionLevel = iota
+	// FECProtectionNone never protects the stream frames
+	FECProtectionNone
+	// FECProtectionLow protects the stream frames with a low redundancy, e.g. for bulk data
+	FECProtectionLow
+	// FECProtectionMedium protects the stream frames with a medium redundancy, e.g. for audio
+	FECProtectionMedium
+	// FECProtectionHigh protects the stream frames with a high redundancy, e.g. for video key frames
+	FECProtectionHigh
+)
 
-var (
-	NumberOfFecPackets           uint32 = 4
-	NumberOfRepairSymbols        uint8  = 1
-	NumberOfInterleavedFECGroups uint   = 1
-	ConvolutionalWindowSize      uint8  = 20
-	ConvolutionalStepSize        int    = 6
+// DefaultMaxFECReceiveBufferSize is the maximum number of bytes of source and repair symbols buffered by the FEC
+// receiver of a session, if it is not set in the quic.Config. It holds the largest convolutional encoding windows.
+const DefaultMaxFECReceiveBufferSize ByteCount = 16 * (1 << 20)
+
+// MaxFECReceiveBufferAge is the maximum time during which a FEC receiver buffers symbols to recover packets
+const MaxFECReceiveBufferAge = 10 * time.Second
+
+// The parameters of the default redundancy controller of a session, if they are not set in the quic.Config
+const (
+	// DefaultNumberOfFECPackets is the initial number of source symbols protected together. It is the RCInit with which
+	// the rQUIC redundancy controller of the sessions always started, the former NumberOfFecPackets of 4 being ignored by it.
+	DefaultNumberOfFECPackets uint8 = 10
+	// DefaultNumberOfRepairSymbols is the number of repair symbols sent for each FEC Group or window step
+	DefaultNumberOfRepairSymbols uint8 = 1
+	// DefaultNumberOfInterleavedFECGroups is the number of FEC Groups that are filled in turn
+	DefaultNumberOfInterleavedFECGroups uint8 = 1
+	// DefaultConvolutionalStepSize is the number of source symbols sent between two repair steps of a convolutional FEC scheme
+	DefaultConvolutionalStepSize uint8 = 2
 )
 
 var Total = make(map[string]uint64)
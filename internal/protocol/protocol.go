@@ -105,3 +105,13 @@ const MaxClientHellos = 3
 
 // add by zhaolee
 const MaxTrackedReceivedSymbolAckRanges = 2000
+
+// MaxSymbolAckRanges is the maximum number of symbol ranges sent in a SymbolAckFrame
+const MaxSymbolAckRanges = 32
+
+// SourceSymbolsBeforeSymbolAck is the number of new source symbols received with a convolutional FEC scheme before
+// sending a SymbolAckFrame. A SymbolAckFrame is also sent when new repair symbols are received.
+const SourceSymbolsBeforeSymbolAck = 16
+
+// MaxFECBlockStatuses is the maximum number of FEC block statuses sent in a SymbolAckFrame
+const MaxFECBlockStatuses = 32
@@ -9,10 +9,12 @@ type PacketsRingBuffer interface {
 	IsFull() bool
 	CurrentSize() int
 	GetAll() [][]byte
+	// discards the n oldest packets
+	RemoveOldest(n int)
 }
 
 type packetsRingBuffer struct {
-	array [protocol.MaxFECWindowSize][]byte
+	array [][]byte
 	startIndex  int
 	currentSize int
 	maxSize			int
@@ -20,8 +22,12 @@ type packetsRingBuffer struct {
 
 var _ PacketsRingBuffer = &packetsRingBuffer{}
 
-func NewPacketsRingBuffer(maxSize uint8) *packetsRingBuffer {
+func NewPacketsRingBuffer(maxSize uint16) *packetsRingBuffer {
+	if maxSize > protocol.MaxFECWindowSize {
+		maxSize = protocol.MaxFECWindowSize
+	}
 	return &packetsRingBuffer{
+		array:   make([][]byte, Max(int(maxSize), 1)),
 		maxSize: int(maxSize),
 	}
 }
@@ -61,4 +67,13 @@ func (b *packetsRingBuffer) GetAll() [][]byte {
 		}
 	}
 	return retVal
-}
\ No newline at end of file
+}
+
+func (b *packetsRingBuffer) RemoveOldest(n int) {
+	n = Min(n, b.currentSize)
+	for i := 0; i < n; i++ {
+		b.array[(b.startIndex+i)%len(b.array)] = nil
+	}
+	b.startIndex = (b.startIndex + n) % len(b.array)
+	b.currentSize -= n
+}
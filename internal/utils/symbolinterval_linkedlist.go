@@ -19,7 +19,7 @@ func (e *SymbolIntervalElement) Next() *SymbolIntervalElement {
 }
 
 func (e *SymbolIntervalElement) Prev() *SymbolIntervalElement {
-	if p := e.prev; e.list != nil && p != &p.list.root {
+	if p := e.prev; e.list != nil && p != &e.list.root {
 		return p
 	}
 	return nil
@@ -82,7 +82,7 @@ func (l *SymbolIntervalList) insertValue(v SymbolTnterval, at *SymbolIntervalEle
 	return l.insert(&SymbolIntervalElement{Value: v}, at)
 }
 
-func (l SymbolIntervalList) remove(e *SymbolIntervalElement) *SymbolIntervalElement {
+func (l *SymbolIntervalList) remove(e *SymbolIntervalElement) *SymbolIntervalElement {
 	e.prev.next = e.next
 	e.next.prev = e.prev
 	e.next = nil
@@ -43,8 +43,23 @@ type FECFrame struct {
 	//   应该是指这个frame对应的Symbol的情况，(n,k)的k
 	NumberOfRepairSymbols byte
 	Data                  []byte // payload part of this frame
+
+	// RepairFECPayloadID is the Repair FEC Payload ID defined by the FEC scheme, written instead of the FEC-QUIC one
+	// in convolutional FEC frames if set
+	RepairFECPayloadID RepairFECPayloadID
+}
+
+// A RepairFECPayloadID is a Repair FEC Payload ID defined by a convolutional FEC scheme. It is written on 8 bytes,
+// like the FEC-QUIC one, and gives the FEC scheme specific field and the encoding symbol ID of the repair symbol.
+type RepairFECPayloadID interface {
+	Write(b *bytes.Buffer) error
+	GetFECSchemeSpecific() uint32
+	GetEncodingSymbolID() protocol.FECEncodingSymbolID
 }
 
+// A RepairFECPayloadIDParser parses the Repair FEC Payload IDs of a convolutional FEC scheme
+type RepairFECPayloadIDParser func(r *bytes.Reader) (RepairFECPayloadID, error)
+
 var _ Frame = &FECFrame{}
 
 var (
@@ -56,6 +71,12 @@ var (
 
 // ParseStreamFrame reads a stream frame. The type byte must not have been read yet.
 func ParseFECFrame(r *bytes.Reader, version protocol.VersionNumber) (*FECFrame, error) {
+	return ParseFECFrameWithRepairFECPayloadID(r, version, nil)
+}
+
+// ParseFECFrameWithRepairFECPayloadID reads a FEC frame whose convolutional Repair FEC Payload ID is parsed by
+// parseRepairFECPayloadID, or is the FEC-QUIC one if parseRepairFECPayloadID is nil. The type byte must not have been read yet.
+func ParseFECFrameWithRepairFECPayloadID(r *bytes.Reader, version protocol.VersionNumber, parseRepairFECPayloadID RepairFECPayloadIDParser) (*FECFrame, error) {
 	frame := &FECFrame{}
 	// 第一个字节是ByteType
 	_, err := r.ReadByte()
@@ -77,20 +98,29 @@ func ParseFECFrame(r *bytes.Reader, version protocol.VersionNumber) (*FECFrame,
 	// 其余31位
 	frame.DataLength = protocol.FecFrameLength(finBitConvolutionalAndFrameLength >> 2)
 
-	// 再读8字节，是FECPayLoadID
-	fpiddata, err := utils.BigEndian.ReadUint64(r)
-	if err != nil {
-		return nil, err
-	}
+	if frame.Convolutional && parseRepairFECPayloadID != nil {
+		frame.RepairFECPayloadID, err = parseRepairFECPayloadID(r)
+		if err != nil {
+			return nil, err
+		}
+		frame.FECSchemeSpecific = frame.RepairFECPayloadID.GetFECSchemeSpecific()
+		frame.EncodingSymbolID = frame.RepairFECPayloadID.GetEncodingSymbolID()
+	} else {
+		// 再读8字节，是FECPayLoadID
+		fpiddata, err := utils.BigEndian.ReadUint64(r)
+		if err != nil {
+			return nil, err
+		}
 
-	fpid := protocol.FECPayloadID(fpiddata)
+		fpid := protocol.FECPayloadID(fpiddata)
 
-	frame.FECSchemeSpecific = fpid.GetFECSchemeSpecific()
-	if frame.Convolutional {
-		frame.EncodingSymbolID = fpid.GetConvolutionalEncodingSymbolID()
-	} else {
-		frame.FECBlockNumber = fpid.GetBlockNumber()
-		frame.RepairSymbolNumber = fpid.GetBlockSymbolNumber()
+		frame.FECSchemeSpecific = fpid.GetFECSchemeSpecific()
+		if frame.Convolutional {
+			frame.EncodingSymbolID = fpid.GetConvolutionalEncodingSymbolID()
+		} else {
+			frame.FECBlockNumber = fpid.GetBlockNumber()
+			frame.RepairSymbolNumber = fpid.GetBlockSymbolNumber()
+		}
 	}
 
 	offset, err := r.ReadByte()
@@ -152,15 +182,21 @@ func (f FECFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
 	lengthFinBitAndConvolutional |= uint16(f.DataLength) << 2
 	utils.BigEndian.WriteUint16(b, lengthFinBitAndConvolutional)
 
-	var fpid protocol.FECPayloadID
-
-	if f.Convolutional {
-		fpid = protocol.NewConvolutionalRepairFECPayloadID(f.FECSchemeSpecific, f.EncodingSymbolID)
+	if f.Convolutional && f.RepairFECPayloadID != nil {
+		if err := f.RepairFECPayloadID.Write(b); err != nil {
+			return err
+		}
 	} else {
-		fpid = protocol.NewBlockRepairFECPayloadID(f.FECSchemeSpecific, protocol.FECBlockNumber(f.FECBlockNumber), f.RepairSymbolNumber)
-	}
+		var fpid protocol.FECPayloadID
 
-	utils.BigEndian.WriteUint64(b, uint64(fpid))
+		if f.Convolutional {
+			fpid = protocol.NewConvolutionalRepairFECPayloadID(f.FECSchemeSpecific, f.EncodingSymbolID)
+		} else {
+			fpid = protocol.NewBlockRepairFECPayloadID(f.FECSchemeSpecific, protocol.FECBlockNumber(f.FECBlockNumber), f.RepairSymbolNumber)
+		}
+
+		utils.BigEndian.WriteUint64(b, uint64(fpid))
+	}
 
 	b.WriteByte(uint8(f.Offset))
 	if f.Offset == 0 {
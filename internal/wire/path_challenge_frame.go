@@ -0,0 +1,42 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// A PathChallengeFrame is sent on a new path to check that the peer can receive on it
+type PathChallengeFrame struct {
+	Data [8]byte
+}
+
+// Write writes a PATH_CHALLENGE frame
+func (f *PathChallengeFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
+	b.WriteByte(0x14)
+	b.Write(f.Data[:])
+	return nil
+}
+
+// MinLength of a written frame
+func (f *PathChallengeFrame) MinLength(version protocol.VersionNumber) (protocol.ByteCount, error) {
+	return 1 + 8, nil
+}
+
+// ParsePathChallengeFrame parses a PATH_CHALLENGE frame
+func ParsePathChallengeFrame(r *bytes.Reader, version protocol.VersionNumber) (*PathChallengeFrame, error) {
+	frame := &PathChallengeFrame{}
+
+	// read the TypeByte
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, frame.Data[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
@@ -21,6 +21,8 @@ type StreamFrame struct {
 	Unreliable				 bool
 	RetransmitDeadline				 time.Duration
 	TimeSent			 time.Time
+	// The FEC protection level of the stream, not sent on the wire
+	FECProtectionLevel protocol.FECProtectionLevel
 }
 
 var (
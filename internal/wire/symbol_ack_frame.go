@@ -19,8 +19,37 @@ var (
 	errInconsistentLargestSymbolAck = errors.New("internal inconsistency: LargestSymbol does not match Symbol ranges")
 	errInconsistentLowestSymbolAck  = errors.New("internal inconsistency: LowestSymbol does not match Symbol ranges")
 	errInvalidTypeByte              = errors.New("Invalid SymBolACKFrame bytetype")
+	errInvalidFECBlockStatus        = errors.New("SymbolFrame: invalid FEC block status")
+	errTooManySymbolAckEntries      = errors.New("SymbolFrame: too many Symbol ranges or FEC block statuses")
 )
 
+const (
+	symbolAckTypeByte byte = 0x40
+	// set in the ACK type byte when the frame carries Symbol ranges
+	symbolAckHasRangesFlag byte = 0x01
+	// set in the ACK type byte when the frame carries FEC block statuses
+	symbolAckHasBlockStatusesFlag byte = 0x02
+)
+
+// FECBlockDecodingStatus is the outcome of the decoding of a FEC block by the receiver
+type FECBlockDecodingStatus uint8
+
+const (
+	// FECBlockComplete means that all the source symbols of the block have been received
+	FECBlockComplete FECBlockDecodingStatus = iota + 1
+	// FECBlockRecovered means that the missing source symbols of the block have been recovered
+	FECBlockRecovered
+	// FECBlockFailed means that the block has been given up with missing source symbols
+	FECBlockFailed
+)
+
+// A FECBlockStatus reports the decoding of a FEC block
+type FECBlockStatus struct {
+	FECBlockNumber           protocol.FECBlockNumber
+	Status                   FECBlockDecodingStatus
+	NumberOfRecoveredPackets uint8
+}
+
 type SymbolAckRange AckRange
 
 var SymbolAckFrameTypeByte byte = 0x13
@@ -29,6 +58,11 @@ var SymbolAckFrameTypeByte byte = 0x13
 type SymbolAckFrame struct {
 	// We just need one parameter to show how many symbols have been accepted.
 	SymbolReceived protocol.NumberOfAckedSymbol
+	// SymbolRanges are the encoding symbol IDs received or recovered with a convolutional FEC scheme,
+	// the largest first. They must not overlap.
+	SymbolRanges []SymbolRange
+	// BlockStatuses are the FEC blocks decoded since the last frame
+	BlockStatuses []FECBlockStatus
 }
 
 var _ Frame = &SymbolAckFrame{}
@@ -40,6 +74,14 @@ func (s *SymbolAckFrame) MinLength(version protocol.VersionNumber) (protocol.Byt
 	minLength += protocol.ByteCount(1)
 	largestAckedLen := protocol.GetPacketNumberLength(protocol.PacketNumber(s.SymbolReceived))
 	minLength += protocol.ByteCount(largestAckedLen)
+	if len(s.SymbolRanges) > 0 {
+		// number of ranges, then the last symbol and the length of every range
+		minLength += 1 + protocol.ByteCount(len(s.SymbolRanges))*8
+	}
+	if len(s.BlockStatuses) > 0 {
+		// number of statuses, then the block number, the status and the number of recovered packets of every block
+		minLength += 1 + protocol.ByteCount(len(s.BlockStatuses))*6
+	}
 
 	return minLength, nil
 }
@@ -49,8 +91,21 @@ func (s *SymbolAckFrame) Write(b *bytes.Buffer, version protocol.VersionNumber)
 	typeByte := uint8(0x13)
 	b.WriteByte(typeByte)
 
+	if len(s.SymbolRanges) > 0xFF || len(s.BlockStatuses) > 0xFF {
+		return errTooManySymbolAckEntries
+	}
+	if err := s.validateSymbolRanges(); err != nil {
+		return err
+	}
+
 	// write ACK typeByte
-	ACKtypeByte := uint8(0x40)
+	ACKtypeByte := symbolAckTypeByte
+	if len(s.SymbolRanges) > 0 {
+		ACKtypeByte |= symbolAckHasRangesFlag
+	}
+	if len(s.BlockStatuses) > 0 {
+		ACKtypeByte |= symbolAckHasBlockStatusesFlag
+	}
 	b.WriteByte(ACKtypeByte)
 
 	// only in 1,2,4,6
@@ -74,6 +129,38 @@ func (s *SymbolAckFrame) Write(b *bytes.Buffer, version protocol.VersionNumber)
 		utils.GetByteOrder(version).WriteUint48(b, uint64(numOfSymbolsToBeAcked)&(1<<48-1))
 	}
 
+	if len(s.SymbolRanges) > 0 {
+		b.WriteByte(uint8(len(s.SymbolRanges)))
+		for _, r := range s.SymbolRanges {
+			utils.GetByteOrder(version).WriteUint32(b, uint32(r.Last))
+			utils.GetByteOrder(version).WriteUint32(b, uint32(r.Last-r.First))
+		}
+	}
+	if len(s.BlockStatuses) > 0 {
+		b.WriteByte(uint8(len(s.BlockStatuses)))
+		for _, status := range s.BlockStatuses {
+			utils.GetByteOrder(version).WriteUint32(b, uint32(status.FECBlockNumber))
+			b.WriteByte(uint8(status.Status))
+			b.WriteByte(status.NumberOfRecoveredPackets)
+		}
+	}
+
+	return nil
+}
+
+// validateSymbolRanges checks that the Symbol ranges are sorted, the largest first, and don't overlap
+func (s *SymbolAckFrame) validateSymbolRanges() error {
+	for i, r := range s.SymbolRanges {
+		if r.First > r.Last {
+			if i == 0 {
+				return ErrInvalidFirstSymbolAckRange
+			}
+			return ErrInvalidSymbolAckRanges
+		}
+		if i > 0 && r.Last >= s.SymbolRanges[i-1].First {
+			return ErrInvalidSymbolAckRanges
+		}
+	}
 	return nil
 }
 
@@ -89,8 +176,8 @@ func ParseSymbolAckFrame(r *bytes.Reader, version protocol.VersionNumber) (*Symb
 		return nil, errInvalidTypeByte
 	}
 
-	// this should be an ackTypeByte, we don't consider it temporarily.
-	_, err = r.ReadByte()
+	// the flags of the ackTypeByte tell which optional fields follow the number of symbols
+	ackTypeByte, err := r.ReadByte()
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +202,60 @@ func ParseSymbolAckFrame(r *bytes.Reader, version protocol.VersionNumber) (*Symb
 	}
 
 	frame.SymbolReceived = protocol.NumberOfAckedSymbol(numOfSymbolsToBeAcked)
+
+	if ackTypeByte&symbolAckHasRangesFlag != 0 {
+		numRanges, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		frame.SymbolRanges = make([]SymbolRange, numRanges)
+		for i := range frame.SymbolRanges {
+			last, err := utils.GetByteOrder(version).ReadUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			length, err := utils.GetByteOrder(version).ReadUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			if length > last {
+				return nil, ErrInvalidSymbolAckRanges
+			}
+			frame.SymbolRanges[i] = SymbolRange{First: protocol.PacketNumber(last - length), Last: protocol.PacketNumber(last)}
+		}
+		if err := frame.validateSymbolRanges(); err != nil {
+			return nil, err
+		}
+	}
+	if ackTypeByte&symbolAckHasBlockStatusesFlag != 0 {
+		numStatuses, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		frame.BlockStatuses = make([]FECBlockStatus, numStatuses)
+		for i := range frame.BlockStatuses {
+			blockNumber, err := utils.GetByteOrder(version).ReadUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			status, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if FECBlockDecodingStatus(status) < FECBlockComplete || FECBlockDecodingStatus(status) > FECBlockFailed {
+				return nil, errInvalidFECBlockStatus
+			}
+			recovered, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			frame.BlockStatuses[i] = FECBlockStatus{
+				FECBlockNumber:           protocol.FECBlockNumber(blockNumber),
+				Status:                   FECBlockDecodingStatus(status),
+				NumberOfRecoveredPackets: recovered,
+			}
+		}
+	}
 	return frame, nil
 
 }
@@ -0,0 +1,99 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SymbolAckFrame", func() {
+	Context("when parsing", func() {
+		It("accepts a frame with only the number of received symbols", func() {
+			b := bytes.NewReader([]byte{0x13, 0x40, 0x1, 0x2a})
+			frame, err := ParseSymbolAckFrame(b, protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.SymbolReceived).To(Equal(protocol.NumberOfAckedSymbol(0x2a)))
+			Expect(frame.SymbolRanges).To(BeEmpty())
+			Expect(frame.BlockStatuses).To(BeEmpty())
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("errors on invalid type bytes", func() {
+			_, err := ParseSymbolAckFrame(bytes.NewReader([]byte{0x12, 0x40, 0x1, 0x2a}), protocol.VersionWhatever)
+			Expect(err).To(MatchError(errInvalidTypeByte))
+		})
+
+		It("errors on invalid FEC block statuses", func() {
+			b := &bytes.Buffer{}
+			frame := &SymbolAckFrame{
+				SymbolReceived: 1,
+				BlockStatuses:  []FECBlockStatus{{FECBlockNumber: 3, Status: FECBlockFailed}},
+			}
+			Expect(frame.Write(b, protocol.VersionWhatever)).To(Succeed())
+			data := b.Bytes()
+			data[len(data)-2] = 0x7
+			_, err := ParseSymbolAckFrame(bytes.NewReader(data), protocol.VersionWhatever)
+			Expect(err).To(MatchError(errInvalidFECBlockStatus))
+		})
+
+		It("errors on EOFs", func() {
+			b := &bytes.Buffer{}
+			frame := &SymbolAckFrame{
+				SymbolReceived: 300,
+				SymbolRanges:   []SymbolRange{{First: 10, Last: 20}, {First: 1, Last: 5}},
+				BlockStatuses:  []FECBlockStatus{{FECBlockNumber: 3, Status: FECBlockRecovered, NumberOfRecoveredPackets: 2}},
+			}
+			Expect(frame.Write(b, protocol.VersionWhatever)).To(Succeed())
+			data := b.Bytes()
+			_, err := ParseSymbolAckFrame(bytes.NewReader(data), protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < len(data); i++ {
+				_, err := ParseSymbolAckFrame(bytes.NewReader(data[0:i]), protocol.VersionWhatever)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("when writing", func() {
+		It("writes a frame with only the number of received symbols", func() {
+			b := &bytes.Buffer{}
+			frame := &SymbolAckFrame{SymbolReceived: 0x2a}
+			Expect(frame.Write(b, protocol.VersionWhatever)).To(Succeed())
+			Expect(b.Bytes()).To(Equal([]byte{0x13, 0x40, 0x1, 0x2a}))
+		})
+
+		It("writes and parses Symbol ranges and FEC block statuses", func() {
+			b := &bytes.Buffer{}
+			frame := &SymbolAckFrame{
+				SymbolReceived: 1000,
+				SymbolRanges:   []SymbolRange{{First: 100, Last: 120}, {First: 42, Last: 42}, {First: 1, Last: 40}},
+				BlockStatuses: []FECBlockStatus{
+					{FECBlockNumber: 7, Status: FECBlockComplete},
+					{FECBlockNumber: 8, Status: FECBlockRecovered, NumberOfRecoveredPackets: 2},
+					{FECBlockNumber: 9, Status: FECBlockFailed},
+				},
+			}
+			Expect(frame.Write(b, protocol.VersionWhatever)).To(Succeed())
+			minLength, err := frame.MinLength(protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(minLength).To(Equal(protocol.ByteCount(b.Len())))
+			r := bytes.NewReader(b.Bytes())
+			parsed, err := ParseSymbolAckFrame(r, protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed).To(Equal(frame))
+			Expect(r.Len()).To(BeZero())
+		})
+
+		It("refuses overlapping Symbol ranges", func() {
+			frame := &SymbolAckFrame{SymbolRanges: []SymbolRange{{First: 10, Last: 20}, {First: 15, Last: 30}}}
+			Expect(frame.Write(&bytes.Buffer{}, protocol.VersionWhatever)).To(MatchError(ErrInvalidSymbolAckRanges))
+		})
+
+		It("refuses an invalid first Symbol range", func() {
+			frame := &SymbolAckFrame{SymbolRanges: []SymbolRange{{First: 20, Last: 10}}}
+			Expect(frame.Write(&bytes.Buffer{}, protocol.VersionWhatever)).To(MatchError(ErrInvalidFirstSymbolAckRange))
+		})
+	})
+})
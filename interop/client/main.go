@@ -25,6 +25,7 @@ import (
 var NUMBER_OF_SOURCE_SYMBOLS uint = 20
 var NUMBER_OF_REPAIR_SYMBOLS uint = 10
 var NUMBER_OF_INTERLEAVED_BLOCKS uint = 1
+var CONVOLUTIONAL_STEP_SIZE uint = 6
 var DISABLE_RECOVERED_FRAMES bool = true
 var USE_FEC bool = false
 var RS_WHEN_APPLICATION_LIMITED = false
@@ -76,6 +77,10 @@ func mainClient() {
 		ProtectReliableStreamFrames:       USE_FEC,
 		UseFastRetransmit:                 true,
 		OnlySendFECWhenApplicationLimited: RS_WHEN_APPLICATION_LIMITED,
+		NumberOfFECPackets:                uint8(NUMBER_OF_SOURCE_SYMBOLS),
+		NumberOfRepairSymbols:             uint8(NUMBER_OF_REPAIR_SYMBOLS),
+		NumberOfInterleavedFECGroups:      uint8(NUMBER_OF_INTERLEAVED_BLOCKS),
+		ConvolutionalStepSize:             uint8(CONVOLUTIONAL_STEP_SIZE),
 	}
 
 	hclient := &http.Client{
@@ -130,7 +135,7 @@ func main() {
 	nss := flag.Uint("nss", NUMBER_OF_SOURCE_SYMBOLS, "Default number of Source Symbols (max. 255)")
 	nrs := flag.Uint("nrs", NUMBER_OF_REPAIR_SYMBOLS, "Default number of Repair Symbols (max. 255)")
 	nifg := flag.Uint("nifg", NUMBER_OF_INTERLEAVED_BLOCKS, "Set to 1 (recommended) when no block interleaving is needed. Specifies the number of FEC blocks to interleave to handle loss bursts for weak codes such as XOR. (max. 255)")
-	css := flag.Uint("css", uint(protocol.ConvolutionalStepSize), "Step size of the convolutional window for convolutional codes")
+	css := flag.Uint("css", CONVOLUTIONAL_STEP_SIZE, "Step size of the convolutional window for convolutional codes")
 	norf := flag.Bool("no-rf", false, "Use this flag to prevent the receiver from sending recovered frames")
 	nofec := flag.Bool("no-fec", false, "Use this flag to prevent the sender from sending Repair Symbols")
 	eos := flag.Bool("eos", false, "Use this flag to only send Repair Symbols to only send FEC Frames when application-limited")
@@ -153,7 +158,7 @@ func main() {
 	DISABLE_RECOVERED_FRAMES = *norf
 	USE_FEC = !*nofec
 	RS_WHEN_APPLICATION_LIMITED = *eos
-	protocol.ConvolutionalStepSize = int(*css)
+	CONVOLUTIONAL_STEP_SIZE = *css
 
 	// certPath = *cp
 	// www = *w
@@ -184,10 +189,6 @@ func main() {
 		log.Printf("RLC")
 	}
 
-	protocol.NumberOfFecPackets = uint32(NUMBER_OF_SOURCE_SYMBOLS)
-	protocol.NumberOfRepairSymbols = uint8(NUMBER_OF_REPAIR_SYMBOLS)
-	protocol.NumberOfInterleavedFECGroups = NUMBER_OF_INTERLEAVED_BLOCKS
-
 	mainClient()
 
 }
@@ -59,7 +59,7 @@ func (sch *lossBasedScheduler) selectPath(s sessionI, hasRetransmission bool, ha
 		return 1.0/math.Max(packetsBetweenTwoLosses, currentPacketsSinceLastLost) // MPTCP LAMPS
 	}
 
-	paths := s.Paths()
+	paths := s.GetPaths()
 	// XXX Avoid using PathID 0 if there is more than 1 path
 	if len(paths) <= 1 {
 		if !hasRetransmission && !paths[protocol.InitialPathID].SendingAllowed() {
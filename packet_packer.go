@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"time"
 
 	"github.com/lucas-clemente/quic-go/ackhandler"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
@@ -25,6 +26,8 @@ type packedPacket struct {
 	containsUnreliableStreamFrames bool
 	fecFlag                        bool
 	fecPayloadID                   protocol.FECPayloadID
+	// copies links the packet to its copies sent on other paths by the Redundant multipath service
+	copies *ackhandler.PacketCopies
 }
 
 // 打包器
@@ -84,6 +87,26 @@ func (p *packetPacker) PackConnectionClose(ccf *wire.ConnectionCloseFrame, pth *
 	}, err
 }
 
+// PackPathValidationPacket packs a packet that ONLY contains a PathChallengeFrame or a PathResponseFrame.
+// Such frames must be sent on the path they validate, so they don't go through the control frame queue.
+func (p *packetPacker) PackPathValidationPacket(frame wire.Frame, pth *path) (*packedPacket, error) {
+	switch frame.(type) {
+	case *wire.PathChallengeFrame, *wire.PathResponseFrame:
+	default:
+		return nil, errors.New("PacketPacker BUG: not a path validation frame")
+	}
+	frames := []wire.Frame{frame}
+	encLevel, sealer := p.cryptoSetup.GetSealer()
+	header := p.getHeader(encLevel, pth)
+	raw, err := p.writeAndSealPacket(header, frames, sealer, pth)
+	return &packedPacket{
+		header:          header,
+		raw:             raw,
+		frames:          frames,
+		encryptionLevel: encLevel,
+	}, err
+}
+
 // PackPing packs a packet that ONLY contains a PingFrame
 // Ping帧的打包,一个帧构成一个包;在控制帧前面加上这个ping帧,然后根据pth和pid打包数据包
 func (p *packetPacker) PackPing(pf *wire.PingFrame, pth *path) (*packedPacket, error) {
@@ -150,6 +173,53 @@ func (p *packetPacker) PackHandshakeRetransmission(packet *ackhandler.Packet, pt
 	}, err
 }
 
+// PackDuplicatePacket packs a copy of a forward-secure packet that was sent on another path.
+// ACK, STOP_WAITING and FEC frames are not copied, and the copy is not FEC protected.
+// It returns nil if there is nothing to copy or if the copy wouldn't fit in a packet on pth.
+func (p *packetPacker) PackDuplicatePacket(packet *ackhandler.Packet, pth *path) (*packedPacket, error) {
+	if packet.EncryptionLevel != protocol.EncryptionForwardSecure {
+		return nil, errors.New("PacketPacker BUG: only forward-secure packets can be duplicated")
+	}
+	encLevel, sealer := p.cryptoSetup.GetSealer()
+	if encLevel != protocol.EncryptionForwardSecure {
+		return nil, nil
+	}
+	header := p.getHeader(encLevel, pth)
+	length, err := header.GetLength(p.perspective, p.version)
+	if err != nil {
+		return nil, err
+	}
+	length += protocol.ByteCount(sealer.Overhead())
+	var frames []wire.Frame
+	for _, frame := range packet.GetFramesForRetransmission() {
+		if _, isFEC := frame.(*wire.FECFrame); isFEC {
+			continue
+		}
+		l, err := frame.MinLength(p.version)
+		if err != nil {
+			return nil, err
+		}
+		if sf, ok := frame.(*wire.StreamFrame); ok {
+			l += sf.DataLen()
+		}
+		length += l
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 || length > protocol.MaxPacketSize {
+		return nil, nil
+	}
+	raw, err := p.writeAndSealPacket(header, frames, sealer, pth)
+	if err != nil {
+		return nil, err
+	}
+	return &packedPacket{
+		header:          header,
+		raw:             raw,
+		frames:          frames,
+		encryptionLevel: encLevel,
+	}, nil
+}
+
 // PackPacket packs a new packet
 // the other controlFrames are sent in the next packet, but might be queued and sent in the next packet if the packet would overflow MaxPacketSize otherwise
 //
@@ -224,7 +294,7 @@ func (p *packetPacker) PackPacket(pth *path, sourceFECPayloadID protocol.FECPayl
 	// added by michelfra: check if packet contains only FEC frames (if yes, no FEC frame has to be generated for it)
 	containsOnlyFECFrames := len(payloadFrames) > 0
 	containsUnreliableStreamFrames := false
-	containsStreamFrames := false
+	containsFECProtectedStreamFrames := false
 
 	for _, frame := range payloadFrames {
 		switch f := frame.(type) {
@@ -234,16 +304,23 @@ func (p *packetPacker) PackPacket(pth *path, sourceFECPayloadID protocol.FECPayl
 			if f.Unreliable {
 				containsUnreliableStreamFrames = true
 			}
+			if p.streamFramer.fecProtected(f.FECProtectionLevel, f.Unreliable) {
+				containsFECProtectedStreamFrames = true
+			}
 			containsOnlyFECFrames = false
-			containsStreamFrames = true
 		default:
 			containsOnlyFECFrames = false
 		}
 	}
 
-	if (p.sess.config.ProtectReliableStreamFrames && containsStreamFrames && p.sess.fecFrameworkSender.fecScheme != nil) || containsUnreliableStreamFrames {
+	// the FEC scheme is nil when the peers have no FEC scheme in common: the packets are not protected
+	if containsFECProtectedStreamFrames && p.sess.fecFrameworkSender.fecScheme != nil {
 		header.FECFlag = true
 		header.FECPayloadID = sourceFECPayloadID
+		// the stream frames of the protection levels are protected by the FEC blocks of their level
+		if level := p.fecProtectionLevel(payloadFrames); level != protocol.FECProtectionDefault {
+			header.FECPayloadID = p.sess.fecFrameworkSender.GetNextSourceFECPayloadIDForLevel(header.PathID, level)
+		}
 	}
 
 	// Check if we have enough frames to send
@@ -327,15 +404,18 @@ func (p *packetPacker) composeNextPacket(
 		payloadLength += l
 	}
 
-	// modify: add symbolACKFrame for next packet
-	symbolACKFrame := p.sess.fecFrameworkReceiver.GetSymbolACKFrame()
-	if symbolACKFrame != nil {
-		payloadFrames = append(payloadFrames, symbolACKFrame)
-		l, err := symbolACKFrame.MinLength(p.version)
-		if err != nil {
-			return nil, err
+	// modify: add symbolACKFrame for next packet, if it fits
+	if payloadLength < maxFrameSize {
+		if symbolACKFrame := p.sess.getSymbolACKFrame(maxFrameSize - payloadLength); symbolACKFrame != nil {
+			l, err := symbolACKFrame.MinLength(p.version)
+			if err != nil {
+				return nil, err
+			}
+			if payloadLength+l <= maxFrameSize {
+				payloadFrames = append(payloadFrames, symbolACKFrame)
+				payloadLength += l
+			}
 		}
-		payloadLength += l
 	}
 
 	// 逐个取出所有的控制帧并添加到payLoadFrame中
@@ -407,8 +487,8 @@ func (p *packetPacker) composeNextPacket(
 		p.streamFramer.currentRTT = pth.rttStats.SmoothedRTT()
 		fs := p.streamFramer.PopStreamFrames(maxFrameSize-payloadLength, FECProtectionOverhead)
 		if len(fs) != 0 {
-			if !p.sess.config.ProtectReliableStreamFrames && !fs[len(fs)-1].Unreliable {
-				fs[len(fs)-1].DataLenPresent = false
+			if last := fs[len(fs)-1]; !p.streamFramer.fecProtected(last.FECProtectionLevel, last.Unreliable) {
+				last.DataLenPresent = false
 			}
 		}
 
@@ -545,10 +625,14 @@ func (p *packetPacker) writeAndSealPacket(
 	// TODO: there should be an option to send FEC even for other frames than UnreliableStreamFrames, maybe a parameter in the session, or... ?
 	if header.FECFlag {
 		// FEC-protected: give the packet to FEC framework
+		level := p.fecProtectionLevel(payloadFrames)
+		if deadline := minRetransmitDeadline(payloadFrames); deadline > 0 {
+			p.sess.fecFrameworkSender.protectUntilDeadline(header.PathID, level, deadline)
+		}
 		if p.sess.config.OnlySendFECWhenApplicationLimited {
-			p.sess.fecFrameworkSender.HandlePacket(raw, header)
+			p.sess.fecFrameworkSender.HandlePacket(raw, header, level)
 		} else {
-			_, err := p.sess.fecFrameworkSender.HandlePacketAndMaybePushRS(raw, header)
+			_, err := p.sess.fecFrameworkSender.HandlePacketAndMaybePushRS(raw, header, level)
 			if err != nil {
 				return nil, err
 			}
@@ -565,6 +649,30 @@ func (p *packetPacker) writeAndSealPacket(
 	return raw, nil
 }
 
+// fecProtectionLevel returns the FEC protection level of the FEC-protected stream frames, the stream framer never
+// puts frames of different levels in the same packet
+func (p *packetPacker) fecProtectionLevel(frames []wire.Frame) protocol.FECProtectionLevel {
+	for _, frame := range frames {
+		if f, ok := frame.(*wire.StreamFrame); ok && p.streamFramer.fecProtected(f.FECProtectionLevel, f.Unreliable) {
+			return f.FECProtectionLevel
+		}
+	}
+	return protocol.FECProtectionDefault
+}
+
+// minRetransmitDeadline returns the shortest retransmission deadline of the unreliable stream frames, or 0 if none has one
+func minRetransmitDeadline(frames []wire.Frame) time.Duration {
+	var deadline time.Duration
+	for _, frame := range frames {
+		if f, ok := frame.(*wire.StreamFrame); ok && f.Unreliable && f.RetransmitDeadline > 0 {
+			if deadline == 0 || f.RetransmitDeadline < deadline {
+				deadline = f.RetransmitDeadline
+			}
+		}
+	}
+	return deadline
+}
+
 func (p *packetPacker) canSendData(encLevel protocol.EncryptionLevel) bool {
 	if p.perspective == protocol.PerspectiveClient {
 		return encLevel >= protocol.EncryptionSecure
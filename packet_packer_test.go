@@ -107,7 +107,7 @@ var _ = Describe("Packet packer", func() {
 		rc := fec.NewConstantRedundancyController(10, 1, 1, 1)
 		// sess.fecFrameworkSender = NewFECFrameworkSender(&fec.XORFECScheme{}, fec.NewRoundRobinScheduler(rc, protocol.Version39), fecFramer, rc, protocol.Version39)
 		// add by zhaolee
-		sess.fecFrameworkSender = NewFECFrameworkSender(&fec.XORFECScheme{}, fec.NewRoundRobinScheduler(rc, protocol.Version39), fecFramer, rc, protocol.Version39, sess)
+		sess.fecFrameworkSender = NewFECFrameworkSender(&fec.XORFECScheme{}, fecFramer, rc, protocol.Version39, sess)
 		sess.fecFrameworkReceiver = NewFECFrameworkReceiver(sess, &fec.XORFECScheme{})
 		packer = &packetPacker{
 			cryptoSetup:  &mockCryptoSetup{encLevelSeal: protocol.EncryptionForwardSecure},
@@ -157,6 +157,25 @@ var _ = Describe("Packet packer", func() {
 		Expect(p.encryptionLevel).To(Equal(protocol.EncryptionForwardSecure))
 	})
 
+	It("protects the unreliable stream frames with FEC", func() {
+		// the retransmission fills the packet, no new data of the stream is needed
+		streamFramer.AddFrameForRetransmission(&wire.StreamFrame{StreamID: 5, Data: bytes.Repeat([]byte{'f'}, int(maxFrameSize)), Unreliable: true, RetransmitDeadline: time.Hour, TimeSent: time.Now()})
+		p, err := packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.header.FECFlag).To(BeTrue())
+		Expect(packer.sess.fecFrameworkSender.numberOfSourceSymbols.Get()).To(Equal(uint64(1)))
+	})
+
+	It("doesn't protect the unreliable stream frames without a FEC scheme in common with the peer", func() {
+		packer.sess.fecFrameworkSender.fecScheme = nil
+		// the retransmission fills the packet, no new data of the stream is needed
+		streamFramer.AddFrameForRetransmission(&wire.StreamFrame{StreamID: 5, Data: bytes.Repeat([]byte{'f'}, int(maxFrameSize)), Unreliable: true, RetransmitDeadline: time.Hour, TimeSent: time.Now()})
+		p, err := packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.header.FECFlag).To(BeFalse())
+		Expect(packer.sess.fecFrameworkSender.numberOfSourceSymbols.Get()).To(BeZero())
+	})
+
 	Context("generating a packet header", func() {
 		const (
 			versionPublicHeader = protocol.Version39  // a QUIC version that uses the Public Header format
@@ -303,6 +322,64 @@ var _ = Describe("Packet packer", func() {
 		Expect(p.raw).NotTo(BeEmpty())
 	})
 
+	It("packs the decoding statuses of the FEC blocks in a SymbolAckFrame", func() {
+		packer.isInControlFramesQueue = make(map[wire.Frame]bool)
+		packer.sess.fecFrameworkReceiver.addBlockStatus(3, wire.FECBlockRecovered, 2)
+		packer.QueueControlFrame(&wire.RstStreamFrame{}, pth)
+		p, err := packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).ToNot(BeNil())
+		Expect(p.frames).To(ContainElement(&wire.SymbolAckFrame{
+			BlockStatuses: []wire.FECBlockStatus{{FECBlockNumber: 3, Status: wire.FECBlockRecovered, NumberOfRecoveredPackets: 2}},
+		}))
+		// the statuses are only sent once
+		packer.QueueControlFrame(&wire.RstStreamFrame{}, pth)
+		p, err = packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.frames).To(HaveLen(1))
+	})
+
+	It("packs the source symbols received with a convolutional FEC scheme in a SymbolAckFrame", func() {
+		packer.isInControlFramesQueue = make(map[wire.Frame]bool)
+		sess := packer.sess
+		sess.fecFrameworkReceiver = nil
+		sess.fecFrameworkReceiverConvolutional = NewFECFrameworkReceiverConvolutional(sess, fec.NewRandomLinearFECScheme())
+		for _, id := range []protocol.FECEncodingSymbolID{1, 2, 4} {
+			sess.fecFrameworkReceiverConvolutional.receivedSymbol(id)
+		}
+		// the receiver waits for more source symbols or for a new repair symbol before acknowledging
+		p, err := packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(BeNil())
+		sess.fecFrameworkReceiverConvolutional.hasNewRepairSymbols = true
+		packer.QueueControlFrame(&wire.RstStreamFrame{}, pth)
+		p, err = packer.PackPacket(pth, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.frames).To(ContainElement(&wire.SymbolAckFrame{
+			SymbolRanges: []wire.SymbolRange{{First: 4, Last: 4}, {First: 1, Last: 2}},
+		}))
+	})
+
+	It("finds the shortest retransmission deadline of the unreliable stream frames", func() {
+		Expect(minRetransmitDeadline([]wire.Frame{&wire.StreamFrame{RetransmitDeadline: time.Second}})).To(BeZero())
+		Expect(minRetransmitDeadline([]wire.Frame{
+			&wire.AckFrame{},
+			&wire.StreamFrame{Unreliable: true},
+			&wire.StreamFrame{Unreliable: true, RetransmitDeadline: 100 * time.Millisecond},
+			&wire.StreamFrame{Unreliable: true, RetransmitDeadline: 50 * time.Millisecond},
+		})).To(Equal(50 * time.Millisecond))
+	})
+
+	It("finds the FEC protection level of the protected stream frames", func() {
+		Expect(packer.fecProtectionLevel([]wire.Frame{&wire.StreamFrame{}})).To(Equal(protocol.FECProtectionDefault))
+		Expect(packer.fecProtectionLevel([]wire.Frame{
+			&wire.AckFrame{},
+			&wire.StreamFrame{FECProtectionLevel: protocol.FECProtectionNone, Unreliable: true},
+			&wire.StreamFrame{},
+			&wire.StreamFrame{FECProtectionLevel: protocol.FECProtectionHigh},
+		})).To(Equal(protocol.FECProtectionHigh))
+	})
+
 	It("increases the packet number", func() {
 		packer.QueueControlFrame(&wire.RstStreamFrame{}, pth)
 		p1, err := packer.PackPacket(pth, 0)
@@ -806,6 +883,53 @@ var _ = Describe("Packet packer", func() {
 		})
 	})
 
+	Context("duplicating packets", func() {
+		sf := &wire.StreamFrame{
+			StreamID: 5,
+			Data:     []byte("foobar"),
+		}
+
+		It("copies the retransmittable frames", func() {
+			packet := &ackhandler.Packet{
+				EncryptionLevel: protocol.EncryptionForwardSecure,
+				Frames:          []wire.Frame{&wire.AckFrame{}, &wire.StopWaitingFrame{}, &wire.FECFrame{}, &wire.PingFrame{}, sf},
+			}
+			p, err := packer.PackDuplicatePacket(packet, pth)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.frames).To(Equal([]wire.Frame{&wire.PingFrame{}, sf}))
+			Expect(p.header.FECFlag).To(BeFalse())
+			Expect(p.encryptionLevel).To(Equal(protocol.EncryptionForwardSecure))
+		})
+
+		It("doesn't pack packets without retransmittable frames", func() {
+			packet := &ackhandler.Packet{
+				EncryptionLevel: protocol.EncryptionForwardSecure,
+				Frames:          []wire.Frame{&wire.AckFrame{}},
+			}
+			p, err := packer.PackDuplicatePacket(packet, pth)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(BeNil())
+		})
+
+		It("doesn't pack copies that don't fit in a packet", func() {
+			packet := &ackhandler.Packet{
+				EncryptionLevel: protocol.EncryptionForwardSecure,
+				Frames: []wire.Frame{&wire.StreamFrame{
+					StreamID: 5,
+					Data:     bytes.Repeat([]byte{'f'}, int(protocol.MaxPacketSize)),
+				}},
+			}
+			p, err := packer.PackDuplicatePacket(packet, pth)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(BeNil())
+		})
+
+		It("refuses to duplicate packets that were not sent with forward-secure encryption", func() {
+			_, err := packer.PackDuplicatePacket(&ackhandler.Packet{EncryptionLevel: protocol.EncryptionSecure}, pth)
+			Expect(err).To(MatchError("PacketPacker BUG: only forward-secure packets can be duplicated"))
+		})
+	})
+
 	Context("packing ACK packets", func() {
 		It("packs ACK packets", func() {
 			packer.QueueControlFrame(&wire.AckFrame{}, pth)
@@ -826,6 +950,19 @@ var _ = Describe("Packet packer", func() {
 		})
 	})
 
+	It("packs PATH_CHALLENGE packets", func() {
+		f := &wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}
+		streamFramer.AddFrameForRetransmission(&wire.StreamFrame{StreamID: 5, Data: []byte("foobar")})
+		p, err := packer.PackPathValidationPacket(f, pth)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.frames).To(Equal([]wire.Frame{f}))
+	})
+
+	It("refuses to pack other frames in path validation packets", func() {
+		_, err := packer.PackPathValidationPacket(&wire.PingFrame{}, pth)
+		Expect(err).To(MatchError("PacketPacker BUG: not a path validation frame"))
+	})
+
 	It("packs PING packet", func() {
 		p, err := packer.PackPing(&wire.PingFrame{}, pth)
 		Expect(err).NotTo(HaveOccurred())
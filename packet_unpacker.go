@@ -136,7 +136,7 @@ func (u *packetUnpacker) Unpack(headerBinary []byte, hdr *wire.Header, data []by
 				err = qerr.Error(qerr.InvalidBlockedData, err.Error())
 			}
 		} else if typeByte == 0xa {
-			frame, err = wire.ParseFECFrame(r, u.version)
+			frame, err = wire.ParseFECFrameWithRepairFECPayloadID(r, u.version, fec.GetRepairFECPayloadIDParser(u.sess.GetFECScheme()))
 			if err != nil {
 				panic("ERROR " + err.Error())
 			}
@@ -168,6 +168,10 @@ func (u *packetUnpacker) Unpack(headerBinary []byte, hdr *wire.Header, data []by
 			// log.Printf("DECODE SymBolAckFrame")
 			// r.ReadByte()
 			frame, err = wire.ParseSymbolAckFrame(r, u.version)
+		} else if typeByte == 0x14 {
+			frame, err = wire.ParsePathChallengeFrame(r, u.version)
+		} else if typeByte == 0x15 {
+			frame, err = wire.ParsePathResponseFrame(r, u.version)
 		} else {
 			err = qerr.Error(qerr.InvalidFrameData, fmt.Sprintf("unknown type byte 0x%x", typeByte))
 		}
@@ -367,6 +367,24 @@ var _ = Describe("Packet unpacker", func() {
 		}))
 	})
 
+	It("unpacks PATH_CHALLENGE frames", func() {
+		setData([]byte{0x14, 1, 2, 3, 4, 5, 6, 7, 8})
+		packet, err := unpacker.Unpack(hdrBin, hdr, data, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet.frames).To(Equal([]wire.Frame{
+			&wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		}))
+	})
+
+	It("unpacks PATH_RESPONSE frames", func() {
+		setData([]byte{0x15, 1, 2, 3, 4, 5, 6, 7, 8})
+		packet, err := unpacker.Unpack(hdrBin, hdr, data, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(packet.frames).To(Equal([]wire.Frame{
+			&wire.PathResponseFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		}))
+	})
+
 	It("errors on invalid type", func() {
 		setData([]byte{0xf})
 		_, err := unpacker.Unpack(hdrBin, hdr, data, false)
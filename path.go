@@ -1,7 +1,9 @@
 package quic
 
 import (
+	"crypto/rand"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/ackhandler"
@@ -17,8 +19,48 @@ const (
 	minPathTimer = 10 * time.Millisecond
 	// XXX (QDC): To avoid idling...
 	maxPathTimer = 1 * time.Second
+
+	// Time to wait for the PATH_RESPONSE of the first PATH_CHALLENGE, doubled for every retry
+	pathChallengeTimeout = 200 * time.Millisecond
+	// The path remains unvalidated if the peer doesn't answer this number of PATH_CHALLENGEs
+	maxPathChallenges = 5
+
+	// A path is potentially failed if nothing was received during this number of smoothed RTTs while data is in flight
+	missingAcksRTTs = 3
+	// A single RTO may be spurious: a path is only potentially failed after this number of consecutive RTOs
+	potentiallyFailedRTOs = 2
+	// A potentially failed path is failed after this number of consecutive RTOs
+	maxConsecutiveRTOs = 4
+	// Interval between the PINGs probing a potentially failed path, doubled after every probe
+	minPathProbeInterval = 100 * time.Millisecond
+	// Failed paths are probed at this interval
+	maxPathProbeInterval = 4 * time.Second
+)
+
+// pathState is the state of the path failure detection
+type pathState uint8
+
+const (
+	// pathStateActive paths can be used by the schedulers
+	pathStateActive pathState = iota
+	// pathStatePotentiallyFailed paths faced a RTO or are missing ACKs, they are only probed until they recover
+	pathStatePotentiallyFailed
+	// pathStateFailed paths remained potentially failed during several RTOs, they are only probed
+	pathStateFailed
 )
 
+func (s pathState) String() string {
+	switch s {
+	case pathStateActive:
+		return "active"
+	case pathStatePotentiallyFailed:
+		return "potentially failed"
+	case pathStateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
 type path struct {
 	pathID protocol.PathID
 	conn   connection
@@ -43,6 +85,30 @@ type path struct {
 	wasPotentiallyFailed utils.AtomicBool
 	// It might be useful to know that this path faced a RTO at some point
 	facedRTO utils.AtomicBool
+	// failed is set if the path remained potentially failed during maxConsecutiveRTOs RTOs
+	failed          utils.AtomicBool
+	consecutiveRTOs int
+	// Potentially failed paths are probed with PINGs.
+	// probeMutex guards the probing state, set by the session and read by the path timer
+	probeMutex    sync.Mutex
+	probeInterval time.Duration
+	nextProbeTime time.Time
+	// The application closed this path with Session.ClosePath
+	closed utils.AtomicBool
+	// The peer announced this path in a PATHS frame, and then stopped announcing it
+	announcedByPeer bool
+	closedByPeer    utils.AtomicBool
+	// The number of packets the scheduler sent on this path
+	scheduledPackets utils.AtomicUint64
+
+	// Application data is only sent on validated paths, i.e. once the peer answered our PATH_CHALLENGE.
+	// The initial path is validated by the handshake.
+	validated utils.AtomicBool
+	// validationMutex guards the challenge state, set by the path manager and read by the path timer
+	validationMutex   sync.Mutex
+	challenge         [8]byte
+	challengesSent    int
+	nextChallengeTime time.Time
 
 	sentPacket chan struct{}
 
@@ -61,6 +127,13 @@ type path struct {
 	timer *utils.Timer
 
 	fec *utils.AtomicBool
+
+	// The redundancy controller of the path, fed by the losses of the path
+	redundancyController fec.RedundancyController
+	// The estimator of the loss bursts of the path given to its redundancy controller: the OLIA congestion controller,
+	// or lossBurstEstimator if the path has no OLIA
+	burstEstimator     fec.BurstEstimator
+	lossBurstEstimator *fec.LossBurstEstimator
 }
 
 // FIXME this is why we should change the PathID when network changes...
@@ -73,10 +146,14 @@ func (p *path) setupReusePath(oliaSenders map[protocol.PathID]*congestion.OliaSe
 	}
 
 	p.active.Set(true)
+	p.closed.Set(false)
+	p.closedByPeer.Set(false)
 	p.validRemAddrID = true
 	p.potentiallyFailed.Set(false)
 	p.wasPotentiallyFailed.Set(false)
 	p.facedRTO.Set(false)
+	p.recovered()
+	p.setRedundancyController(p.redundancyController)
 }
 
 // setup initializes values that are independent of the perspective
@@ -89,14 +166,26 @@ func (p *path) setup(oliaSenders map[protocol.PathID]*congestion.OliaSender, red
 		cong = congestion.NewOliaSender(oliaSenders, p.rttStats, protocol.InitialCongestionWindow, protocol.DefaultMaxCongestionWindow)
 		oliaSenders[p.pathID] = cong.(*congestion.OliaSender)
 	}
+	p.setBurstEstimator(cong)
+	p.setRedundancyController(redundancyController)
 
 	sentPacketHandler := ackhandler.NewSentPacketHandler(p.rttStats,
 		cong,
 		p.onRTO,
 		// 调用的冗余控制器的方法
-		func(pn protocol.PacketNumber) { redundancyController.OnPacketLost(pn) },
+		func(pn protocol.PacketNumber) {
+			p.redundancyController.OnPacketLost(pn)
+			if p.lossBurstEstimator != nil {
+				p.lossBurstEstimator.OnPacketLost(pn)
+			}
+		},
 		// 调用冗余控制器的方法
-		func(pn protocol.PacketNumber) { redundancyController.OnPacketReceived(pn) },
+		func(pn protocol.PacketNumber) {
+			p.redundancyController.OnPacketReceived(pn)
+			if p.lossBurstEstimator != nil {
+				p.lossBurstEstimator.OnPacketReceived(pn)
+			}
+		},
 		p.sess.GetConfig().UseFastRetransmit,
 	)
 
@@ -122,7 +211,9 @@ func (p *path) setup(oliaSenders map[protocol.PathID]*congestion.OliaSender, red
 	p.potentiallyFailed.Set(false)
 	p.wasPotentiallyFailed.Set(false)
 	p.facedRTO.Set(false)
+	p.recovered()
 	p.validRemAddrID = true
+	p.validated.Set(true)
 
 	p.fec = &utils.AtomicBool{}
 
@@ -192,6 +283,16 @@ func (p *path) maybeResetTimer() {
 	if lossTime := p.sentPacketHandler.GetAlarmTimeout(); !lossTime.IsZero() {
 		deadline = utils.MinTime(deadline, lossTime)
 	}
+	p.validationMutex.Lock()
+	if !p.nextChallengeTime.IsZero() {
+		deadline = utils.MinTime(deadline, p.nextChallengeTime)
+	}
+	p.validationMutex.Unlock()
+	p.probeMutex.Lock()
+	if !p.nextProbeTime.IsZero() {
+		deadline = utils.MinTime(deadline, p.nextProbeTime)
+	}
+	p.probeMutex.Unlock()
 
 	deadline = utils.MinTime(utils.MaxTime(deadline, time.Now().Add(minPathTimer)), time.Now().Add(maxPathTimer))
 
@@ -212,7 +313,8 @@ func (p *path) idleTimeout() time.Duration {
 }
 
 func (p *path) handlePacketImpl(pkt *receivedPacket) (*unpackedPacket, error) {
-	if !p.active.Get() {
+	// The paths closed by the application stay unused until they are opened again
+	if !p.active.Get() && !p.closed.Get() {
 		// We just got some response from remote!
 		p.active.Set(true)
 		// If we lost connectivity for local reason, identify the current local address ID
@@ -234,7 +336,10 @@ func (p *path) handlePacketImpl(pkt *receivedPacket) (*unpackedPacket, error) {
 	data := pkt.data
 
 	// We just received a new packet on that path, so it works
-	p.potentiallyFailed.Set(false)
+	if p.state() != pathStateActive {
+		utils.Infof("Path %x recovered", p.pathID)
+	}
+	p.recovered()
 
 	// Calculate packet number
 	hdr.PacketNumber = protocol.InferPacketNumber(
@@ -296,15 +401,193 @@ func (p *path) handlePacketImpl(pkt *receivedPacket) (*unpackedPacket, error) {
 
 func (p *path) onRTO(lastSentTime time.Time) bool {
 	p.facedRTO.Set(true)
+	p.consecutiveRTOs++
 	// Was there any activity since last sent packet?
 	if p.lastNetworkActivityTime.Before(lastSentTime) {
-		//p.potentiallyFailed.Set(true)
-		//p.wasPotentiallyFailed.Set(true)
+		if p.consecutiveRTOs >= potentiallyFailedRTOs {
+			// The scheduler will reinject the packets in flight on the other paths
+			p.setPotentiallyFailed(time.Now())
+		}
+		if p.consecutiveRTOs >= maxConsecutiveRTOs && !p.failed.Get() {
+			utils.Infof("Path %x failed after %d RTOs", p.pathID, p.consecutiveRTOs)
+			p.failed.Set(true)
+			p.probeMutex.Lock()
+			p.probeInterval = maxPathProbeInterval
+			p.probeMutex.Unlock()
+		}
 		return true
 	}
 	return false
 }
 
+// state returns the state of the path in the failure detection
+func (p *path) state() pathState {
+	if p.failed.Get() {
+		return pathStateFailed
+	}
+	if p.potentiallyFailed.Get() {
+		return pathStatePotentiallyFailed
+	}
+	return pathStateActive
+}
+
+// setPotentiallyFailed excludes the path from the schedulers and starts probing it
+func (p *path) setPotentiallyFailed(now time.Time) {
+	if !p.potentiallyFailed.Get() {
+		utils.Infof("Path %x is potentially failed", p.pathID)
+		p.probeMutex.Lock()
+		p.probeInterval = minPathProbeInterval
+		p.nextProbeTime = now
+		p.probeMutex.Unlock()
+	}
+	p.potentiallyFailed.Set(true)
+	p.wasPotentiallyFailed.Set(true)
+}
+
+// recovered makes the path usable again, the peer received on it
+func (p *path) recovered() {
+	p.potentiallyFailed.Set(false)
+	p.failed.Set(false)
+	p.consecutiveRTOs = 0
+	p.probeMutex.Lock()
+	p.probeInterval = 0
+	p.nextProbeTime = time.Time{}
+	p.probeMutex.Unlock()
+}
+
+// isMissingAcks returns true if nothing was received on the path for missingAcksRTTs RTTs while a packet is in flight
+func (p *path) isMissingAcks(now time.Time) bool {
+	srtt := p.rttStats.SmoothedRTT()
+	if srtt == 0 || p.sentPacketHandler.GetBytesInFlight() == 0 {
+		return false
+	}
+	deadline := missingAcksRTTs * srtt
+	if now.Sub(p.lastNetworkActivityTime) < deadline {
+		return false
+	}
+	for _, pkt := range p.sentPacketHandler.GetPacketsInFlight() {
+		if pkt.SendTime.After(p.lastNetworkActivityTime) && now.Sub(pkt.SendTime) >= deadline {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeProbe updates the failure detection of the path, and returns true if a PING must be sent to probe it
+func (p *path) maybeProbe(now time.Time) bool {
+	if !p.active.Get() {
+		return false
+	}
+	if !p.potentiallyFailed.Get() {
+		if !p.isMissingAcks(now) {
+			return false
+		}
+		p.setPotentiallyFailed(now)
+	}
+	p.probeMutex.Lock()
+	defer p.probeMutex.Unlock()
+	if now.Before(p.nextProbeTime) {
+		return false
+	}
+	p.nextProbeTime = now.Add(p.probeInterval)
+	p.probeInterval = utils.MinDuration(2*p.probeInterval, maxPathProbeInterval)
+	return true
+}
+
+// pushRedundancyParameters gives the statistics of the path to its redundancy controller
+func (p *path) pushRedundancyParameters() {
+	sntPkts, sntRetrans, sntLost := p.sentPacketHandler.GetStatistics()
+	rcvPkts, recoveredPkts := p.receivedPacketHandler.GetStatistics()
+	p.redundancyController.PushParamerters(fec.TransParams{
+		SntPkts:       sntPkts,
+		SntRetrans:    sntRetrans,
+		SntLost:       sntLost,
+		RcvPkts:       rcvPkts,
+		RecoveredPkts: recoveredPkts,
+		SmoothedRTT:   p.rttStats.SmoothedRTT(),
+	})
+}
+
+// lossRate returns the ratio of the packets sent on the path that have been lost
+func (p *path) lossRate() float64 {
+	sntPkts, _, sntLost := p.sentPacketHandler.GetStatistics()
+	if sntPkts == 0 {
+		return 0
+	}
+	return float64(sntLost) / float64(sntPkts)
+}
+
+// setBurstEstimator makes the OLIA congestion controller of the path estimate its loss bursts, or a LossBurstEstimator
+// if the path has no OLIA, as the initial path
+func (p *path) setBurstEstimator(cong congestion.SendAlgorithm) {
+	if olia, ok := cong.(*congestion.OliaSender); ok {
+		p.burstEstimator = olia
+		p.lossBurstEstimator = nil
+		return
+	}
+	p.lossBurstEstimator = fec.NewLossBurstEstimator()
+	p.burstEstimator = p.lossBurstEstimator
+}
+
+// setRedundancyController makes the path feed the redundancy controller c, and gives it the burst estimator of the path
+func (p *path) setRedundancyController(c fec.RedundancyController) {
+	p.redundancyController = c
+	if rc, ok := c.(fec.BurstAwareRedundancyController); ok && p.burstEstimator != nil {
+		rc.SetBurstEstimator(p.pathID, p.burstEstimator)
+	}
+}
+
+// smoothedOrInitialRTT returns the smoothed RTT of the path, or the initial RTT if it has not been measured yet
+func (p *path) smoothedOrInitialRTT() time.Duration {
+	if rtt := p.rttStats.SmoothedRTT(); rtt != 0 {
+		return rtt
+	}
+	return time.Duration(p.rttStats.InitialRTTus()) * time.Microsecond
+}
+
+// startValidation prevents sending data on the path until the peer answers a PATH_CHALLENGE on it
+func (p *path) startValidation() {
+	p.validationMutex.Lock()
+	defer p.validationMutex.Unlock()
+	p.validated.Set(false)
+	rand.Read(p.challenge[:])
+	p.challengesSent = 0
+	p.nextChallengeTime = time.Now()
+}
+
+// maybeGetPathChallenge returns the PATH_CHALLENGE frame to send on the path, if it is time to (re)send it.
+// The path fails if the peer answered none of the maxPathChallenges challenges.
+func (p *path) maybeGetPathChallenge(now time.Time) *wire.PathChallengeFrame {
+	p.validationMutex.Lock()
+	defer p.validationMutex.Unlock()
+	if p.validated.Get() || p.nextChallengeTime.IsZero() || now.Before(p.nextChallengeTime) {
+		return nil
+	}
+	if p.challengesSent >= maxPathChallenges {
+		// The peer is not reachable on this path, give up
+		utils.Infof("Path %x could not be validated", p.pathID)
+		p.nextChallengeTime = time.Time{}
+		p.failed.Set(true)
+		p.active.Set(false)
+		return nil
+	}
+	p.nextChallengeTime = now.Add(pathChallengeTimeout << uint(p.challengesSent))
+	p.challengesSent++
+	return &wire.PathChallengeFrame{Data: p.challenge}
+}
+
+// handlePathResponse validates the path if the PATH_RESPONSE echoes our PATH_CHALLENGE
+func (p *path) handlePathResponse(f *wire.PathResponseFrame) {
+	p.validationMutex.Lock()
+	defer p.validationMutex.Unlock()
+	if p.validated.Get() || p.challengesSent == 0 || f.Data != p.challenge {
+		return
+	}
+	p.validated.Set(true)
+	p.nextChallengeTime = time.Time{}
+	utils.Debugf("Path %x validated", p.pathID)
+}
+
 func (p *path) SetLeastUnacked(leastUnacked protocol.PacketNumber) {
 	p.leastUnacked = leastUnacked
 }
@@ -2,6 +2,7 @@ package quic
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"sync"
@@ -30,6 +31,10 @@ type pathManager struct {
 	addAddressChange    chan *wire.AddAddressFrame
 	removeAddressChange chan *wire.RemoveAddressFrame
 
+	// Interfaces marked as backup or not by the application, by name
+	backupInterfaces      map[string]bool
+	backupInterfaceChange chan backupInterfaceChange
+
 	// TODO (QDC): find a cleaner way
 	oliaSenders map[protocol.PathID]*congestion.OliaSender
 
@@ -41,14 +46,20 @@ type pathManager struct {
 	runClosed          chan struct{}
 	timer              *time.Timer
 
-	redundancyController fec.RedundancyController
+	// Every path gets its own redundancy controller
+	newRedundancyController func() fec.RedundancyController
 
 	// Rendez-vous point when closing all paths
 	wg sync.WaitGroup
 	hasFECPath				*utils.AtomicBool
 }
 
-func (pm *pathManager) setup(conn connection, redundancyController fec.RedundancyController) {
+type backupInterfaceChange struct {
+	ifName string
+	backup bool
+}
+
+func (pm *pathManager) setup(conn connection, newRedundancyController func() fec.RedundancyController) {
 	// Initial PathID is 0
 	pm.nxtPathID = 1
 
@@ -58,10 +69,12 @@ func (pm *pathManager) setup(conn connection, redundancyController fec.Redundanc
 	pm.handshakeCompleted = make(chan struct{}, 1)
 	pm.addAddressChange = make(chan *wire.AddAddressFrame, 1)
 	pm.removeAddressChange = make(chan *wire.RemoveAddressFrame, 1)
+	pm.backupInterfaces = make(map[string]bool)
+	pm.backupInterfaceChange = make(chan backupInterfaceChange, 1)
 	pm.runClosed = make(chan struct{}, 1)
 	pm.timer = time.NewTimer(0)
 	pm.reusablePaths = make([]protocol.PathID, 0)
-	pm.redundancyController = redundancyController
+	pm.newRedundancyController = newRedundancyController
 
 	pm.oliaSenders = make(map[protocol.PathID]*congestion.OliaSender)
 
@@ -69,7 +82,7 @@ func (pm *pathManager) setup(conn connection, redundancyController fec.Redundanc
 
 	pm.hasFECPath = &utils.AtomicBool{}
 
-	paths := pm.sess.Paths()
+	paths := pm.sess.GetPaths()
 
 	// Setup the first path of the connection
 	paths[protocol.InitialPathID] = &path{
@@ -79,7 +92,7 @@ func (pm *pathManager) setup(conn connection, redundancyController fec.Redundanc
 	}
 
 	// Setup this first path
-	paths[protocol.InitialPathID].setup(pm.oliaSenders, pm.redundancyController)
+	paths[protocol.InitialPathID].setup(pm.oliaSenders, pm.newRedundancyController())
 	pm.wg.Add(1)
 
 	// With the initial path, get the remoteAddr to create paths accordingly
@@ -108,22 +121,23 @@ func (pm *pathManager) tryCreatingPaths() {
 func (pm *pathManager) addRemoteAddress(f *wire.AddAddressFrame) {
 	pm.remoteAddrs[f.AddrID] = &f.Addr
 	pm.remoteBackups[f.AddrID] = f.Backup
+	pm.sess.PathsLock().Lock()
+	defer pm.sess.PathsLock().Unlock()
 	// Particular case: if address ID 0 is actually the one announced, perform the change
 	if pm.remoteAddrs[protocol.AddressID(0)].String() == pm.remoteAddrs[f.AddrID].String() {
-		pm.sess.PathsLock().Lock()
-		defer pm.sess.PathsLock().Unlock()
-		for _, pth := range pm.sess.Paths() {
+		for _, pth := range pm.sess.GetPaths() {
 			if pth.remAddrID == protocol.AddressID(0) && pth.validRemAddrID {
 				pth.remAddrID = f.AddrID
-				bk := f.Backup
-				if pm.sess.GetConfig().MultipathService == Handover {
-					bk = bk || pm.isBackupAddress(pth.locAddrID)
-				}
-				pth.backup.Set(bk)
 			}
 		}
 		delete(pm.remoteAddrs, protocol.AddressID(0))
 	}
+	// The peer might have changed the backup state of the address
+	for _, pth := range pm.sess.GetPaths() {
+		if pth.remAddrID == f.AddrID && pth.validRemAddrID {
+			pth.backup.Set(pm.isBackupPath(pth))
+		}
+	}
 }
 
 func (pm *pathManager) removeRemoteAddress(f *wire.RemoveAddressFrame) {
@@ -134,7 +148,7 @@ func (pm *pathManager) removeRemoteAddress(f *wire.RemoveAddressFrame) {
 
 	pm.sess.PathsLock().Lock()
 	defer pm.sess.PathsLock().Unlock()
-	for pathID, pth := range pm.sess.Paths() {
+	for pathID, pth := range pm.sess.GetPaths() {
 		if pth.remAddrID == f.AddrID && pth.validRemAddrID {
 			pth.active.Set(false)
 			pth.validRemAddrID = false
@@ -147,7 +161,7 @@ func (pm *pathManager) removeLocalAddress(addrID protocol.AddressID) {
 	utils.Debugf("Received remove local address")
 	pm.sess.PathsLock().Lock()
 	defer pm.sess.PathsLock().Unlock()
-	for pathID, pth := range pm.sess.Paths() {
+	for pathID, pth := range pm.sess.GetPaths() {
 		if pth.locAddrID == addrID {
 			pth.active.Set(false)
 			pth.validRemAddrID = false
@@ -170,6 +184,8 @@ initialLoop:
 			pm.addRemoteAddress(addAddressFrame)
 		case removeAddressFrame := <-pm.removeAddressChange:
 			pm.removeRemoteAddress(removeAddressFrame)
+		case change := <-pm.backupInterfaceChange:
+			pm.updateBackupInterface(change)
 		case <-pm.pconnMgr.AddedAddrIDChan():
 		case addrID := <-pm.pconnMgr.RemovedAddrIDChan():
 			pm.removeLocalAddress(addrID)
@@ -197,6 +213,8 @@ runLoop:
 			pm.addRemoteAddress(addAddressFrame)
 		case removeAddressFrame := <-pm.removeAddressChange:
 			pm.removeRemoteAddress(removeAddressFrame)
+		case change := <-pm.backupInterfaceChange:
+			pm.updateBackupInterface(change)
 		case <-pm.pconnMgr.AddedAddrIDChan():
 		case addrID := <-pm.pconnMgr.RemovedAddrIDChan():
 			pm.removeLocalAddress(addrID)
@@ -226,15 +244,8 @@ func (pm *pathManager) advertiseAddresses() {
 				if pm.pconnMgr.GetPreferredPort() != 0 {
 					advAddr.Port = pm.pconnMgr.GetPreferredPort()
 				}
-				backup := false
 				// Check if it is a backup path or not
-				cfg := pm.sess.GetConfig()
-				if cfg.MultipathService == Handover {
-					ifName, ok := pm.pconnMgr.GetInterfaceName(*locAddr)
-					if ok && (strings.HasPrefix(ifName, "rmnet") || strings.HasPrefix(ifName, "pdp_ip")) {
-						backup = true
-					}
-				}
+				backup := pm.isBackupAddress(addrID)
 				pm.sess.GetStreamFramer().AddAddAddressForTransmission(addrID, *advAddr, backup)
 			}
 			pm.advertisedLocAddrs[addrID] = true
@@ -242,17 +253,17 @@ func (pm *pathManager) advertiseAddresses() {
 	}
 }
 
-func (pm *pathManager) createPath(locAddrID protocol.AddressID, locAddr net.UDPAddr, remAddrID protocol.AddressID, remAddr net.UDPAddr) error {
+func (pm *pathManager) createPath(locAddrID protocol.AddressID, locAddr net.UDPAddr, remAddrID protocol.AddressID, remAddr net.UDPAddr) (*path, error) {
 	// First check that the path does not exist yet
-	paths := pm.sess.Paths()
+	paths := pm.sess.GetPaths()
 	for _, pth := range paths {
-		// Skip non-used paths
-		if !pth.active.Get() {
+		// Skip non-used paths, but don't recreate the paths closed by the application
+		if (!pth.active.Get() && !pth.closed.Get()) || pth.conn == nil {
 			continue
 		}
 		if pth.conn.LocalAddr().String() == locAddr.String() && pth.conn.RemoteAddr().String() == remAddr.String() {
 			// Path already exists, so don't create it again
-			return nil
+			return pth, nil
 		}
 	}
 	var pth *path
@@ -281,7 +292,7 @@ func (pm *pathManager) createPath(locAddrID protocol.AddressID, locAddr net.UDPA
 			remAddrID: remAddrID,
 		}
 		pm.wg.Add(1)
-		pth.setup(pm.oliaSenders, pm.redundancyController)
+		pth.setup(pm.oliaSenders, pm.newRedundancyController())
 		if utils.Debug() {
 			utils.Debugf("Starting path %x on %s to %s", pm.nxtPathID, locAddr.String(), remAddr.String())
 		}
@@ -289,18 +300,11 @@ func (pm *pathManager) createPath(locAddrID protocol.AddressID, locAddr net.UDPA
 	}
 
 	// Check if it is a backup path or not
-	cfg := pm.sess.GetConfig()
-	bk, ok := pm.remoteBackups[pth.remAddrID]
-	if !ok {
-		bk = false
-	}
-	if cfg.MultipathService == Handover {
-		bk = bk || pm.isBackupAddress(pth.locAddrID)
-	}
+	bk := pm.isBackupPath(pth)
 	pth.backup.Set(bk)
 
 	// use a FEC paths if we already have two other non-fec paths
-	if protocol.USE_FEC_DEDICATED_PATH && !bk && !pm.hasFECPath.Get() && len(paths) > 2 {
+	if pm.sess.GetConfig().FECPathPolicy == FECPathDedicated && !bk && !pm.hasFECPath.Get() && len(paths) > 2 {
 		// FIXME: do not set a fec path is fec is not needed
 		if pth.fec == nil {
 			pth.fec = &utils.AtomicBool{}
@@ -310,16 +314,60 @@ func (pm *pathManager) createPath(locAddrID protocol.AddressID, locAddr net.UDPA
 	}
 
 	paths[pathID] = pth
+	// The addresses might have been advertised by anyone, check that the peer is there
+	pth.startValidation()
 	// Send a PING frame to get latency info about the new path and informing the
 	// peer of its existence
 	// FIXME PING + PATHS frames
-	return pm.sess.SendPing(pth)
+	return pth, pm.sess.SendPing(pth)
 }
 
+// isBackupAddress tells if the local address is on a backup interface.
+// The interfaces marked with Session.SetBackupInterface are backup whatever the MultipathService is,
+// the cellular ones are backup in Handover mode.
 func (pm *pathManager) isBackupAddress(addrID protocol.AddressID) bool {
-	addr := pm.pconnMgr.LocalAddrs()[addrID]
+	addr, ok := pm.pconnMgr.LocalAddrs()[addrID]
+	if !ok || addr == nil {
+		return false
+	}
 	ifName, ok := pm.pconnMgr.GetInterfaceName(*addr)
-	return ok && (strings.HasPrefix(ifName, "rmnet") || strings.HasPrefix(ifName, "pdp_ip"))
+	if !ok {
+		return false
+	}
+	if backup, ok := pm.backupInterfaces[ifName]; ok {
+		return backup
+	}
+	return pm.sess.GetConfig().MultipathService == Handover && (strings.HasPrefix(ifName, "rmnet") || strings.HasPrefix(ifName, "pdp_ip"))
+}
+
+// isBackupPath tells if one of the addresses of the path is a backup one
+func (pm *pathManager) isBackupPath(pth *path) bool {
+	return pm.remoteBackups[pth.remAddrID] || pm.isBackupAddress(pth.locAddrID)
+}
+
+// setBackupInterface marks the local interface ifName as backup or not
+func (pm *pathManager) setBackupInterface(ifName string, backup bool) {
+	select {
+	case pm.backupInterfaceChange <- backupInterfaceChange{ifName: ifName, backup: backup}:
+	case <-pm.runClosed:
+	}
+}
+
+func (pm *pathManager) updateBackupInterface(change backupInterfaceChange) {
+	pm.pconnMgr.PconnsLock().RLock()
+	defer pm.pconnMgr.PconnsLock().RUnlock()
+	pm.backupInterfaces[change.ifName] = change.backup
+	// Advertise again the addresses of the interface to inform the peer
+	for addrID, addr := range pm.pconnMgr.LocalAddrs() {
+		if ifName, ok := pm.pconnMgr.GetInterfaceName(*addr); ok && ifName == change.ifName {
+			delete(pm.advertisedLocAddrs, addrID)
+		}
+	}
+	pm.sess.PathsLock().Lock()
+	defer pm.sess.PathsLock().Unlock()
+	for _, pth := range pm.sess.GetPaths() {
+		pth.backup.Set(pm.isBackupPath(pth))
+	}
 }
 
 func (pm *pathManager) createPaths() error {
@@ -350,7 +398,7 @@ func (pm *pathManager) createPaths() error {
 			if utils.GetIPVersion(remAddr.IP) != version {
 				continue
 			}
-			err := pm.createPath(locAddrID, *locAddr, remAddrID, *remAddr)
+			_, err := pm.createPath(locAddrID, *locAddr, remAddrID, *remAddr)
 			if err != nil {
 				return err
 			}
@@ -360,6 +408,108 @@ func (pm *pathManager) createPaths() error {
 	return nil
 }
 
+// openPath creates a path between a local and a remote address on request of the application.
+// If the application closed such a path before, it is opened again.
+func (pm *pathManager) openPath(locAddr net.Addr, remAddr net.Addr) (protocol.PathID, error) {
+	if pm.sess.GetPerspective() != protocol.PerspectiveClient {
+		return 0, errors.New("only the client can open paths")
+	}
+	if !pm.sess.IsHandshakeComplete() {
+		return 0, errors.New("cannot open paths before the handshake completed")
+	}
+
+	pm.pconnMgr.PconnsLock().RLock()
+	defer pm.pconnMgr.PconnsLock().RUnlock()
+	pm.sess.PathsLock().Lock()
+	defer pm.sess.PathsLock().Unlock()
+
+	locAddrID, ok := pm.pconnMgr.GetAddrIDOf(locAddr)
+	if !ok {
+		return 0, fmt.Errorf("unknown local address %s", locAddr)
+	}
+	var remAddrID protocol.AddressID
+	var udpRemAddr *net.UDPAddr
+	for addrID, addr := range pm.remoteAddrs {
+		if addr.String() == remAddr.String() {
+			remAddrID = addrID
+			udpRemAddr = addr
+			break
+		}
+	}
+	if udpRemAddr == nil {
+		return 0, fmt.Errorf("unknown remote address %s", remAddr)
+	}
+
+	for pathID, pth := range pm.sess.GetPaths() {
+		if pth.closed.Get() && pth.conn != nil && pth.locAddrID == locAddrID && pth.conn.RemoteAddr().String() == udpRemAddr.String() {
+			pth.setupReusePath(pm.oliaSenders)
+			pm.sess.SchedulePathsFrame()
+			return pathID, pm.sess.SendPing(pth)
+		}
+	}
+
+	if !pm.canCreatePaths() {
+		return 0, errors.New("no path ID available")
+	}
+	pth, err := pm.createPath(locAddrID, *pm.pconnMgr.LocalAddrs()[locAddrID], remAddrID, *udpRemAddr)
+	if err != nil {
+		return 0, err
+	}
+	pm.sess.SchedulePathsFrame()
+	return pth.pathID, nil
+}
+
+// closePath stops using a path on request of the application.
+// The packets received on it are dropped, until it is opened again or reused.
+func (pm *pathManager) closePath(pathID protocol.PathID) error {
+	if pathID == protocol.InitialPathID {
+		return errors.New("cannot close the initial path")
+	}
+
+	pm.pconnMgr.PconnsLock().RLock()
+	defer pm.pconnMgr.PconnsLock().RUnlock()
+	pm.sess.PathsLock().Lock()
+	defer pm.sess.PathsLock().Unlock()
+
+	pth, ok := pm.sess.GetPaths()[pathID]
+	if !ok {
+		return fmt.Errorf("there is no path with ID %d", pathID)
+	}
+	if pth.closed.Get() {
+		return nil
+	}
+	pth.closed.Set(true)
+	pth.active.Set(false)
+	// The losses of the path don't tell anything about the other paths sharing its redundancy controller anymore
+	if rc, ok := pth.redundancyController.(fec.BurstAwareRedundancyController); ok {
+		rc.SetBurstEstimator(pathID, nil)
+	}
+	// The PATHS frame doesn't announce the path anymore, such that the peer stops using it
+	pm.sess.SchedulePathsFrame()
+	pm.sess.scheduleSending()
+	return nil
+}
+
+// handlePathsFrame stops using the paths that the peer announced before, but not in frame, as the peer closed them.
+// Caller MUST hold PathsLock!
+func (pm *pathManager) handlePathsFrame(frame *wire.PathsFrame) {
+	for pathID, pth := range pm.sess.GetPaths() {
+		if pathID == protocol.InitialPathID {
+			continue
+		}
+		if _, ok := frame.PathInfos[pathID]; ok {
+			pth.announcedByPeer = true
+			continue
+		}
+		if pth.announcedByPeer && pth.active.Get() {
+			utils.Infof("Path %x closed by the peer", pathID)
+			pth.announcedByPeer = false
+			pth.closedByPeer.Set(true)
+			pth.active.Set(false)
+		}
+	}
+}
+
 func (pm *pathManager) createPathFromRemote(p *receivedPacket) (*path, error) {
 	pm.pconnMgr.PconnsLock().RLock()
 	defer pm.pconnMgr.PconnsLock().RUnlock()
@@ -368,7 +518,7 @@ func (pm *pathManager) createPathFromRemote(p *receivedPacket) (*path, error) {
 	localPconn := p.rcvPconn
 	remoteAddr := p.remoteAddr
 	pathID := p.header.PathID
-	paths := pm.sess.Paths()
+	paths := pm.sess.GetPaths()
 
 	// Sanity check: pathID should not exist yet
 	_, ko := paths[pathID]
@@ -399,21 +549,14 @@ func (pm *pathManager) createPathFromRemote(p *receivedPacket) (*path, error) {
 		delete(pm.remoteAddrIDOfComingPaths, pathID)
 	}
 
-	pth.setup(pm.oliaSenders, pm.redundancyController)
+	pth.setup(pm.oliaSenders, pm.newRedundancyController())
 
 	// Check if it is a backup path or not
-	cfg := pm.sess.GetConfig()
-	bk, ok := pm.remoteBackups[pth.remAddrID]
-	if !ok {
-		bk = false
-	}
-	if cfg.MultipathService == Handover {
-		bk = bk || pm.isBackupAddress(pth.locAddrID)
-	}
+	bk := pm.isBackupPath(pth)
 	pth.backup.Set(bk)
 
 	// use a FEC paths if we already have two other non-fec paths
-	if protocol.USE_FEC_DEDICATED_PATH && !bk && !pm.hasFECPath.Get() && len(paths) > 2 {
+	if pm.sess.GetConfig().FECPathPolicy == FECPathDedicated && !bk && !pm.hasFECPath.Get() && len(paths) > 2 {
 		// FIXME: do not set a fec path is fec is not needed
 		if pth.fec == nil {
 			pth.fec = &utils.AtomicBool{}
@@ -424,6 +567,8 @@ func (pm *pathManager) createPathFromRemote(p *receivedPacket) (*path, error) {
 
 	paths[pathID] = pth
 	pm.wg.Add(1)
+	// Don't send data to the source address before the peer proved it receives on it
+	pth.startValidation()
 
 	if utils.Debug() {
 		utils.Debugf("Created remote path %x on %s to %s", pathID, localPconn.LocalAddr().String(), remoteAddr.String())
@@ -450,7 +595,7 @@ func (pm *pathManager) closePaths() {
 	default:
 		// continue
 	}
-	paths := pm.sess.Paths()
+	paths := pm.sess.GetPaths()
 	for _, pth := range paths {
 		// Independently of active or not paths, close them!
 		pth.active.Set(false)
@@ -461,5 +606,5 @@ func (pm *pathManager) closePaths() {
 
 // GetNumActivePaths caller MUST hold PathsLock!
 func (pm *pathManager) GetNumActivePaths() int {
-	return len(pm.sess.Paths()) - len(pm.reusablePaths)
+	return len(pm.sess.GetPaths()) - len(pm.reusablePaths)
 }
@@ -1,5 +1,15 @@
 package quic
 
+import (
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
 // import (
 // 	"errors"
 // 	"github.com/lucas-clemente/quic-go/fec"
@@ -512,3 +522,106 @@ package quic
 // 		})
 // 	})
 // })
+
+var _ = Describe("Path Manager", func() {
+	var (
+		sess       *pathTestSession
+		pm         *pathManager
+		localAddr  *net.UDPAddr
+		remoteAddr *net.UDPAddr
+	)
+
+	BeforeEach(func() {
+		localAddr = &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 0x1337}
+		remoteAddr = &net.UDPAddr{IP: net.IPv4(7, 6, 5, 4), Port: 443}
+		sess = &pathTestSession{
+			paths:    make(map[protocol.PathID]*path),
+			unpacker: ackOnlyUnpacker{},
+		}
+		pm = &pathManager{
+			pconnMgr: &pconnManager{
+				localAddrs:   map[protocol.AddressID]*net.UDPAddr{1: localAddr},
+				localAddrIDS: map[string]protocol.AddressID{localAddr.String(): 1},
+			},
+			sess:        sess,
+			remoteAddrs: map[protocol.AddressID]*net.UDPAddr{1: remoteAddr},
+		}
+		sess.pathManager = pm
+		sess.paths[protocol.InitialPathID] = newTestPath(sess, protocol.InitialPathID)
+		pth := newTestPath(sess, 1)
+		pth.locAddrID = 1
+		pth.conn = &conn{pconn: &mockPacketConn{addr: localAddr}, currentAddr: remoteAddr}
+		sess.paths[1] = pth
+	})
+
+	Context("paths closed by the peer", func() {
+		BeforeEach(func() {
+			sess.paths[2] = newTestPath(sess, 2)
+			pm.handlePathsFrame(&wire.PathsFrame{PathInfos: map[protocol.PathID]wire.PathInfoSection{0: {}, 1: {}, 2: {}}})
+		})
+
+		It("stops using the paths the peer doesn't announce anymore", func() {
+			pm.handlePathsFrame(&wire.PathsFrame{PathInfos: map[protocol.PathID]wire.PathInfoSection{0: {}, 1: {}}})
+			Expect(sess.paths[2].closedByPeer.Get()).To(BeTrue())
+			Expect(sess.paths[2].active.Get()).To(BeFalse())
+			Expect(sess.paths[1].closedByPeer.Get()).To(BeFalse())
+			Expect(schedulablePaths(sess).GetPaths()).ToNot(HaveKey(protocol.PathID(2)))
+		})
+
+		It("keeps the paths the peer didn't announce yet", func() {
+			sess.paths[3] = newTestPath(sess, 3)
+			pm.handlePathsFrame(&wire.PathsFrame{PathInfos: map[protocol.PathID]wire.PathInfoSection{0: {}, 1: {}, 2: {}}})
+			Expect(sess.paths[3].active.Get()).To(BeTrue())
+			Expect(sess.paths[3].closedByPeer.Get()).To(BeFalse())
+		})
+	})
+
+	Context("paths closed by the application", func() {
+		It("stops sending on a closed path", func() {
+			Expect(pm.closePath(1)).To(Succeed())
+			Expect(sess.paths[1].closed.Get()).To(BeTrue())
+			Expect(sess.paths[1].active.Get()).To(BeFalse())
+			Expect(sess.pathsFrameScheduled).To(BeTrue())
+			Expect(schedulablePaths(sess).GetPaths()).ToNot(HaveKey(protocol.PathID(1)))
+		})
+
+		It("doesn't close the initial path", func() {
+			Expect(pm.closePath(protocol.InitialPathID)).To(MatchError("cannot close the initial path"))
+		})
+
+		It("errors for unknown paths", func() {
+			Expect(pm.closePath(42)).To(MatchError("there is no path with ID 42"))
+		})
+
+		It("doesn't use a closed path again when receiving ACKs on it", func() {
+			Expect(pm.closePath(1)).To(Succeed())
+			_, err := sess.paths[1].handlePacketImpl(&receivedPacket{
+				remoteAddr: remoteAddr,
+				header:     &wire.Header{PathID: 1, PacketNumber: 1, PacketNumberLen: protocol.PacketNumberLen6},
+				rcvTime:    time.Now(),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sess.paths[1].active.Get()).To(BeFalse())
+			Expect(sess.paths[1].closed.Get()).To(BeTrue())
+			Expect(schedulablePaths(sess).GetPaths()).ToNot(HaveKey(protocol.PathID(1)))
+		})
+
+		It("opens a closed path again", func() {
+			Expect(pm.closePath(1)).To(Succeed())
+			sess.pathsFrameScheduled = false
+			pathID, err := pm.openPath(localAddr, remoteAddr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pathID).To(Equal(protocol.PathID(1)))
+			Expect(sess.paths[1].closed.Get()).To(BeFalse())
+			Expect(sess.paths[1].active.Get()).To(BeTrue())
+			Expect(sess.pathsFrameScheduled).To(BeTrue())
+			Expect(sess.pings).To(Equal([]*path{sess.paths[1]}))
+			Expect(schedulablePaths(sess).GetPaths()).To(HaveKey(protocol.PathID(1)))
+		})
+
+		It("doesn't open paths to unknown addresses", func() {
+			_, err := pm.openPath(localAddr, &net.UDPAddr{IP: net.IPv4(8, 8, 8, 8), Port: 443})
+			Expect(err).To(MatchError("unknown remote address 8.8.8.8:443"))
+		})
+	})
+})
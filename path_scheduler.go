@@ -0,0 +1,188 @@
+package quic
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// Names of the built-in path schedulers, usable as Config.Scheduler
+const (
+	// SchedulerLowLatency sends on the path with the lowest smoothed RTT (default)
+	SchedulerLowLatency = "lowlatency"
+	// SchedulerRoundRobin sends on the path with the fewest packets sent so far
+	SchedulerRoundRobin = "roundrobin"
+	// SchedulerLowestLossRate sends on the path with the best loss-weighted RTT
+	SchedulerLowestLossRate = "lowestlossrate"
+	// SchedulerHighestRemainingBytes picks a path at random, weighted by its free congestion window
+	SchedulerHighestRemainingBytes = "highestremainingbytes"
+	// SchedulerLossBased round-robins over the paths whose loss bursts can be covered by FEC
+	SchedulerLossBased = "lossbased"
+	// SchedulerECF is the Earliest Completion First scheduler, which waits for the fastest path
+	// when sending on a slower one would cause head-of-line blocking at the receiver
+	SchedulerECF = "ecf"
+)
+
+// A PathID identifies a path of a multipath QUIC connection.
+type PathID = protocol.PathID
+
+// PathInfo is a snapshot of the state of a path, as returned by Session.Paths and seen by a PathScheduler.
+type PathInfo struct {
+	ID PathID
+	// LocalAddr and RemoteAddr are nil if the path lost its local address
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+	// Active is false if the path was closed, or if one of its addresses disappeared
+	Active bool
+	// Validated is false as long as the peer did not prove it receives on the path
+	Validated bool
+	// SmoothedRTT is zero as long as the path was not probed
+	SmoothedRTT      time.Duration
+	CongestionWindow ByteCount
+	BytesInFlight    ByteCount
+	// PacketsSent is the number of packets the scheduler sent on this path
+	PacketsSent uint
+	// SendingAllowed is false if the congestion window of the path is full
+	SendingAllowed    bool
+	Backup            bool
+	PotentiallyFailed bool
+	// Failed is true if the path remained potentially failed during several RTOs, it is then only probed
+	Failed   bool
+	FacedRTO bool
+	// FEC is true if the path is dedicated to the transmission of FEC frames
+	FEC bool
+	// Retransmissions, PacketsLost and Reinjections are counted by the loss recovery of the path
+	Retransmissions uint64
+	PacketsLost     uint64
+	Reinjections    uint64
+}
+
+// A PathScheduler chooses the path on which the next packet of a session is sent.
+// A PathScheduler is only used by a single session, and is never called concurrently.
+type PathScheduler interface {
+	// SelectPath returns the ID of the path to use, and false if no path can be used now.
+	// paths contains every path of the session, sorted by ID. If hasRetransmission is
+	// true, the packet carries a retransmission of a packet sent on fromPath, and paths
+	// whose congestion window is full may be selected.
+	SelectPath(paths []PathInfo, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPath PathID) (PathID, bool)
+}
+
+// pathSelector is the signature of the built-in scheduling policies of the scheduler
+type pathSelector func(sch *scheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path
+
+var builtinPathSchedulers = map[string]pathSelector{
+	SchedulerLowLatency: func(sch *scheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+		return sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
+	},
+	SchedulerRoundRobin:            (*scheduler).selectPathRoundRobin,
+	SchedulerLowestLossRate:        (*scheduler).selectPathLowestLossRate,
+	SchedulerHighestRemainingBytes: (*scheduler).selectPathHighestRemainingBytes,
+	SchedulerECF:                   (*scheduler).selectPathECF,
+	SchedulerLossBased: func(sch *scheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+		if sch.lossRateScheduler == nil {
+			// Without redundancy controller, there is no FEC to rely on
+			return sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
+		}
+		return sch.lossRateScheduler.selectPath(s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
+	},
+}
+
+var (
+	customPathSchedulers      = make(map[string]func() PathScheduler)
+	customPathSchedulersMutex sync.RWMutex
+)
+
+// RegisterPathScheduler makes a PathScheduler available under name, such that it can be selected with Config.Scheduler.
+// newScheduler is called once for every session using it.
+// The names of the built-in schedulers cannot be overridden.
+func RegisterPathScheduler(name string, newScheduler func() PathScheduler) error {
+	if _, ok := builtinPathSchedulers[name]; ok {
+		return fmt.Errorf("path scheduler %s is a built-in scheduler", name)
+	}
+	customPathSchedulersMutex.Lock()
+	defer customPathSchedulersMutex.Unlock()
+	customPathSchedulers[name] = newScheduler
+	return nil
+}
+
+// getPathSelector returns the scheduling policy registered under name.
+// The empty name selects the lowest latency scheduler.
+func getPathSelector(name string) (pathSelector, error) {
+	if name == "" {
+		name = SchedulerLowLatency
+	}
+	if sel, ok := builtinPathSchedulers[name]; ok {
+		return sel, nil
+	}
+	customPathSchedulersMutex.RLock()
+	newScheduler, ok := customPathSchedulers[name]
+	customPathSchedulersMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("there is no path scheduler named %s", name)
+	}
+	ps := newScheduler()
+	return func(sch *scheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+		return sch.selectPathCustom(ps, s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
+	}, nil
+}
+
+func (sch *scheduler) getPathInfo(pth *path) PathInfo {
+	_, retransmissions, losses := pth.sentPacketHandler.GetStatistics()
+	info := PathInfo{
+		ID:                pth.pathID,
+		Active:            pth.active.Get(),
+		Validated:         pth.validated.Get(),
+		SmoothedRTT:       pth.rttStats.SmoothedRTT(),
+		CongestionWindow:  pth.sentPacketHandler.GetSendAlgorithm().GetCongestionWindow(),
+		BytesInFlight:     pth.sentPacketHandler.GetBytesInFlight(),
+		PacketsSent:       uint(pth.scheduledPackets.Get()),
+		SendingAllowed:    pth.SendingAllowed(),
+		Backup:            pth.backup.Get(),
+		PotentiallyFailed: pth.potentiallyFailed.Get(),
+		Failed:            pth.failed.Get(),
+		FacedRTO:          pth.facedRTO.Get(),
+		FEC:               pth.fec != nil && pth.fec.Get(),
+		Retransmissions:   retransmissions,
+		PacketsLost:       losses,
+		Reinjections:      pth.sentPacketHandler.GetReinjections(),
+	}
+	if pth.conn != nil {
+		info.LocalAddr = pth.conn.LocalAddr()
+		info.RemoteAddr = pth.conn.RemoteAddr()
+	}
+	return info
+}
+
+// getPathInfos returns the PathInfo of every path, sorted by ID
+func (sch *scheduler) getPathInfos(paths map[protocol.PathID]*path) []PathInfo {
+	infos := make([]PathInfo, 0, len(paths))
+	for _, pth := range paths {
+		infos = append(infos, sch.getPathInfo(pth))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// selectPathCustom asks an application-provided PathScheduler for a path
+func (sch *scheduler) selectPathCustom(ps PathScheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+	paths := s.GetPaths()
+	infos := sch.getPathInfos(paths)
+
+	fromPathID := protocol.PathID(protocol.InitialPathID)
+	if fromPth != nil {
+		fromPathID = fromPth.pathID
+	}
+	pathID, ok := ps.SelectPath(infos, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPathID)
+	if !ok {
+		return nil
+	}
+	pth, ok := paths[pathID]
+	if !ok || !pth.active.Get() {
+		return nil
+	}
+	return pth
+}
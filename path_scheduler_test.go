@@ -0,0 +1,352 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/ackhandler"
+	"github.com/lucas-clemente/quic-go/congestion"
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// schedulerTestSession only implements what the schedulers need
+type schedulerTestSession struct {
+	sessionI
+	paths        map[protocol.PathID]*path
+	remoteRTTs   map[protocol.PathID]time.Duration
+	streamFramer *streamFramer
+	fecFramer    *FECFramer
+}
+
+func (s *schedulerTestSession) GetPaths() map[protocol.PathID]*path           { return s.paths }
+func (s *schedulerTestSession) RemoteRTTs() map[protocol.PathID]time.Duration { return s.remoteRTTs }
+func (s *schedulerTestSession) GetStreamFramer() *streamFramer                { return s.streamFramer }
+func (s *schedulerTestSession) GetFECFramer() *FECFramer                      { return s.fecFramer }
+
+func newSchedulerTestPath(pathID protocol.PathID, rtt time.Duration) *path {
+	rttStats := &congestion.RTTStats{}
+	if rtt != 0 {
+		rttStats.UpdateRTT(rtt, 0, time.Now())
+	}
+	pth := &path{
+		pathID:            pathID,
+		rttStats:          rttStats,
+		sentPacketHandler: ackhandler.NewSentPacketHandler(rttStats, nil, nil, nil, nil, false),
+		fec:               &utils.AtomicBool{},
+	}
+	pth.active.Set(true)
+	pth.validated.Set(true)
+	return pth
+}
+
+// fillCongestionWindow sends packets on the path until its congestion window is full
+func fillCongestionWindow(pth *path) {
+	var pn protocol.PacketNumber
+	for pth.SendingAllowed() {
+		pn++
+		err := pth.sentPacketHandler.SentPacket(&ackhandler.Packet{
+			PacketNumber: pn,
+			Frames:       []wire.Frame{&wire.PingFrame{}},
+			Length:       protocol.MaxPacketSize,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
+
+type fixedPathScheduler struct {
+	pathID    PathID
+	lastPaths []PathInfo
+}
+
+func (f *fixedPathScheduler) SelectPath(paths []PathInfo, _ bool, _ bool, _ bool, _ PathID) (PathID, bool) {
+	f.lastPaths = paths
+	return f.pathID, true
+}
+
+var _ = Describe("Path Scheduler", func() {
+	var (
+		sch  *scheduler
+		sess *schedulerTestSession
+	)
+
+	BeforeEach(func() {
+		sch = &scheduler{}
+		sch.setup()
+		sess = &schedulerTestSession{
+			paths: map[protocol.PathID]*path{
+				0: newSchedulerTestPath(0, 0),
+				1: newSchedulerTestPath(1, 50*time.Millisecond),
+				2: newSchedulerTestPath(2, 10*time.Millisecond),
+			},
+			remoteRTTs:   make(map[protocol.PathID]time.Duration),
+			streamFramer: newStreamFramer(nil, &streamsMap{}, nil, false),
+			fecFramer:    newFECFramer(nil, protocol.VersionWhatever),
+		}
+	})
+
+	It("uses the lowest latency scheduler by default", func() {
+		policy, err := getPathSelector("")
+		Expect(err).ToNot(HaveOccurred())
+		sch.policy = policy
+		Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+	})
+
+	It("selects built-in schedulers by name", func() {
+		policy, err := getPathSelector(SchedulerRoundRobin)
+		Expect(err).ToNot(HaveOccurred())
+		sch.policy = policy
+		sch.quotas[1] = 0
+		sch.quotas[2] = 3
+		Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+	})
+
+	It("errors for unknown schedulers", func() {
+		_, err := getPathSelector("foobar")
+		Expect(err).To(MatchError("there is no path scheduler named foobar"))
+	})
+
+	It("doesn't allow overriding built-in schedulers", func() {
+		err := RegisterPathScheduler(SchedulerLowLatency, func() PathScheduler { return &fixedPathScheduler{} })
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("custom schedulers", func() {
+		var custom *fixedPathScheduler
+
+		BeforeEach(func() {
+			custom = &fixedPathScheduler{pathID: 1}
+			err := RegisterPathScheduler("fixed", func() PathScheduler { return custom })
+			Expect(err).ToNot(HaveOccurred())
+			policy, err := getPathSelector("fixed")
+			Expect(err).ToNot(HaveOccurred())
+			sch.policy = policy
+		})
+
+		It("uses the path selected by the custom scheduler", func() {
+			sess.paths[1].scheduledPackets.Set(7)
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+			Expect(custom.lastPaths).To(HaveLen(3))
+			for i, info := range custom.lastPaths {
+				Expect(info.ID).To(Equal(PathID(i)))
+			}
+			Expect(custom.lastPaths[1].PacketsSent).To(Equal(uint(7)))
+			Expect(custom.lastPaths[1].SmoothedRTT).To(Equal(50 * time.Millisecond))
+			Expect(custom.lastPaths[1].SendingAllowed).To(BeTrue())
+			Expect(custom.lastPaths[1].CongestionWindow).ToNot(BeZero())
+		})
+
+		It("returns no path if the selected path doesn't exist", func() {
+			custom.pathID = 42
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+		})
+
+		It("returns no path if the selected path is inactive", func() {
+			sess.paths[1].active.Set(false)
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+		})
+	})
+
+	Context("backup paths", func() {
+		BeforeEach(func() {
+			sess.paths[2].backup.Set(true)
+		})
+
+		It("doesn't send on backup paths while a primary path works", func() {
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+		})
+
+		It("waits for the primary paths when their congestion window is full", func() {
+			fillCongestionWindow(sess.paths[1])
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+		})
+
+		It("hides the backup paths from every scheduler", func() {
+			custom := &fixedPathScheduler{pathID: 2}
+			sch.policy = func(sch *scheduler, s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+				return sch.selectPathCustom(custom, s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
+			}
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+			Expect(custom.lastPaths).To(HaveLen(2))
+		})
+
+		It("uses the backup paths when all primary paths are potentially failed", func() {
+			sess.paths[1].potentiallyFailed.Set(true)
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+	})
+
+	Context("path validation", func() {
+		BeforeEach(func() {
+			sess.paths[2].startValidation()
+		})
+
+		It("doesn't send data on unvalidated paths", func() {
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+			Expect(sch.getPathInfos(sess.paths)[2].Validated).To(BeFalse())
+		})
+
+		It("sends data once the peer answered the challenge", func() {
+			f := sess.paths[2].maybeGetPathChallenge(time.Now())
+			Expect(f).ToNot(BeNil())
+			sess.paths[2].handlePathResponse(&wire.PathResponseFrame{Data: f.Data})
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+	})
+
+	Context("failure detection", func() {
+		It("excludes potentially failed paths while another path works", func() {
+			sess.paths[2].setPotentiallyFailed(time.Now())
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+			sess.paths[1].setPotentiallyFailed(time.Now())
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+
+		It("doesn't send on failed paths", func() {
+			sess.paths[2].failed.Set(true)
+			sess.paths[1].setPotentiallyFailed(time.Now())
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+			Expect(sch.getPathInfos(sess.paths)[2].Failed).To(BeTrue())
+		})
+
+		It("keeps a path schedulable after a single RTO", func() {
+			Expect(sess.paths[2].onRTO(time.Now())).To(BeTrue())
+			Expect(schedulablePaths(sess).GetPaths()).To(HaveKey(protocol.PathID(2)))
+		})
+
+		It("sends again on a recovered path", func() {
+			for i := 0; i < maxConsecutiveRTOs; i++ {
+				sess.paths[2].onRTO(time.Now())
+			}
+			Expect(schedulablePaths(sess).GetPaths()).ToNot(HaveKey(protocol.PathID(2)))
+			sess.paths[2].recovered()
+			Expect(schedulablePaths(sess).GetPaths()).To(HaveKey(protocol.PathID(2)))
+		})
+	})
+
+	Context("reinjection", func() {
+		BeforeEach(func() {
+			fillCongestionWindow(sess.paths[1])
+		})
+
+		It("reinjects the packets of potentially failed paths", func() {
+			sess.paths[1].potentiallyFailed.Set(true)
+			sch.reinjectFromDegradedPaths(sess)
+			Expect(sess.paths[1].sentPacketHandler.GetBytesInFlight()).To(BeZero())
+			Expect(sess.paths[1].sentPacketHandler.GetReinjections()).ToNot(BeZero())
+			Expect(sess.paths[1].sentPacketHandler.DequeuePacketForRetransmission()).ToNot(BeNil())
+			Expect(sch.selectPath(sess, true, false, false, sess.paths[1]).pathID).To(Equal(protocol.PathID(2)))
+		})
+
+		It("reports the reinjections in the path info", func() {
+			sess.paths[1].active.Set(false)
+			sch.reinjectFromDegradedPaths(sess)
+			infos := sch.getPathInfos(sess.paths)
+			Expect(infos).To(HaveLen(3))
+			Expect(infos[1].ID).To(Equal(PathID(1)))
+			Expect(infos[1].Active).To(BeFalse())
+			Expect(infos[1].Reinjections).ToNot(BeZero())
+			Expect(infos[1].BytesInFlight).To(BeZero())
+			Expect(infos[1].LocalAddr).To(BeNil())
+			Expect(infos[2].Reinjections).To(BeZero())
+		})
+
+		It("reinjects the packets of inactive paths", func() {
+			sess.paths[1].active.Set(false)
+			sch.reinjectFromDegradedPaths(sess)
+			Expect(sess.paths[1].sentPacketHandler.GetBytesInFlight()).To(BeZero())
+		})
+
+		It("doesn't reinject the packets of working paths", func() {
+			sch.reinjectFromDegradedPaths(sess)
+			Expect(sess.paths[1].sentPacketHandler.GetReinjections()).To(BeZero())
+		})
+
+		It("doesn't reinject if no other path is usable", func() {
+			sess.paths[1].potentiallyFailed.Set(true)
+			sess.paths[2].potentiallyFailed.Set(true)
+			sch.reinjectFromDegradedPaths(sess)
+			Expect(sess.paths[1].sentPacketHandler.GetReinjections()).To(BeZero())
+		})
+	})
+
+	Context("FEC path policy", func() {
+		BeforeEach(func() {
+			sess.paths[3] = newSchedulerTestPath(3, 100*time.Millisecond)
+			sess.fecFramer.pushRepairSymbols([]*fec.RepairSymbol{{SourcePathID: 3}})
+		})
+
+		It("sends the repair symbols on the dedicated FEC path", func() {
+			sess.paths[3].fec.Set(true)
+			Expect(sch.selectPath(sess, false, false, true, nil).pathID).To(Equal(protocol.PathID(3)))
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+
+		It("doesn't send data on the dedicated FEC path", func() {
+			sess.paths[1].fec.Set(true)
+			sess.paths[2].potentiallyFailed.Set(true)
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(3)))
+		})
+
+		It("sends the repair symbols on the path of their source symbols", func() {
+			sch.fecPathPolicy = FECPathSame
+			Expect(sch.selectPath(sess, false, false, true, nil).pathID).To(Equal(protocol.PathID(3)))
+		})
+
+		It("sends the repair symbols on the path with the lowest loss rate", func() {
+			sch.fecPathPolicy = FECPathLowestLoss
+			Expect(sch.selectPath(sess, false, false, true, nil).pathID).To(Equal(protocol.PathID(1)))
+			fillCongestionWindow(sess.paths[1])
+			Expect(sch.selectPath(sess, false, false, true, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+
+		It("spreads the repair symbols across the paths", func() {
+			sch.fecPathPolicy = FECPathSpread
+			var pathIDs []protocol.PathID
+			for i := 0; i < 6; i++ {
+				pathIDs = append(pathIDs, sch.selectPath(sess, false, false, true, nil).pathID)
+			}
+			Expect(pathIDs).To(Equal([]protocol.PathID{1, 2, 3, 1, 2, 3}))
+		})
+	})
+
+	Context("ECF", func() {
+		BeforeEach(func() {
+			sch.policy = builtinPathSchedulers[SchedulerECF]
+		})
+
+		It("uses the fastest path when it can send", func() {
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+		})
+
+		It("uses the slow path when there is little data to send", func() {
+			fillCongestionWindow(sess.paths[2])
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(1)))
+		})
+
+		It("waits for the fast path when there is a lot of data to send", func() {
+			fillCongestionWindow(sess.paths[2])
+			sess.streamFramer.AddFrameForRetransmission(&wire.StreamFrame{Data: make([]byte, 100000)})
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+			Expect(sch.ecfWaiting).To(BeTrue())
+		})
+
+		It("never delays retransmissions", func() {
+			fillCongestionWindow(sess.paths[2])
+			sess.streamFramer.AddFrameForRetransmission(&wire.StreamFrame{Data: make([]byte, 100000)})
+			Expect(sch.selectPath(sess, true, true, false, sess.paths[2])).ToNot(BeNil())
+		})
+
+		It("uses the RTTs announced by the peer for unprobed paths", func() {
+			sess.paths[3] = newSchedulerTestPath(3, 0)
+			fillCongestionWindow(sess.paths[3])
+			sess.streamFramer.AddFrameForRetransmission(&wire.StreamFrame{Data: make([]byte, 100000)})
+			Expect(sch.selectPath(sess, false, false, false, nil).pathID).To(Equal(protocol.PathID(2)))
+			sess.remoteRTTs[3] = time.Millisecond
+			Expect(sch.selectPath(sess, false, false, false, nil)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,241 @@
+package quic
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/ackhandler"
+	"github.com/lucas-clemente/quic-go/congestion"
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// pathTestSession only implements what the paths and the path manager need
+type pathTestSession struct {
+	sessionI
+	paths               map[protocol.PathID]*path
+	pathsLock           sync.RWMutex
+	pathManager         *pathManager
+	unpacker            unpacker
+	pathsFrameScheduled bool
+	pings               []*path
+}
+
+func (s *pathTestSession) GetPaths() map[protocol.PathID]*path   { return s.paths }
+func (s *pathTestSession) PathsLock() *sync.RWMutex              { return &s.pathsLock }
+func (s *pathTestSession) PathManager() *pathManager             { return s.pathManager }
+func (s *pathTestSession) GetCryptoSetup() handshake.CryptoSetup { return nil }
+func (s *pathTestSession) GetPerspective() protocol.Perspective  { return protocol.PerspectiveClient }
+func (s *pathTestSession) IsHandshakeComplete() bool             { return true }
+func (s *pathTestSession) GetVersion() protocol.VersionNumber    { return protocol.VersionMP }
+func (s *pathTestSession) GetUnpacker() unpacker                 { return s.unpacker }
+func (s *pathTestSession) SchedulePathsFrame()                   { s.pathsFrameScheduled = true }
+func (s *pathTestSession) scheduleSending()                      {}
+func (s *pathTestSession) SendPing(pth *path) error {
+	s.pings = append(s.pings, pth)
+	return nil
+}
+
+// ackOnlyUnpacker unpacks every packet as a packet only acknowledging the packet 1
+type ackOnlyUnpacker struct{}
+
+func (ackOnlyUnpacker) Unpack(_ []byte, _ *wire.Header, _ []byte, _ bool) (*unpackedPacket, error) {
+	return &unpackedPacket{
+		encryptionLevel: protocol.EncryptionForwardSecure,
+		frames:          []wire.Frame{&wire.AckFrame{LargestAcked: 1, LowestAcked: 1}},
+	}, nil
+}
+
+func newTestPath(sess sessionI, pathID protocol.PathID) *path {
+	rttStats := &congestion.RTTStats{}
+	pth := &path{
+		pathID:                pathID,
+		sess:                  sess,
+		rttStats:              rttStats,
+		sentPacketHandler:     ackhandler.NewSentPacketHandler(rttStats, nil, nil, nil, nil, false),
+		receivedPacketHandler: ackhandler.NewReceivedPacketHandler(protocol.VersionMP, false),
+		redundancyController:  fec.NewConstantRedundancyController(10, 1, 1, 1),
+		fec:                   &utils.AtomicBool{},
+	}
+	pth.active.Set(true)
+	pth.validated.Set(true)
+	return pth
+}
+
+// burstAwareTestController records the burst estimators that the paths give it
+type burstAwareTestController struct {
+	fec.RedundancyController
+	estimators map[protocol.PathID]fec.BurstEstimator
+}
+
+func (c *burstAwareTestController) SetBurstEstimator(pathID protocol.PathID, e fec.BurstEstimator) {
+	c.estimators[pathID] = e
+}
+
+var _ = Describe("Path", func() {
+	var (
+		sess *pathTestSession
+		pth  *path
+	)
+
+	BeforeEach(func() {
+		sess = &pathTestSession{paths: make(map[protocol.PathID]*path)}
+		pth = newTestPath(sess, 1)
+		sess.paths[1] = pth
+	})
+
+	Context("validation", func() {
+		BeforeEach(func() {
+			pth.startValidation()
+		})
+
+		It("is validated once the peer answered the challenge", func() {
+			Expect(pth.validated.Get()).To(BeFalse())
+			f := pth.maybeGetPathChallenge(time.Now())
+			Expect(f).ToNot(BeNil())
+			pth.handlePathResponse(&wire.PathResponseFrame{Data: f.Data})
+			Expect(pth.validated.Get()).To(BeTrue())
+			Expect(pth.maybeGetPathChallenge(time.Now().Add(time.Hour))).To(BeNil())
+		})
+
+		It("ignores responses to other challenges", func() {
+			f := pth.maybeGetPathChallenge(time.Now())
+			Expect(f).ToNot(BeNil())
+			f.Data[0]++
+			pth.handlePathResponse(&wire.PathResponseFrame{Data: f.Data})
+			Expect(pth.validated.Get()).To(BeFalse())
+		})
+
+		It("backs off the challenges and gives up eventually", func() {
+			now := time.Now()
+			Expect(pth.maybeGetPathChallenge(now)).ToNot(BeNil())
+			Expect(pth.maybeGetPathChallenge(now)).To(BeNil())
+			Expect(pth.maybeGetPathChallenge(now.Add(pathChallengeTimeout))).ToNot(BeNil())
+			Expect(pth.maybeGetPathChallenge(now.Add(2 * pathChallengeTimeout))).To(BeNil())
+			for i := 0; i < maxPathChallenges; i++ {
+				now = now.Add(time.Minute)
+				pth.maybeGetPathChallenge(now)
+			}
+			Expect(pth.maybeGetPathChallenge(now.Add(time.Hour))).To(BeNil())
+		})
+
+		It("fails if it could not be validated", func() {
+			now := time.Now()
+			for i := 0; i <= maxPathChallenges; i++ {
+				pth.maybeGetPathChallenge(now)
+				now = now.Add(time.Hour)
+			}
+			Expect(pth.failed.Get()).To(BeTrue())
+			Expect(pth.active.Get()).To(BeFalse())
+			Expect(pth.state()).To(Equal(pathStateFailed))
+		})
+	})
+
+	Context("failure detection", func() {
+		It("fails after consecutive RTOs without activity", func() {
+			for i := 0; i < maxConsecutiveRTOs-1; i++ {
+				Expect(pth.onRTO(time.Now())).To(BeTrue())
+			}
+			Expect(pth.state()).To(Equal(pathStatePotentiallyFailed))
+			Expect(pth.onRTO(time.Now())).To(BeTrue())
+			Expect(pth.state()).To(Equal(pathStateFailed))
+		})
+
+		It("stays active after a single RTO", func() {
+			Expect(pth.onRTO(time.Now())).To(BeTrue())
+			Expect(pth.state()).To(Equal(pathStateActive))
+			Expect(pth.onRTO(time.Now())).To(BeTrue())
+			Expect(pth.state()).To(Equal(pathStatePotentiallyFailed))
+		})
+
+		It("doesn't fail with recent activity", func() {
+			pth.lastNetworkActivityTime = time.Now()
+			Expect(pth.onRTO(time.Now().Add(-time.Second))).To(BeFalse())
+			Expect(pth.state()).To(Equal(pathStateActive))
+		})
+
+		It("detects missing ACKs", func() {
+			now := time.Now()
+			pth.rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+			pth.lastNetworkActivityTime = now.Add(-time.Second)
+			err := pth.sentPacketHandler.SentPacket(&ackhandler.Packet{
+				PacketNumber: 1,
+				Frames:       []wire.Frame{&wire.PingFrame{}},
+				Length:       100,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pth.maybeProbe(now)).To(BeFalse())
+			Expect(pth.maybeProbe(now.Add(time.Second))).To(BeTrue())
+			Expect(pth.state()).To(Equal(pathStatePotentiallyFailed))
+		})
+
+		It("probes when potentially failed, with a backoff", func() {
+			now := time.Now()
+			pth.setPotentiallyFailed(now)
+			Expect(pth.maybeProbe(now)).To(BeTrue())
+			Expect(pth.maybeProbe(now.Add(minPathProbeInterval / 2))).To(BeFalse())
+			Expect(pth.maybeProbe(now.Add(minPathProbeInterval))).To(BeTrue())
+			Expect(pth.maybeProbe(now.Add(2 * minPathProbeInterval))).To(BeFalse())
+			Expect(pth.maybeProbe(now.Add(3 * minPathProbeInterval))).To(BeTrue())
+		})
+
+		It("recovers when receiving on the path", func() {
+			for i := 0; i < maxConsecutiveRTOs; i++ {
+				pth.onRTO(time.Now())
+			}
+			pth.recovered()
+			Expect(pth.state()).To(Equal(pathStateActive))
+			Expect(pth.wasPotentiallyFailed.Get()).To(BeTrue())
+			Expect(pth.maybeProbe(time.Now())).To(BeFalse())
+		})
+
+		It("sets the path timer while the session probes the path", func() {
+			pth.timer = utils.NewTimer()
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				for i := 0; i < 100; i++ {
+					pth.maybeResetTimer()
+				}
+				close(done)
+			}()
+			for i := 0; i < 100; i++ {
+				now := time.Now()
+				pth.setPotentiallyFailed(now)
+				pth.maybeProbe(now)
+				pth.recovered()
+			}
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Context("burst estimation", func() {
+		It("gives the burst estimator of every path to a shared redundancy controller", func() {
+			rc := &burstAwareTestController{
+				RedundancyController: fec.NewConstantRedundancyController(10, 1, 1, 1),
+				estimators:           make(map[protocol.PathID]fec.BurstEstimator),
+			}
+			sess.paths[protocol.InitialPathID] = newTestPath(sess, protocol.InitialPathID)
+			sess.paths[2] = newTestPath(sess, 2)
+			olia := congestion.NewOliaSender(make(map[protocol.PathID]*congestion.OliaSender), sess.paths[2].rttStats, protocol.InitialCongestionWindow, protocol.DefaultMaxCongestionWindow)
+			sess.paths[protocol.InitialPathID].setBurstEstimator(nil)
+			sess.paths[1].setBurstEstimator(nil)
+			sess.paths[2].setBurstEstimator(olia)
+			for _, p := range sess.paths {
+				p.setRedundancyController(rc)
+			}
+			Expect(rc.estimators).To(HaveLen(3))
+			// the initial path has no OLIA, it estimates its bursts itself
+			Expect(rc.estimators[protocol.InitialPathID]).To(BeIdenticalTo(sess.paths[protocol.InitialPathID].lossBurstEstimator))
+			Expect(rc.estimators[1]).To(BeIdenticalTo(sess.paths[1].lossBurstEstimator))
+			Expect(rc.estimators[1]).ToNot(BeIdenticalTo(rc.estimators[protocol.InitialPathID]))
+			Expect(rc.estimators[2]).To(BeIdenticalTo(olia))
+			Expect(sess.paths[2].lossBurstEstimator).To(BeNil())
+		})
+	})
+})
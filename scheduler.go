@@ -24,10 +24,19 @@ type scheduler struct {
 	quotas               map[protocol.PathID]uint
 	lossRateScheduler    *lossBasedScheduler
 	redundancyController fec.RedundancyController
+	// The scheduling policy, selected by Config.Scheduler
+	policy pathSelector
+	// ECF hysteresis: true if the last decision was to wait for the fastest path
+	ecfWaiting bool
+	// The path on which the repair symbols are sent, selected by Config.FECPathPolicy
+	fecPathPolicy FECPathPolicy
+	// Number of times a path was selected to carry repair symbols, for FECPathSpread
+	fecQuotas map[protocol.PathID]uint
 }
 
 func (sch *scheduler) setup() {
 	sch.quotas = make(map[protocol.PathID]uint)
+	sch.fecQuotas = make(map[protocol.PathID]uint)
 	if sch.redundancyController != nil {
 		sch.lossRateScheduler = newLossBasedScheduler(sch.quotas, sch.redundancyController.GetNumberOfRepairSymbols())
 	}
@@ -37,10 +46,10 @@ func (sch *scheduler) getRetransmission(s sessionI) (hasRetransmission bool, ret
 	// check for retransmissions first
 	// 处理重传队列所有的包，直到没有重传数据包
 	for {
-		// TODO add ability to reinject on another path
 		// XXX We need to check on ALL paths if any packet should be first retransmitted
+		// Packets of degraded paths were reinjected by reinjectFromDegradedPaths, selectPath moves them to another path
 	retransmitLoop:
-		for _, pthTmp := range s.Paths() {
+		for _, pthTmp := range s.GetPaths() {
 			// 取出第一个数据包用于重传并返回该数据包,重传统计加一,在scheduler调用
 			retransmitPacket = pthTmp.sentPacketHandler.DequeuePacketForRetransmission()
 			if retransmitPacket != nil {
@@ -73,6 +82,8 @@ func (sch *scheduler) getRetransmission(s sessionI) (hasRetransmission bool, ret
 			case *wire.PathsFrame:
 				// Schedule a new PATHS frame to send
 				s.SchedulePathsFrame()
+			case *wire.PathChallengeFrame, *wire.PathResponseFrame:
+				// Only meaningful on their path, the path validation sends new ones if needed
 			case *wire.FECFrame:
 			default:
 				s.GetPacker().QueueControlFrame(frame, pth)
@@ -82,12 +93,35 @@ func (sch *scheduler) getRetransmission(s sessionI) (hasRetransmission bool, ret
 	return
 }
 
+// pathDegraded returns true if the packets in flight on pth are unlikely to be delivered in time
+func pathDegraded(pth *path) bool {
+	return !pth.active.Get() || pth.potentiallyFailed.Get()
+}
+
+// reinjectFromDegradedPaths queues the packets in flight on degraded paths for retransmission,
+// such that they are sent on the remaining paths instead of waiting for the timers of the degraded paths.
+func (sch *scheduler) reinjectFromDegradedPaths(s sessionI) {
+	paths := s.GetPaths()
+	for _, pth := range paths {
+		if !pathDegraded(pth) || pth.sentPacketHandler.GetBytesInFlight() == 0 {
+			continue
+		}
+		for pathID, tmpPth := range paths {
+			if pathID != protocol.InitialPathID && tmpPth != pth && !pathDegraded(tmpPth) && tmpPth.validated.Get() {
+				utils.Debugf("Reinjecting packets in flight of degraded path %d", pth.pathID)
+				pth.sentPacketHandler.ReinjectPacketsInFlight()
+				break
+			}
+		}
+	}
+}
+
 func (sch *scheduler) selectPathRoundRobin(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
 	if sch.quotas == nil {
 		sch.setup()
 	}
 
-	paths := s.Paths()
+	paths := s.GetPaths()
 
 	// XXX Avoid using PathID 0 if there is more than 1 path
 	if len(paths) <= 1 {
@@ -148,7 +182,7 @@ pathLoop:
 }
 
 func (sch *scheduler) selectPathLowLatency(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, fromPth *path) *path {
-	paths := s.Paths()
+	paths := s.GetPaths()
 	// XXX Avoid using PathID 0 if there is more than 1 path
 	if len(paths) <= 1 {
 		if !hasRetransmission && !paths[protocol.InitialPathID].SendingAllowed() {
@@ -261,8 +295,81 @@ pathLoop:
 	return selectedPath
 }
 
+// ecfBeta is the hysteresis factor of the ECF scheduler
+const ecfBeta = 0.25
+
+// pathRTT returns the smoothed RTT of the path, or the RTT announced by the peer
+// in a PATHS frame if we did not measure it yet
+func (sch *scheduler) pathRTT(s sessionI, pth *path) time.Duration {
+	if rtt := pth.rttStats.SmoothedRTT(); rtt != 0 {
+		return rtt
+	}
+	return s.RemoteRTTs()[pth.pathID]
+}
+
+// selectPathECF implements the Earliest Completion First scheduler (Lim et al., CoNEXT 2017).
+// When the fastest path has no room in its congestion window, the data is only sent on a
+// slower path if it would be delivered earlier there than by waiting for the fastest path.
+// Otherwise, no path is returned and the data waits in the send buffer.
+func (sch *scheduler) selectPathECF(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
+	selectedPath := sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
+	// Never delay retransmissions, and let the lowest latency scheduler probe new paths
+	if selectedPath == nil || hasRetransmission || selectedPath.rttStats.SmoothedRTT() == 0 {
+		return selectedPath
+	}
+
+	// Find the fastest path, even if its congestion window is full
+	var fastestPath *path
+	var fastestRTT time.Duration
+	for pathID, pth := range s.GetPaths() {
+		if pathID == protocol.InitialPathID || !pth.active.Get() || pth.potentiallyFailed.Get() {
+			continue
+		}
+		if pth.backup.Get() && !selectedPath.backup.Get() {
+			continue
+		}
+		rtt := sch.pathRTT(s, pth)
+		if rtt == 0 {
+			continue
+		}
+		if fastestPath == nil || rtt < fastestRTT {
+			fastestPath = pth
+			fastestRTT = rtt
+		}
+	}
+	if fastestPath == nil || fastestPath == selectedPath {
+		sch.ecfWaiting = false
+		return selectedPath
+	}
+
+	rttF := float64(fastestRTT)
+	rttS := float64(selectedPath.rttStats.SmoothedRTT())
+	cwndF := float64(fastestPath.sentPacketHandler.GetSendAlgorithm().GetCongestionWindow())
+	cwndS := float64(selectedPath.sentPacketHandler.GetSendAlgorithm().GetCongestionWindow())
+	delta := float64(utils.MaxDuration(fastestPath.rttStats.MeanDeviation(), selectedPath.rttStats.MeanDeviation()))
+	// Amount of data waiting to be sent
+	k := float64(s.GetStreamFramer().LenOfDataToSend())
+
+	beta := 0.
+	if sch.ecfWaiting {
+		beta = ecfBeta
+	}
+	// Rounds needed to send everything on the fastest path
+	n := 1 + k/cwndF
+	if n*rttF < (1+beta)*(rttS+delta) {
+		if (k/cwndS)*rttS >= 2*rttF+delta {
+			// Waiting for the fastest path completes earlier
+			sch.ecfWaiting = true
+			return nil
+		}
+		return selectedPath
+	}
+	sch.ecfWaiting = false
+	return selectedPath
+}
+
 func (sch *scheduler) selectPathHighestRemainingBytes(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
-	paths := s.Paths()
+	paths := s.GetPaths()
 	// XXX Avoid using PathID 0 if there is more than 1 path
 	if len(paths) <= 1 {
 		if !hasRetransmission && !paths[protocol.InitialPathID].SendingAllowed() {
@@ -415,7 +522,7 @@ pathLoop:
 
 func (sch *scheduler) selectPathLowestLossRate(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
 
-	paths := s.Paths()
+	paths := s.GetPaths()
 	// XXX Avoid using PathID 0 if there is more than 1 path
 	if len(paths) <= 1 {
 		if !hasRetransmission && !paths[protocol.InitialPathID].SendingAllowed() {
@@ -536,21 +643,175 @@ pathLoop:
 	return selectedPath
 }
 
+// schedulablePathsView hides from the scheduling policies the paths that must not carry data
+type schedulablePathsView struct {
+	sessionI
+	paths map[protocol.PathID]*path
+}
+
+func (v *schedulablePathsView) GetPaths() map[protocol.PathID]*path { return v.paths }
+
+// pathUnusable tells if pth must not carry data at all, because it is not validated yet, failed or was closed by the application
+func pathUnusable(pth *path) bool {
+	return !pth.validated.Get() || pth.failed.Get() || pth.closed.Get()
+}
+
+// schedulablePaths hides the paths of s that are not validated yet, failed or closed. Potentially failed
+// paths are hidden as long as another path works, and backup paths as long as a primary path works.
+// Hidden paths only carry probes and ACKs.
+func schedulablePaths(s sessionI) sessionI {
+	paths := s.GetPaths()
+	var hasHidden, hasDegraded, hasBackup, hasWorking, hasPrimary bool
+	for pathID, pth := range paths {
+		if pathID == protocol.InitialPathID && len(paths) > 1 {
+			continue
+		}
+		switch {
+		case pathUnusable(pth):
+			hasHidden = true
+		case pathDegraded(pth):
+			hasDegraded = true
+		default:
+			hasWorking = true
+			if pth.backup.Get() {
+				hasBackup = true
+			} else {
+				hasPrimary = true
+			}
+		}
+	}
+	hideDegraded := hasDegraded && hasWorking
+	hideBackups := hasBackup && hasPrimary
+	if !hasHidden && !hideDegraded && !hideBackups {
+		return s
+	}
+	schedulable := make(map[protocol.PathID]*path, len(paths))
+	for pathID, pth := range paths {
+		// Keep the initial path, the schedulers expect it
+		if pathID == protocol.InitialPathID {
+			schedulable[pathID] = pth
+			continue
+		}
+		if pathUnusable(pth) {
+			continue
+		}
+		if hideDegraded && pathDegraded(pth) {
+			continue
+		}
+		if hideBackups && pth.backup.Get() {
+			continue
+		}
+		schedulable[pathID] = pth
+	}
+	return &schedulablePathsView{sessionI: s, paths: schedulable}
+}
+
+// withoutFECPaths hides the paths dedicated to the repair symbols
+func withoutFECPaths(s sessionI) sessionI {
+	paths := s.GetPaths()
+	var hasFECPath bool
+	for _, pth := range paths {
+		if pth.fec != nil && pth.fec.Get() {
+			hasFECPath = true
+		}
+	}
+	if !hasFECPath {
+		return s
+	}
+	dataPaths := make(map[protocol.PathID]*path, len(paths))
+	for pathID, pth := range paths {
+		if pth.fec == nil || !pth.fec.Get() {
+			dataPaths[pathID] = pth
+		}
+	}
+	return &schedulablePathsView{sessionI: s, paths: dataPaths}
+}
+
+// pathLossRate returns the fraction of the packets sent on pth that were lost
+func pathLossRate(pth *path) float64 {
+	sent, _, lost := pth.sentPacketHandler.GetStatistics()
+	if sent == 0 {
+		return 0
+	}
+	return float64(lost) / float64(sent)
+}
+
+// selectFECPath returns the path that should carry the next repair symbols according to the FEC path policy,
+// or nil if the scheduling policy should decide
+func (sch *scheduler) selectFECPath(s sessionI) *path {
+	paths := s.GetPaths()
+	usable := func(pathID protocol.PathID, pth *path) bool {
+		// XXX Prevent using initial pathID if multiple paths
+		return (pathID != protocol.InitialPathID || len(paths) == 1) && pth.SendingAllowed()
+	}
+
+	switch sch.fecPathPolicy {
+	case FECPathDedicated:
+		for pathID, pth := range paths {
+			if pth.fec != nil && pth.fec.Get() && usable(pathID, pth) {
+				return pth
+			}
+		}
+	case FECPathSame:
+		pathID, ok := s.GetFECFramer().nextSourcePathID()
+		if !ok {
+			return nil
+		}
+		if pth, ok := paths[pathID]; ok && usable(pathID, pth) {
+			return pth
+		}
+	case FECPathLowestLoss:
+		var selectedPath *path
+		var lowerLossRate float64
+		for pathID, pth := range paths {
+			if !usable(pathID, pth) {
+				continue
+			}
+			lossRate := pathLossRate(pth)
+			if selectedPath == nil || lossRate < lowerLossRate || (lossRate == lowerLossRate && pathID < selectedPath.pathID) {
+				selectedPath = pth
+				lowerLossRate = lossRate
+			}
+		}
+		return selectedPath
+	case FECPathSpread:
+		var selectedPath *path
+		for pathID, pth := range paths {
+			if !usable(pathID, pth) {
+				continue
+			}
+			if selectedPath == nil || sch.fecQuotas[pathID] < sch.fecQuotas[selectedPath.pathID] ||
+				(sch.fecQuotas[pathID] == sch.fecQuotas[selectedPath.pathID] && pathID < selectedPath.pathID) {
+				selectedPath = pth
+			}
+		}
+		if selectedPath != nil {
+			sch.fecQuotas[selectedPath.pathID]++
+		}
+		return selectedPath
+	}
+	return nil
+}
+
 // Lock of s.paths must be held
 func (sch *scheduler) selectPath(s sessionI, hasRetransmission bool, hasStreamRetransmission bool, hasFECFrame bool, fromPth *path) *path {
-	// XXX Currently round-robin
-	// TODO select the right scheduler dynamically
 	if sch.lossRateScheduler != nil && sch.redundancyController != nil {
 		sch.lossRateScheduler.maxNumberOfRepairSymbols = sch.redundancyController.GetNumberOfRepairSymbols()
 	}
-	// return sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
-	path := sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
-	// path :=  sch.lossRateScheduler.selectPath(s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
-	//path :=  sch.selectPathRoundRobin(s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
-	//path :=  sch.selectPathLowestLossRate(s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
-	//path :=  sch.selectPathHighestRemainingBytes(s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
-
-	return path
+	s = schedulablePaths(s)
+	if hasFECFrame && !hasRetransmission {
+		if pth := sch.selectFECPath(s); pth != nil {
+			return pth
+		}
+	}
+	if sch.fecPathPolicy == FECPathDedicated {
+		// The dedicated FEC path only carries repair symbols
+		s = withoutFECPaths(s)
+	}
+	if sch.policy == nil {
+		return sch.selectPathLowLatency(s, hasRetransmission, hasStreamRetransmission, fromPth)
+	}
+	return sch.policy(sch, s, hasRetransmission, hasStreamRetransmission, hasFECFrame, fromPth)
 }
 
 // Lock of s.paths must be free (in case of log print)
@@ -560,12 +821,16 @@ func (sch *scheduler) performPacketSending(s sessionI, windowUpdateFrames []wire
 		s.GetPacker().QueueControlFrame(&wire.PingFrame{}, pth)
 	}
 
-	fecPayloadIDOfPacket := s.GetFECFrameworkSender().GetNextSourceFECPayloadID()
+	fecPayloadIDOfPacket := s.GetFECFrameworkSender().GetNextSourceFECPayloadID(pth.pathID)
 
 	packet, err := s.GetPacker().PackPacket(pth, fecPayloadIDOfPacket)
 	if err != nil || packet == nil {
 		return nil, false, err
 	}
+	if s.GetConfig().MultipathService == Redundant {
+		// an acknowledgment of any copy of the packet settles all of them
+		packet.copies = &ackhandler.PacketCopies{}
+	}
 	if err = s.sendPackedPacket(packet, pth); err != nil {
 		return nil, false, err
 	}
@@ -577,6 +842,7 @@ func (sch *scheduler) performPacketSending(s sessionI, windowUpdateFrames []wire
 
 	// Packet sent, so update its quota
 	sch.quotas[pth.pathID]++
+	pth.scheduledPackets.Increment(1)
 
 	// Provide some logging if it is the last packet
 	// modify by zhaolee
@@ -586,7 +852,7 @@ func (sch *scheduler) performPacketSending(s sessionI, windowUpdateFrames []wire
 	// 		if frame.FinBit {
 	// 			// Last packet to send on the stream, print stats
 	// 			utils.Infof("Info for stream %x of %x", frame.StreamID, s.GetConnectionID())
-	// 			for pathID, pth := range s.Paths() {
+	// 			for pathID, pth := range s.GetPaths() {
 	// 				sntPkts, sntRetrans, sntLost := pth.sentPacketHandler.GetStatistics()
 	// 				rcvPkts, recoveredPkts := pth.receivedPacketHandler.GetStatistics()
 	// 				utils.Infof("Path %x: sent %d retrans %d lost %d; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, rcvPkts, recoveredPkts)
@@ -601,6 +867,7 @@ func (sch *scheduler) performPacketSending(s sessionI, windowUpdateFrames []wire
 		Frames:          packet.frames,
 		Length:          protocol.ByteCount(len(packet.raw)),
 		EncryptionLevel: packet.encryptionLevel,
+		Copies:          packet.copies,
 	}
 
 	return pkt, true, nil
@@ -619,7 +886,10 @@ func (sch *scheduler) ackRemainingPaths(s sessionI, totalWindowUpdates []wire.Fr
 		windowUpdates = s.getWindowUpdates(s.GetPeerBlocked())
 	}
 	packer := s.GetPacker()
-	for _, pthTmp := range s.Paths() {
+	for _, pthTmp := range s.GetPaths() {
+		if pthTmp.closed.Get() {
+			continue
+		}
 		ackTmp := pthTmp.GetAckFrame()
 		for _, f := range windowUpdates {
 			packer.QueueControlFrame(f, pthTmp)
@@ -628,6 +898,10 @@ func (sch *scheduler) ackRemainingPaths(s sessionI, totalWindowUpdates []wire.Fr
 			if pthTmp.pathID == protocol.InitialPathID && ackTmp == nil {
 				continue
 			}
+			// Without ACK to send, the packet might carry data
+			if !pthTmp.validated.Get() && ackTmp == nil {
+				continue
+			}
 			swf := pthTmp.GetStopWaitingFrame(false)
 			if swf != nil {
 				packer.QueueControlFrame(swf, pthTmp)
@@ -640,7 +914,7 @@ func (sch *scheduler) ackRemainingPaths(s sessionI, totalWindowUpdates []wire.Fr
 				// Avoid internal error bug
 				packet, err = packer.PackAckPacket(pthTmp)
 			} else {
-				fpid := s.GetFECFrameworkSender().GetNextSourceFECPayloadID()
+				fpid := s.GetFECFrameworkSender().GetNextSourceFECPayloadID(pthTmp.pathID)
 				packet, err = packer.PackPacket(pthTmp, fpid)
 			}
 			if err != nil {
@@ -656,12 +930,45 @@ func (sch *scheduler) ackRemainingPaths(s sessionI, totalWindowUpdates []wire.Fr
 	return nil
 }
 
+// sendRedundantCopies sends a copy of pkt, just sent on pth, on every other usable path.
+// The receiver drops the copies arriving last as duplicate stream data. The copies are linked to pkt: once one of them is
+// acknowledged, the others are not retransmitted when they are lost.
+// Lock of s.paths must be free
+func (sch *scheduler) sendRedundantCopies(s sessionI, pkt *ackhandler.Packet, pth *path) error {
+	if !pkt.IsRetransmittable() {
+		return nil
+	}
+	for pathID, tmpPth := range s.GetPaths() {
+		if pathID == protocol.InitialPathID || pathID == pth.pathID {
+			continue
+		}
+		// Only copy on validated paths having the same backup state than the original path
+		if !tmpPth.active.Get() || !tmpPth.validated.Get() || tmpPth.potentiallyFailed.Get() || tmpPth.backup.Get() != pth.backup.Get() || !tmpPth.SendingAllowed() {
+			continue
+		}
+		packet, err := s.GetPacker().PackDuplicatePacket(pkt, tmpPth)
+		if err != nil {
+			return err
+		}
+		if packet == nil {
+			continue
+		}
+		packet.copies = pkt.Copies
+		if err = s.sendPackedPacket(packet, tmpPth); err != nil {
+			return err
+		}
+		sch.quotas[pathID]++
+		tmpPth.scheduledPackets.Increment(1)
+	}
+	return nil
+}
+
 // 在session中调用
 func (sch *scheduler) sendPacket(s sessionI) error {
 	var pth *path
 
 	// Update leastUnacked value of paths
-	for _, pthTmp := range s.Paths() {
+	for _, pthTmp := range s.GetPaths() {
 		pthTmp.SetLeastUnacked(pthTmp.sentPacketHandler.GetLeastUnacked())
 	}
 
@@ -677,13 +984,31 @@ func (sch *scheduler) sendPacket(s sessionI) error {
 	}
 
 	// Perform this check now to avoid doing lot of time the computation
-	for _, pthTmp := range s.Paths() {
+	for _, pthTmp := range s.GetPaths() {
 		if pthTmp.sentPacketHandler.ComputeRTOTimeout() > time.Duration(500)*time.Millisecond {
 			// Even if not
 			pthTmp.facedRTO.Set(true)
 		}
 	}
 
+	// Probe the paths being validated, and the potentially failed ones
+	now := time.Now()
+	for _, pthTmp := range s.GetPaths() {
+		if f := pthTmp.maybeGetPathChallenge(now); f != nil {
+			if err := s.SendPathValidationFrame(f, pthTmp); err != nil {
+				return err
+			}
+		}
+		if s.IsHandshakeComplete() && pthTmp.maybeProbe(now) {
+			if err := s.SendPing(pthTmp); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Don't let the packets of a failing path wait for its retransmission timers
+	sch.reinjectFromDegradedPaths(s)
+
 	// Repeatedly try sending until we don't have any more data, or run out of the congestion window
 	for {
 		// We first check for retransmissions
@@ -760,14 +1085,18 @@ func (sch *scheduler) sendPacket(s sessionI) error {
 			return sch.ackRemainingPaths(s, windowUpdates)
 		}
 
-		// Duplicate traffic when it was sent on an unknown performing path
-		// Don't do this if it is a retransmission to avoid looping here
-		// FIXME adapt for new paths coming during the connection
-		if !hasRetransmission && pth.rttStats.SmoothedRTT() == 0 {
+		if s.GetConfig().MultipathService == Redundant {
+			if err = sch.sendRedundantCopies(s, pkt, pth); err != nil {
+				return err
+			}
+		} else if !hasRetransmission && pth.rttStats.SmoothedRTT() == 0 {
+			// Duplicate traffic when it was sent on an unknown performing path
+			// Don't do this if it is a retransmission to avoid looping here
+			// FIXME adapt for new paths coming during the connection
 			currentQuota := sch.quotas[pth.pathID]
 			// Was the packet duplicated on all potential paths?
 		duplicateLoop:
-			for pathID, tmpPth := range s.Paths() {
+			for pathID, tmpPth := range s.GetPaths() {
 				if pathID == protocol.InitialPathID || pathID == pth.pathID {
 					continue
 				}
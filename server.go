@@ -200,6 +200,27 @@ func populateServerConfig(config *Config) *Config {
 		maxReceiveConnectionFlowControlWindow = protocol.DefaultMaxReceiveConnectionFlowControlWindowServer
 	}
 
+	numberOfFECPackets := config.NumberOfFECPackets
+	if numberOfFECPackets == 0 {
+		numberOfFECPackets = protocol.DefaultNumberOfFECPackets
+	}
+	numberOfRepairSymbols := config.NumberOfRepairSymbols
+	if numberOfRepairSymbols == 0 {
+		numberOfRepairSymbols = protocol.DefaultNumberOfRepairSymbols
+	}
+	numberOfInterleavedFECGroups := config.NumberOfInterleavedFECGroups
+	if numberOfInterleavedFECGroups == 0 {
+		numberOfInterleavedFECGroups = protocol.DefaultNumberOfInterleavedFECGroups
+	}
+	maxFECReceiveBufferSize := config.MaxFECReceiveBufferSize
+	if maxFECReceiveBufferSize == 0 {
+		maxFECReceiveBufferSize = uint64(protocol.DefaultMaxFECReceiveBufferSize)
+	}
+	convolutionalStepSize := config.ConvolutionalStepSize
+	if convolutionalStepSize == 0 {
+		convolutionalStepSize = protocol.DefaultConvolutionalStepSize
+	}
+
 	return &Config{
 		Versions:                              versions,
 		HandshakeTimeout:                      handshakeTimeout,
@@ -210,9 +231,19 @@ func populateServerConfig(config *Config) *Config {
 		MaxReceiveConnectionFlowControlWindow: maxReceiveConnectionFlowControlWindow,
 		MaxPathID:                             config.MaxPathID,
 		MultipathService:                      config.MultipathService,
+		Scheduler:                             config.Scheduler,
 		NotifyID:                              config.NotifyID,
 		FECScheme:                             config.FECScheme,
 		RedundancyController:                  config.RedundancyController,
+		NewRedundancyController:               config.NewRedundancyController,
+		NumberOfFECPackets:                    numberOfFECPackets,
+		NumberOfRepairSymbols:                 numberOfRepairSymbols,
+		NumberOfInterleavedFECGroups:          numberOfInterleavedFECGroups,
+		ConvolutionalStepSize:                 convolutionalStepSize,
+		ConvolutionalWindowSize:               config.ConvolutionalWindowSize,
+		FECProtectionLevels:                   config.FECProtectionLevels,
+		FECPathPolicy:                         config.FECPathPolicy,
+		MaxFECReceiveBufferSize:               maxFECReceiveBufferSize,
 		DisableFECRecoveredFrames:             config.DisableFECRecoveredFrames,
 		ProtectReliableStreamFrames:           config.ProtectReliableStreamFrames,
 		UseFastRetransmit:                     config.UseFastRetransmit,
@@ -74,14 +74,17 @@ type sessionI interface {
 	getWindowUpdates(force bool) []wire.Frame
 	IsHandshakeComplete() bool
 	PathManager() *pathManager
-	Paths() map[protocol.PathID]*path
+	GetPaths() map[protocol.PathID]*path
 	PathsLock() *sync.RWMutex
 	PathTimersChan() chan *path
+	RemoteRTTs() map[protocol.PathID]time.Duration
 	SchedulePathsFrame()
 	sendPackedPacket(packet *packedPacket, pth *path) error
 	SendPing(pth *path) error
+	SendPathValidationFrame(f wire.Frame, pth *path) error
 	SetPeerBlocked(peerBlocked bool)
 	onHasFECData()
+	scheduleSending()
 }
 
 // A Session is a QUIC session
@@ -121,6 +124,8 @@ type session struct {
 	recoveredPackets chan *receivedPacket //addr header data rcvtime rcvconn recovered
 	sendingScheduled chan struct{}
 	fecScheduled     chan struct{}
+	// redundancyControllerChange passes the redundancy controllers set by the application to the run loop
+	redundancyControllerChange chan fec.RedundancyController
 	// closeChan is used to notify the run loop that it should terminate.
 	closeChan chan closeError
 	closeOnce sync.Once
@@ -165,13 +170,16 @@ type session struct {
 	scheduler *scheduler
 
 	// added by michelfra:
+	// the FEC receivers may be created by the run loop while FECStats reads them, so they are set under the lock
+	fecReceiversLock                  sync.RWMutex
 	fecFrameworkReceiver              *FECFrameworkReceiver
 	fecFrameworkReceiverConvolutional *FECFrameworkReceiverConvolutional
 	fecFrameworkSender                *FECFrameworkSender
-	fecScheduler                      fec.FECScheduler
 	receiverFECScheme                 fec.FECScheme
 	senderFECScheme                   fec.FECScheme
+	supportedFECSchemes               []protocol.FECSchemeID // the FEC schemes advertised to the peer
 	redundancyController              fec.RedundancyController
+	redundancyControllerLock          sync.RWMutex
 	ReceivedFECFrames                 []*wire.FECFrame //Received FEC frames not already handled
 	nRetransmissions                  uint64
 	bulkRecovery                      bool
@@ -263,6 +271,7 @@ func (s *session) setup(
 	s.closeChan = make(chan closeError, 1)
 	s.sendingScheduled = make(chan struct{}, 1)
 	s.fecScheduled = make(chan struct{}, 1)
+	s.redundancyControllerChange = make(chan fec.RedundancyController, 1)
 	s.undecryptablePackets = make([]*receivedPacket, 0, protocol.MaxUndecryptablePackets)
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 
@@ -279,33 +288,18 @@ func (s *session) setup(
 		CacheHandshake:              s.config.CacheHandshake,
 		MaxPathID:                   protocol.PathID(s.config.MaxPathID),
 		FECScheme:                   s.config.FECScheme,
+		SupportedFECSchemes:         fec.SupportedSchemes(),
 	}
-	s.scheduler = &scheduler{redundancyController: s.redundancyController}
-	s.scheduler.setup()
-
-	// s.redundancyController = fec.NewAverageRedundancyController()
-	if s.config.RedundancyController == nil {
-		// s.redundancyController = fec.NewConstantRedundancyController(
-		// 	uint(protocol.NumberOfFecPackets),
-		// 	uint(protocol.NumberOfRepairSymbols),
-		// 	uint(protocol.NumberOfInterleavedFECGroups),
-		// 	uint(protocol.ConvolutionalStepSize))
-
-		// s.redundancyController = fec.NewAverageRedundancyController(
-		// 	uint8(protocol.NumberOfFecPackets),
-		// 	protocol.NumberOfRepairSymbols)
-
-		// default redundancyController
-		utils.Infof("No redundancyController, Set NewrQuicRedundancyController as default")
-
-		s.redundancyController = fec.NewrQuicRedundancyController(
-			uint8(protocol.NumberOfFecPackets),
-			uint8(protocol.NumberOfRepairSymbols))
+	s.supportedFECSchemes = transportParams.SupportedFECSchemes
+	// The session-wide redundancy controller sizes the FEC structures shared by all paths
+	s.redundancyController = s.newRedundancyController()
 
-	} else {
-		s.redundancyController = s.config.RedundancyController
-		// log.Printf("have rc")
+	policy, err := getPathSelector(s.config.Scheduler)
+	if err != nil {
+		return nil, nil, err
 	}
+	s.scheduler = &scheduler{redundancyController: s.redundancyController, policy: policy, fecPathPolicy: s.config.FECPathPolicy}
+	s.scheduler.setup()
 
 	if pconnMgr == nil && conn != nil {
 		// XXX ONLY VALID FOR BENCHMARK!
@@ -314,10 +308,10 @@ func (s *session) setup(
 			sess:   s,
 			conn:   conn,
 		}
-		s.paths[protocol.InitialPathID].setup(nil, s.redundancyController)
+		s.paths[protocol.InitialPathID].setup(nil, s.newRedundancyController())
 	} else if pconnMgr != nil && conn != nil {
 		s.pathManager = &pathManager{pconnMgr: pconnMgr, sess: s}
-		s.pathManager.setup(conn, s.redundancyController)
+		s.pathManager.setup(conn, s.newRedundancyController)
 	} else {
 		panic("session without conn")
 	}
@@ -334,11 +328,9 @@ func (s *session) setup(
 	s.streamFramer = newStreamFramer(s.cryptoStream, s.streamsMap, s.connFlowController, s.config.ProtectReliableStreamFrames)
 
 	s.fecFramer = newFECFramer(s, s.version)
-	s.fecScheduler = fec.NewRoundRobinScheduler(s.redundancyController, s.version)
 
 	s.pathTimers = make(chan *path)
 
-	var err error
 	if s.perspective == protocol.PerspectiveServer {
 		verifySourceAddr := func(clientAddr net.Addr, cookie *Cookie) bool {
 			return s.config.AcceptCookie(clientAddr, cookie)
@@ -414,13 +406,15 @@ func (s *session) setup(
 	)
 	s.unpacker = &packetUnpacker{aead: s.cryptoSetup, version: s.version, sess: s}
 
+	s.fecReceiversLock.Lock()
 	if f, ok := s.receiverFECScheme.(fec.BlockFECScheme); ok {
 		s.fecFrameworkReceiver = NewFECFrameworkReceiver(s, f)
 	} else {
 		// TODO: use interface to not have two different types
 		s.fecFrameworkReceiverConvolutional = NewFECFrameworkReceiverConvolutional(s, s.receiverFECScheme.(fec.ConvolutionalFECScheme))
 	}
-	s.fecFrameworkSender = NewFECFrameworkSender(s.senderFECScheme, s.fecScheduler, s.fecFramer, s.redundancyController, s.version, s)
+	s.fecReceiversLock.Unlock()
+	s.fecFrameworkSender = NewFECFrameworkSender(s.senderFECScheme, s.fecFramer, s.redundancyController, s.version, s)
 	s.bulkRecovery = true
 
 	// s.paths[protocol.InitialPathID].sentPacketHandler
@@ -544,6 +538,8 @@ runLoop:
 			putPacketBuffer(p.header.Raw)
 		case p := <-s.paramsChan:
 			s.processTransportParameters(&p)
+		case c := <-s.redundancyControllerChange:
+			s.setRedundancyController(c)
 		case l, ok := <-aeadChanged:
 			if !ok { // the aeadChanged chan was closed. This means that the handshake is completed.
 				s.handshakeComplete = true
@@ -598,7 +594,8 @@ runLoop:
 					for _, path := range s.paths {
 						// TODO: we could assume that the One-Way Delay is 1/2*RTT and not duplicate a packet if it has
 						// already been sent for 1/2*RTT
-						if path != pathToReinject && path.rttStats.SmoothedRTT() < pathToReinject.rttStats.SmoothedRTT() {
+						// Backup paths are only used when the primary paths failed
+						if path != pathToReinject && path.rttStats.SmoothedRTT() < pathToReinject.rttStats.SmoothedRTT() && (!path.backup.Get() || pathToReinject.backup.Get()) {
 							pkt.Duplicated = true
 							path.sentPacketHandler.DuplicatePacket(pkt)
 						}
@@ -607,6 +604,11 @@ runLoop:
 			}
 		}
 
+		// the repair symbols protecting unreliable stream data are flushed before its deadline
+		if err := s.fecFrameworkSender.flushForDeadlines(now); err != nil {
+			s.closeLocal(err)
+		}
+
 		if err := s.sendPacket(); err != nil {
 			s.closeLocal(err)
 		}
@@ -665,6 +667,9 @@ func (s *session) maybeResetTimer() {
 	if !s.receivedTooManyUndecrytablePacketsTime.IsZero() {
 		deadline = utils.MinTime(deadline, s.receivedTooManyUndecrytablePacketsTime.Add(protocol.PublicResetTimeout))
 	}
+	if flushTime := s.fecFrameworkSender.nextDeadlineFlush(); !flushTime.IsZero() {
+		deadline = utils.MinTime(deadline, flushTime)
+	}
 
 	s.timer.Reset(deadline)
 }
@@ -711,6 +716,10 @@ func (s *session) handlePacketImpl(p *receivedPacket) error {
 				return err
 			}
 		}
+		if pth.closedByPeer.Get() {
+			// The peer uses the path again
+			pth.setupReusePath(s.pathManager.oliaSenders)
+		}
 
 	}
 	var oldRemAddr net.Addr
@@ -723,12 +732,28 @@ func (s *session) handlePacketImpl(p *receivedPacket) error {
 		return err
 	}
 
+	if !p.recovered && pth.closed.Get() {
+		// The application closed this path, only the acknowledgments of the packets still in flight on it are handled
+		var ackFrames []wire.Frame
+		for _, frame := range packet.frames {
+			if _, ok := frame.(*wire.AckFrame); ok {
+				ackFrames = append(ackFrames, frame)
+			}
+		}
+		return s.handleFrames(ackFrames, packet.encryptionLevel, pth)
+	}
+
 	err = s.handleFrames(packet.frames, packet.encryptionLevel, pth)
 
 	pth.rttStats.Windows = append(pth.rttStats.Windows, map[uint64]protocol.ByteCount{uint64(time.Now().UnixNano()): pth.sentPacketHandler.GetSendAlgorithm().GetCongestionWindow()})
 	// Now we potentially processed the PATHS frame with remote address ID, update remote address of all paths using the same remote address
 	// ID, to cope with, e.g., NAT rebinding detected on one of the paths.
 	if s.perspective == protocol.PerspectiveServer && oldRemAddr != p.remoteAddr {
+		// A new remote address must prove it is the peer before receiving data
+		addrChanged := oldRemAddr != nil && oldRemAddr.String() != p.remoteAddr.String()
+		if addrChanged && pth.pathID != protocol.InitialPathID {
+			pth.startValidation()
+		}
 		s.pathsLock.Lock()
 		for _, tmpPth := range s.paths {
 			if tmpPth == pth || !tmpPth.active.Get() {
@@ -736,6 +761,9 @@ func (s *session) handlePacketImpl(p *receivedPacket) error {
 			}
 			if tmpPth.remAddrID == pth.remAddrID {
 				tmpPth.conn.SetCurrentRemoteAddr(p.remoteAddr)
+				if addrChanged && tmpPth.pathID != protocol.InitialPathID {
+					tmpPth.startValidation()
+				}
 			}
 		}
 		s.pathsLock.Unlock()
@@ -798,6 +826,11 @@ func (s *session) handleFrames(fs []wire.Frame, encLevel protocol.EncryptionLeve
 				s.pathsLock.RUnlock()
 				s.pathManager.pconnMgr.PconnsLock().RUnlock()
 			}
+		case *wire.PathChallengeFrame:
+			// Prove that we receive on this path
+			err = s.SendPathValidationFrame(&wire.PathResponseFrame{Data: frame.Data}, p)
+		case *wire.PathResponseFrame:
+			p.handlePathResponse(frame)
 		case *wire.PathsFrame:
 			s.pathsLock.RLock()
 			for k, pathInfo := range frame.PathInfos {
@@ -819,6 +852,7 @@ func (s *session) handleFrames(fs []wire.Frame, encLevel protocol.EncryptionLeve
 					s.PathManager().remoteAddrIDOfComingPaths[k] = pathInfo.AddrID
 				}
 			}
+			s.PathManager().handlePathsFrame(frame)
 			s.pathsLock.RUnlock()
 		case *wire.SymbolAckFrame:
 			// log.Print("received SymbolAckFrame!")
@@ -884,17 +918,20 @@ func (s *session) handleStreamFrame(frame *wire.StreamFrame) error {
 		log.Printf("Info for stream %x of %x", frame.StreamID, s.connectionID)
 		for pathID, pth := range s.paths {
 			sntPkts, sntRetrans, sntLost := pth.sentPacketHandler.GetStatistics()
+			reinjected := pth.sentPacketHandler.GetReinjections()
 			rcvPkts, recoveredPkts := pth.receivedPacketHandler.GetStatistics()
-			log.Printf("Path %x: sent %d retrans %d lost %d; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, rcvPkts, recoveredPkts)
+			log.Printf("Path %x: sent %d retrans %d lost %d reinjected %d; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, reinjected, rcvPkts, recoveredPkts)
 			// modify -add
-			log.Printf("Number of recovered packets in all: %d", fec.NumberofRecoveredPacket)
+			log.Printf("Number of recovered packets in all: %d", s.numberOfRecoveredPackets())
+			evicted, unrecoverable := s.numberOfEvictedFECGroups()
+			log.Printf("Number of FEC groups evicted: %d, unrecoverable: %d", evicted, unrecoverable)
 			// utils.Infof("Redundancycontroller: D:%d,R:%d", s.redundancyController.GetNumberOfDataSymbols(), s.redundancyController.GetNumberOfRepairSymbols())
 			// utils.Infof("Redundancycontroller: D:%d,R:%d", s.fecFrameworkSender.redundancyController.GetNumberOfDataSymbols(), s.fecFrameworkSender.redundancyController.GetNumberOfRepairSymbols())
 		}
-		symbolSent := s.fecFrameworkSender.numberOfSymbols
-		symbolRcv := s.fecFrameworkReceiver.blockTracker.CountReceivedSymbol()
+		symbolSent := s.fecFrameworkSender.numberOfSymbols.Get()
+		symbolAcked := s.fecFrameworkSender.numberOfSymbolsAcked
 
-		log.Printf("Number of Symbol have been sent: %d, Acked: %d", symbolSent, symbolRcv)
+		log.Printf("Number of Symbol have been sent: %d, Acked: %d", symbolSent, symbolAcked)
 
 		s.pathsLock.RUnlock()
 	}
@@ -1043,7 +1080,6 @@ func (s *session) handleCloseError(closeErr closeError) error {
 }
 
 func (s *session) processTransportParameters(params *handshake.TransportParameters) {
-	// TODO: handle fecSchemeID
 	s.peerParams = params
 	s.streamsMap.UpdateMaxStreamLimit(params.MaxStreams)
 	if params.OmitConnectionID {
@@ -1058,8 +1094,27 @@ func (s *session) processTransportParameters(params *handshake.TransportParamete
 		s.maxPathID = params.MaxPathID
 	}
 	utils.Infof("PROCESS TRANSPORT PARAMS %+v", params.FECScheme)
-	s.senderFECScheme, _ = GetFECSchemeFromID(params.FECScheme)
+	peerFECSchemes := params.SupportedFECSchemes
+	if len(peerFECSchemes) == 0 {
+		// the peer does not negotiate: it decodes with its preferred FEC scheme, and encodes with ours
+		peerFECSchemes = []protocol.FECSchemeID{params.FECScheme}
+	}
+	// we encode with the FEC scheme that the peer decodes
+	if id, ok := fec.NegotiateScheme(params.FECScheme, peerFECSchemes, s.supportedFECSchemes); ok {
+		s.senderFECScheme, _ = GetFECSchemeFromID(id)
+	} else {
+		utils.Errorf("no FEC scheme in common with the peer, which supports %v", peerFECSchemes)
+		s.senderFECScheme = nil
+	}
 	s.fecFrameworkSender.fecScheme = s.senderFECScheme
+	// and the peer agreed on the same FEC scheme to encode the packets that we decode
+	if len(params.SupportedFECSchemes) > 0 {
+		if id, ok := fec.NegotiateScheme(s.config.FECScheme, s.supportedFECSchemes, params.SupportedFECSchemes); ok && id != s.config.FECScheme {
+			if fs, err := GetFECSchemeFromID(id); err == nil {
+				s.SetFECScheme(fs)
+			}
+		}
+	}
 }
 
 // 会引入重传，调用s.scheduler.sendPacket(s)
@@ -1085,6 +1140,7 @@ func (s *session) sendPackedPacket(packet *packedPacket, pth *path) error {
 		Frames:          packet.frames,
 		Length:          protocol.ByteCount(len(packet.raw)),
 		EncryptionLevel: packet.encryptionLevel,
+		Copies:          packet.copies,
 	}) //这里主要是拥塞控制相关
 	if err != nil {
 		return err
@@ -1098,10 +1154,11 @@ func (s *session) sendPackedPacket(packet *packedPacket, pth *path) error {
 				foundFinbitInStreamFrame = true
 				// Last packet to send on the stream, print stats
 				log.Printf("Info for stream %x of %x", frame.StreamID, s.GetConnectionID())
-				for pathID, pth := range s.Paths() {
+				for pathID, pth := range s.GetPaths() {
 					sntPkts, sntRetrans, sntLost := pth.sentPacketHandler.GetStatistics()
+					reinjected := pth.sentPacketHandler.GetReinjections()
 					rcvPkts, recoveredPkts := pth.receivedPacketHandler.GetStatistics()
-					log.Printf("Path %x: sent %d retrans %d lost %d; retransRatio %f lossRatio %f; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, float64(sntRetrans)/float64(sntPkts), float64(sntLost)/float64(sntPkts), rcvPkts, recoveredPkts)
+					log.Printf("Path %x: sent %d retrans %d lost %d reinjected %d; retransRatio %f lossRatio %f; rcv %d, recovered %d", pathID, sntPkts, sntRetrans, sntLost, reinjected, float64(sntRetrans)/float64(sntPkts), float64(sntLost)/float64(sntPkts), rcvPkts, recoveredPkts)
 				}
 
 			}
@@ -1111,7 +1168,7 @@ func (s *session) sendPackedPacket(packet *packedPacket, pth *path) error {
 	}
 
 	if foundFinbitInStreamFrame {
-		symbolSent := s.fecFrameworkSender.numberOfSymbols
+		symbolSent := s.fecFrameworkSender.numberOfSymbols.Get()
 		symbolRcv := s.fecFrameworkSender.numberOfSymbolsAcked
 		if symbolSent > 0 {
 			log.Printf("Number of Symbol have been sent: %d, Acked: %d, lossRate: %f", symbolSent, symbolRcv, 1-float64(symbolRcv)/float64(symbolSent))
@@ -1157,6 +1214,15 @@ func (s *session) SendPing(pth *path) error {
 	return s.sendPackedPacket(packet, pth)
 }
 
+// SendPathValidationFrame sends a PATH_CHALLENGE or a PATH_RESPONSE frame on pth
+func (s *session) SendPathValidationFrame(f wire.Frame, pth *path) error {
+	packet, err := s.packer.PackPathValidationPacket(f, pth)
+	if err != nil {
+		return err
+	}
+	return s.sendPackedPacket(packet, pth)
+}
+
 func (s *session) logPacket(packet *packedPacket, pathID protocol.PathID) {
 	if !utils.Debug() {
 		// We don't need to allocate the slices for calling the format functions
@@ -1331,14 +1397,51 @@ func (s *session) PathTimersChan() chan *path {
 	return s.pathTimers
 }
 
-func (s *session) Paths() map[protocol.PathID]*path {
+func (s *session) GetPaths() map[protocol.PathID]*path {
 	return s.paths
 }
 
+// Paths returns a snapshot of the state of the paths, sorted by ID
+func (s *session) Paths() []PathInfo {
+	s.pathsLock.RLock()
+	defer s.pathsLock.RUnlock()
+	return s.scheduler.getPathInfos(s.paths)
+}
+
+// OpenPath opens a path between the local and the remote address
+func (s *session) OpenPath(local net.Addr, remote net.Addr) (PathID, error) {
+	if s.pathManager == nil {
+		return 0, errors.New("multipath is not enabled on this session")
+	}
+	return s.pathManager.openPath(local, remote)
+}
+
+// SetBackupInterface marks the local interface ifName as backup or not
+func (s *session) SetBackupInterface(ifName string, backup bool) error {
+	if s.pathManager == nil {
+		return errors.New("multipath is not enabled on this session")
+	}
+	s.pathManager.setBackupInterface(ifName, backup)
+	return nil
+}
+
+// ClosePath stops sending on the path with the given ID
+func (s *session) ClosePath(id PathID) error {
+	if s.pathManager == nil {
+		return errors.New("multipath is not enabled on this session")
+	}
+	return s.pathManager.closePath(id)
+}
+
 func (s *session) PathsLock() *sync.RWMutex {
 	return &s.pathsLock
 }
 
+// RemoteRTTs returns the RTTs of the paths, as announced by the peer in PATHS frames
+func (s *session) RemoteRTTs() map[protocol.PathID]time.Duration {
+	return s.remoteRTTs
+}
+
 func (s *session) GetMaxPathID() protocol.PathID {
 	return s.maxPathID
 }
@@ -1375,12 +1478,16 @@ func (s *session) SetFECScheme(f fec.FECScheme) {
 	s.receiverFECScheme = f
 	if f2, ok := f.(fec.BlockFECScheme); ok {
 		if s.fecFrameworkReceiver == nil {
+			s.fecReceiversLock.Lock()
 			s.fecFrameworkReceiver = NewFECFrameworkReceiver(s, f2)
+			s.fecReceiversLock.Unlock()
 		}
 		s.fecFrameworkReceiver.fecScheme = f2
 	} else {
 		if s.fecFrameworkReceiverConvolutional == nil {
+			s.fecReceiversLock.Lock()
 			s.fecFrameworkReceiverConvolutional = NewFECFrameworkReceiverConvolutional(s, f.(fec.ConvolutionalFECScheme))
+			s.fecReceiversLock.Unlock()
 		}
 		s.fecFrameworkReceiverConvolutional.fecScheme = f.(fec.ConvolutionalFECScheme)
 	}
@@ -1402,26 +1509,95 @@ func (s *session) GetFECFrameworkSender() *FECFrameworkSender {
 	return s.fecFrameworkSender
 }
 
+// SetRedundancyController makes every path use c. The run loop swaps the controllers, as the FEC framework uses them.
 func (s *session) SetRedundancyController(c fec.RedundancyController) {
+	select {
+	case s.redundancyControllerChange <- c:
+	case <-s.ctx.Done():
+	}
+}
+
+// setRedundancyController is called by the run loop
+func (s *session) setRedundancyController(c fec.RedundancyController) {
+	s.redundancyControllerLock.Lock()
 	s.redundancyController = c
-	s.fecScheduler.SetRedundancyController(c)
-	s.fecFrameworkSender.redundancyController = c
+	s.redundancyControllerLock.Unlock()
+	s.fecFrameworkSender.setRedundancyController(c)
 	s.scheduler.redundancyController = c
+	s.pathsLock.RLock()
+	for _, pth := range s.paths {
+		pth.setRedundancyController(c)
+	}
+	s.pathsLock.RUnlock()
+}
+
+// newRedundancyController returns the redundancy controller of a new path
+func (s *session) newRedundancyController() fec.RedundancyController {
+	if s.config.NewRedundancyController != nil {
+		return s.config.NewRedundancyController()
+	}
+	if s.config.RedundancyController != nil {
+		// A single instance was configured, all the paths share it
+		return s.config.RedundancyController
+	}
+	return fec.NewrQuicRedundancyControllerWithInterleaving(
+		s.config.NumberOfFECPackets,
+		s.config.NumberOfRepairSymbols,
+		s.config.NumberOfInterleavedFECGroups,
+		s.config.ConvolutionalStepSize)
+}
+
+// FECStats returns a snapshot of the FEC counters of the sender and of the receivers of the session
+func (s *session) FECStats() FECStats {
+	var stats FECStats
+	if s.fecFrameworkSender != nil {
+		stats = s.fecFrameworkSender.stats()
+	}
+	s.fecReceiversLock.RLock()
+	defer s.fecReceiversLock.RUnlock()
+	if s.fecFrameworkReceiver != nil {
+		s.fecFrameworkReceiver.addStats(&stats)
+	}
+	if s.fecFrameworkReceiverConvolutional != nil {
+		s.fecFrameworkReceiverConvolutional.addStats(&stats)
+	}
+	return stats
+}
+
+// numberOfRecoveredPackets returns the number of packets recovered by the FEC receivers of the session
+func (s *session) numberOfRecoveredPackets() uint64 {
+	stats := s.FECStats()
+	return stats.RecoveredPacketsBlock + stats.RecoveredPacketsConvolutional
+}
+
+// numberOfEvictedFECGroups returns the number of FEC groups given up by the FEC receivers of the session to bound their
+// memory, and among them, the number of groups that missed packets
+func (s *session) numberOfEvictedFECGroups() (evicted uint64, unrecoverable uint64) {
+	stats := s.FECStats()
+	return stats.EvictedFECGroups, stats.UnrecoverableFECGroups
+}
+
+// getSymbolACKFrame returns the SymbolAckFrame of the FEC receiver of the session, if it has something to acknowledge
+// in at most maxLength bytes
+func (s *session) getSymbolACKFrame(maxLength protocol.ByteCount) *wire.SymbolAckFrame {
+	if s.fecFrameworkReceiverConvolutional != nil {
+		if frame := s.fecFrameworkReceiverConvolutional.GetSymbolACKFrame(maxLength); frame != nil {
+			return frame
+		}
+	}
+	if s.fecFrameworkReceiver != nil {
+		return s.fecFrameworkReceiver.GetSymbolACKFrame(maxLength)
+	}
+	return nil
 }
 
 func (s *session) GetRedundancyController() fec.RedundancyController {
+	s.redundancyControllerLock.RLock()
+	defer s.redundancyControllerLock.RUnlock()
 	return s.redundancyController
 }
 
+// GetFECSchemeFromID creates the built-in or registered FEC scheme with the given ID
 func GetFECSchemeFromID(id protocol.FECSchemeID) (fec.FECScheme, error) {
-	switch id {
-	case protocol.XORFECScheme:
-		return &fec.XORFECScheme{}, nil
-	case protocol.ReedSolomonFECScheme:
-		return fec.NewReedSolomonFECScheme()
-	case protocol.RLCFECScheme:
-		return fec.NewRandomLinearFECScheme(), nil
-	default:
-		return nil, errors.New(fmt.Sprintf("There is no FEC Scheme "))
-	}
+	return fec.NewScheme(id)
 }
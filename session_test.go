@@ -1,5 +1,16 @@
 package quic
 
+import (
+	"context"
+
+	"github.com/lucas-clemente/quic-go/fec"
+	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
 // import (
 // 	"bytes"
 // 	"context"
@@ -1626,3 +1637,83 @@ package quic
 // 		close(done)
 // 	})
 // })
+
+var _ = Describe("Session", func() {
+	Context("redundancy controller", func() {
+		var sess *session
+
+		BeforeEach(func() {
+			sess = &session{redundancyControllerChange: make(chan fec.RedundancyController, 1)}
+			sess.ctx, sess.ctxCancel = context.WithCancel(context.Background())
+		})
+
+		It("lets the run loop swap the redundancy controller", func() {
+			rc := fec.NewConstantRedundancyController(5, 3, 1, 1)
+			sess.SetRedundancyController(rc)
+			Expect(sess.GetRedundancyController()).To(BeNil())
+			Expect(sess.redundancyControllerChange).To(Receive(BeIdenticalTo(rc)))
+		})
+
+		It("doesn't block once the session is closed", func() {
+			sess.ctxCancel()
+			done := make(chan struct{})
+			go func() {
+				sess.SetRedundancyController(fec.NewConstantRedundancyController(5, 3, 1, 1))
+				sess.SetRedundancyController(fec.NewConstantRedundancyController(5, 3, 1, 1))
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("makes the paths and the FEC framework use the new controller", func() {
+			scheme, err := fec.NewReedSolomonFECScheme()
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkSender = NewFECFrameworkSender(scheme, newFECFramer(nil, protocol.VersionWhatever), fec.NewConstantRedundancyController(10, 2, 1, 4), protocol.VersionWhatever, nil)
+			sess.scheduler = &scheduler{}
+			sess.paths = map[protocol.PathID]*path{1: newTestPath(sess, 1)}
+			rc := fec.NewConstantRedundancyController(5, 3, 1, 1)
+			sess.setRedundancyController(rc)
+			Expect(sess.GetRedundancyController()).To(BeIdenticalTo(rc))
+			Expect(sess.scheduler.redundancyController).To(BeIdenticalTo(rc))
+			Expect(sess.paths[1].redundancyController).To(BeIdenticalTo(rc))
+			Expect(sess.fecFrameworkSender.getRedundancyController(1)).To(BeIdenticalTo(rc))
+		})
+	})
+
+	Context("FEC scheme negotiation", func() {
+		var sess *session
+
+		BeforeEach(func() {
+			sess = &session{
+				config:              &Config{FECScheme: protocol.ReedSolomonFECScheme},
+				supportedFECSchemes: []protocol.FECSchemeID{protocol.ReedSolomonFECScheme, protocol.XORFECScheme},
+				connFlowController:  flowcontrol.NewConnectionFlowController(1000, 1000, nil, nil),
+				streamsMap:          newStreamsMap(nil, protocol.PerspectiveClient, protocol.VersionMP),
+			}
+			scheme, err := fec.NewReedSolomonFECScheme()
+			Expect(err).ToNot(HaveOccurred())
+			sess.senderFECScheme = scheme
+			sess.fecFrameworkSender = NewFECFrameworkSender(scheme, newFECFramer(nil, protocol.VersionMP), fec.NewConstantRedundancyController(10, 2, 1, 4), protocol.VersionMP, nil)
+		})
+
+		It("encodes with the FEC scheme that the peer decodes", func() {
+			sess.processTransportParameters(&handshake.TransportParameters{
+				FECScheme:           protocol.XORFECScheme,
+				SupportedFECSchemes: []protocol.FECSchemeID{protocol.XORFECScheme},
+			})
+			Expect(sess.senderFECScheme).To(BeAssignableToTypeOf(&fec.XORFECScheme{}))
+			Expect(sess.fecFrameworkSender.fecScheme).To(BeIdenticalTo(sess.senderFECScheme))
+		})
+
+		It("stops protecting the packets without a FEC scheme in common", func() {
+			sess.processTransportParameters(&handshake.TransportParameters{
+				FECScheme:           protocol.RaptorQFECScheme,
+				SupportedFECSchemes: []protocol.FECSchemeID{protocol.RaptorQFECScheme},
+			})
+			Expect(sess.senderFECScheme).To(BeNil())
+			Expect(sess.fecFrameworkSender.fecScheme).To(BeNil())
+			err := sess.fecFrameworkSender.GenerateRepairSymbols(fec.NewFECGroup(1, protocol.VersionMP), 1, protocol.NewBlockSourceFECPayloadID(1, 0))
+			Expect(err).To(MatchError(FECFrameworkSenderNoFECScheme))
+		})
+	})
+})
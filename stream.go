@@ -84,6 +84,7 @@ type stream struct {
 
 	unreliable		 					bool
 	retransmissionDeadline	time.Duration
+	fecProtection          protocol.FECProtectionLevel
 
 	replayBufferSize 	uint64
 	bufferEnd   protocol.ByteCount
@@ -632,6 +633,18 @@ func (s *stream) SetUnreliable(val bool) {
 	s.frameQueue.unreliable = val
 }
 
+func (s *stream) SetFECProtection(level protocol.FECProtectionLevel) {
+	s.mutex.Lock()
+	s.fecProtection = level
+	s.mutex.Unlock()
+}
+
+func (s *stream) GetFECProtection() protocol.FECProtectionLevel {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.fecProtection
+}
+
 func (s *stream) SetMessageMode(val bool) {
 	s.messageMode = val
 }
@@ -52,12 +52,12 @@ func (f *streamFramer) AddFrameForRetransmission(frame *wire.StreamFrame) {
 }
 
 func (f *streamFramer) PopStreamFrames(maxLen protocol.ByteCount, unreliableLenPenalty protocol.ByteCount) []*wire.StreamFrame {
-	fs, currentLen, containsUnreliable := f.maybePopFramesForRetransmission(maxLen, unreliableLenPenalty)
-	if containsUnreliable || (f.protectReliableStreamFrames && len(fs) > 0) {
+	fs, currentLen, containsUnreliable, fecProtectionLevel := f.maybePopFramesForRetransmission(maxLen, unreliableLenPenalty)
+	if containsUnreliable {
 		// note: FECHeaderOverhead  is used to represent the presence of the ProtectedPayloadLength (2 bytes) and the FEC group (6 bytes) in the packet header when the packet contains unreliable stream frames
 		maxLen -= unreliableLenPenalty
 	}
-	streamFrames := f.maybePopNormalFrames(maxLen-currentLen, containsUnreliable || (f.protectReliableStreamFrames && len(fs) > 0), unreliableLenPenalty)
+	streamFrames := f.maybePopNormalFrames(maxLen-currentLen, containsUnreliable, fecProtectionLevel, unreliableLenPenalty)
 	return append(fs, streamFrames...)
 }
 
@@ -140,6 +140,17 @@ func (f *streamFramer) HasFramesToSend() bool {
 	return false
 }
 
+// LenOfDataToSend returns the number of stream bytes waiting to be sent, including retransmissions
+func (f *streamFramer) LenOfDataToSend() protocol.ByteCount {
+	var l protocol.ByteCount
+	for _, frame := range f.retransmissionQueue {
+		l += frame.DataLen()
+	}
+	f.streamsMap.Range(func(s streamI) {
+		l += s.LenOfDataForWriting()
+	})
+	return l
+}
 
 func (f *streamFramer) HasCryptoStreamFrame() bool {
 	return f.cryptoStream.LenOfDataForWriting() > 0
@@ -159,7 +170,21 @@ func (f *streamFramer) PopCryptoStreamFrame(maxLen protocol.ByteCount) *wire.Str
 	return frame
 }
 
-func (f *streamFramer) maybePopFramesForRetransmission(maxTotalLen protocol.ByteCount, unreliableLenPenalty protocol.ByteCount) (res []*wire.StreamFrame, currentLen protocol.ByteCount, containsUnreliable bool) {
+// fecProtected returns true if the stream frames of a stream with this FEC protection level must be protected by FEC
+func (f *streamFramer) fecProtected(level protocol.FECProtectionLevel, unreliable bool) bool {
+	switch level {
+	case protocol.FECProtectionDefault:
+		return unreliable || f.protectReliableStreamFrames
+	case protocol.FECProtectionNone:
+		return false
+	default:
+		return true
+	}
+}
+
+// maybePopFramesForRetransmission pops the stream frames to retransmit. The FEC-protected frames of a packet all have
+// the same FEC protection level, returned with them.
+func (f *streamFramer) maybePopFramesForRetransmission(maxTotalLen protocol.ByteCount, unreliableLenPenalty protocol.ByteCount) (res []*wire.StreamFrame, currentLen protocol.ByteCount, containsUnreliable bool, fecProtectionLevel protocol.FECProtectionLevel) {
 	containsUnreliable = false
 
 	for len(f.retransmissionQueue) > 0 {
@@ -168,16 +193,21 @@ func (f *streamFramer) maybePopFramesForRetransmission(maxTotalLen protocol.Byte
 			delete(f.isInRetransmissionQueue, frame)
 			f.retransmissionQueue = f.retransmissionQueue[1:]
 			continue
-		} else if frame.Unreliable || f.protectReliableStreamFrames {
+		} else if f.fecProtected(frame.FECProtectionLevel, frame.Unreliable) {
 			if maxTotalLen <= unreliableLenPenalty {
 				break
 			}
+			if containsUnreliable && frame.FECProtectionLevel != fecProtectionLevel {
+				// the frames of different protection levels are protected by different FEC blocks
+				break
+			}
 		}
 		frame.DataLenPresent = true
 
 		// TODO: possible underflow when decreasing maxLen
-		if (f.protectReliableStreamFrames || frame.Unreliable) && !containsUnreliable {	// remove 8 bytes of maxLen if frame is unreliable, and ensure that we only do this once
+		if f.fecProtected(frame.FECProtectionLevel, frame.Unreliable) && !containsUnreliable {	// remove 8 bytes of maxLen if frame is unreliable, and ensure that we only do this once
 			containsUnreliable = true
+			fecProtectionLevel = frame.FECProtectionLevel
 			maxTotalLen -= unreliableLenPenalty
 		}
 
@@ -236,7 +266,9 @@ func (f *streamFramer) maybePopFramesForRetransmission(maxTotalLen protocol.Byte
 	return
 }
 
-func (f *streamFramer) maybePopNormalFrames(maxTotalLen protocol.ByteCount, containsUnreliable bool, unreliableLenPenalty protocol.ByteCount) (res []*wire.StreamFrame) {
+// maybePopNormalFrames pops the new stream frames. If the packet already contains FEC-protected frames, only the
+// streams of their FEC protection level may add protected frames to it.
+func (f *streamFramer) maybePopNormalFrames(maxTotalLen protocol.ByteCount, containsUnreliable bool, fecProtectionLevel protocol.FECProtectionLevel, unreliableLenPenalty protocol.ByteCount) (res []*wire.StreamFrame) {
 	frame := &wire.StreamFrame{DataLenPresent: true}
 	var currentLen protocol.ByteCount
 	fn := func(s streamI) (bool, error) {
@@ -244,9 +276,18 @@ func (f *streamFramer) maybePopNormalFrames(maxTotalLen protocol.ByteCount, cont
 			return true, nil
 		}
 
+		level := s.GetFECProtection()
 		// added by michelfra: this if
-		if f.protectReliableStreamFrames || s.IsUnreliable() {
+		if f.fecProtected(level, s.IsUnreliable()) {
+			if containsUnreliable && level != fecProtectionLevel {
+				// the frames of different protection levels are protected by different FEC blocks
+				return true, nil
+			}
 			if !containsUnreliable {// remove bytes of maxLen if frame is unreliable, and ensure that we only do this once
+				if s.LenOfDataForWriting() == 0 && !s.ShouldSendFin() {
+					// a stream with nothing to send doesn't set the protection level of the packet
+					return true, nil
+				}
 				if maxTotalLen <= unreliableLenPenalty {
 					// Not enough space to have the additional header bytes of a FEC-protected packet. Continue to find a Reliable Stream
 					return true, nil
@@ -254,6 +295,7 @@ func (f *streamFramer) maybePopNormalFrames(maxTotalLen protocol.ByteCount, cont
 				// remove the two + six bytes that will be taken by the header for the FECProtectedPayloadLength and FECBlockNumber fields
 				maxTotalLen -= unreliableLenPenalty
 				containsUnreliable = true
+				fecProtectionLevel = level
 			}
 		}
 
@@ -262,6 +304,7 @@ func (f *streamFramer) maybePopNormalFrames(maxTotalLen protocol.ByteCount, cont
 		frame.Offset = s.GetWriteOffset()
 
 		frame.Unreliable = s.IsUnreliable()
+		frame.FECProtectionLevel = level
 		if s.IsUnreliable() {
 			frame.RetransmitDeadline = s.GetRetransmissionDeadLine()
 		}
@@ -359,5 +402,6 @@ func maybeSplitOffFrame(frame *wire.StreamFrame, n protocol.ByteCount) *wire.Str
 		Data:           frame.Data[:n],
 		DataLenPresent: frame.DataLenPresent,
 		Unreliable: 		frame.Unreliable,
+		FECProtectionLevel: frame.FECProtectionLevel,
 	}
 }